@@ -0,0 +1,295 @@
+package w3g
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ContentEncoder returns a writer that compresses what is written to it with a content coding and writes the result
+// to w. Closing the writer must flush the compressed data without closing w.
+type ContentEncoder func(w io.Writer) io.WriteCloser
+
+// Compressor is a struct to configure a middleware that compresses responses with the content coding negotiated from
+// the Accept-Encoding HTTP header of a request. Encodings lists the content codings of Encoders in order of the server's
+// preference, which breaks ties between codings that a client accepts equally. Responses shorter than MinLength bytes
+// and responses whose media type is not compressible are not compressed.
+type Compressor struct {
+	Encoders  map[string]ContentEncoder `json:"-"`
+	Encodings []string                  `json:"encodings"`
+	MinLength int                       `json:"min_length"`
+}
+
+// compressWriter is a http.ResponseWriter that buffers the start of a response until it can decide whether to
+// compress it.
+type compressWriter struct {
+	http.ResponseWriter
+	buf        []byte
+	code       int
+	coding     string
+	compressor *Compressor
+	decided    bool
+	encoder    io.WriteCloser
+	method     string
+}
+
+// flusher is implemented by encoders that can flush buffered compressed data, such as gzip.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// NewCompressor returns a Compressor that supports the gzip and deflate content codings, preferring gzip, and does not
+// compress responses shorter than 1024 bytes.
+func NewCompressor() *Compressor {
+	var c *Compressor = &Compressor{Encoders: map[string]ContentEncoder{}, MinLength: 1024}
+	c.Register("deflate", func(w io.Writer) io.WriteCloser {
+		return zlib.NewWriter(w)
+	})
+	c.Register("gzip", func(w io.Writer) io.WriteCloser {
+		return gzip.NewWriter(w)
+	})
+	return c
+}
+
+// NegotiateEncoding chooses a content coding from the codings a server supports, in order of its preference, and the
+// parsed Accept-Encoding HTTP header of a request, as defined by RFC 9110. A coding that is not listed is acceptable
+// when * is, and identity is acceptable unless it, or * without an identity entry, has a quality value of 0.
+// Identity is only chosen over another acceptable coding when the client prefers it. The returned bool is false when
+// no coding, including identity, is acceptable.
+func NegotiateEncoding(accept []AcceptEncodingHeader, available []string) (string, bool) {
+	var quality = func(coding string) (float32, bool) {
+		var wildcard float32 = -1
+		for _, a := range accept {
+			if strings.EqualFold(a.Encoding, coding) {
+				return a.Q, true
+			}
+			if a.Encoding == "*" {
+				wildcard = a.Q
+			}
+		}
+		if wildcard >= 0 {
+			return wildcard, true
+		}
+		return 0, false
+	}
+	var best string
+	var bestQ float32
+	for _, coding := range available {
+		if q, _ := quality(coding); q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	var identityQ, listed = quality("identity")
+	if !listed {
+		identityQ = 1
+		if len(best) != 0 {
+			return best, true
+		}
+	}
+	if identityQ > bestQ {
+		return "identity", true
+	}
+	return best, len(best) != 0
+}
+
+// ParseAcceptEncoding parses an Accept-Encoding HTTP header value into its codings in the order they are listed,
+// including codings with a quality value of 0, which are not acceptable. A coding without a quality value has a Q of 1.
+func ParseAcceptEncoding(s string) ([]AcceptEncodingHeader, error) {
+	var codings []AcceptEncodingHeader = []AcceptEncodingHeader{}
+	for _, member := range strings.Split(s, ",") {
+		var params []string = strings.Split(member, ";")
+		var a AcceptEncodingHeader = AcceptEncodingHeader{Encoding: strings.ToLower(strings.TrimSpace(params[0])), Q: 1}
+		if len(a.Encoding) == 0 && len(params) == 1 {
+			continue
+		}
+		if !isToken(a.Encoding) {
+			return nil, &HeaderValueError{Header: AcceptEncoding, Value: s}
+		}
+		for _, param := range params[1:] {
+			var name, value, _ = strings.Cut(strings.TrimSpace(param), "=")
+			if strings.ToLower(strings.TrimSpace(name)) != "q" {
+				continue
+			}
+			var q, err = strconv.ParseFloat(strings.TrimSpace(value), 32)
+			if err != nil || q < 0 || q > 1 {
+				return nil, &HeaderValueError{Header: AcceptEncoding, Value: s}
+			}
+			a.Q = float32(q)
+		}
+		codings = append(codings, a)
+	}
+	return codings, nil
+}
+
+// Handler returns a http.Handler that compresses the responses of next with the content coding negotiated for each
+// request. Compressed responses have a Content-Encoding HTTP header, no Content-Length or Accept-Ranges HTTP
+// headers, and a weak ETag, since the compressed representation is not byte-for-byte the same. Every response that
+// could have been compressed has Accept-Encoding added to its Vary HTTP header.
+func (c *Compressor) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var coding string = "identity"
+		if values := r.Header.Values(AcceptEncoding); len(values) != 0 {
+			var accept, err = ParseAcceptEncoding(strings.Join(values, ","))
+			if err == nil {
+				if negotiated, ok := NegotiateEncoding(accept, c.Encodings); ok {
+					coding = negotiated
+				}
+			}
+		}
+		var cw *compressWriter = &compressWriter{ResponseWriter: w, coding: coding, compressor: c, method: r.Method}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// Register adds a ContentEncoder for a content coding, such as br or zstd, and makes it the most preferred coding.
+func (c *Compressor) Register(coding string, encoder ContentEncoder) {
+	coding = strings.ToLower(coding)
+	if c.Encoders == nil {
+		c.Encoders = map[string]ContentEncoder{}
+	}
+	c.Encoders[coding] = encoder
+	var encodings []string = []string{coding}
+	for _, e := range c.Encodings {
+		if e != coding {
+			encodings = append(encodings, e)
+		}
+	}
+	c.Encodings = encodings
+}
+
+// Flush decides whether to compress the response, when it has not been decided yet, and flushes buffered data to
+// the client.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if f, ok := w.encoder.(flusher); ok {
+		f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use by http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Write buffers data until MinLength bytes have been written, and then writes it through the encoder when the
+// response is compressed.
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.compressor.MinLength {
+			return len(b), nil
+		}
+		if err := w.decide(false); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteHeader records the status code of the response. Informational responses are sent immediately, and the
+// response headers are sent once the response is known not to be compressed or its first MinLength bytes are written.
+func (w *compressWriter) WriteHeader(code int) {
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.code != 0 {
+		return
+	}
+	w.code = code
+	if !w.compress(false) {
+		w.decide(false)
+	}
+}
+
+// close decides whether to compress a response that was shorter than MinLength bytes and closes the encoder.
+func (w *compressWriter) close() {
+	if !w.decided {
+		if w.code == 0 {
+			w.code = http.StatusOK
+		}
+		w.decide(true)
+	}
+	if w.encoder != nil {
+		w.encoder.Close()
+	}
+}
+
+// compress reports whether the response is compressed. When final is true the whole response has been buffered and
+// is compared with MinLength.
+func (w *compressWriter) compress(final bool) bool {
+	if w.coding == "identity" || w.compressor.Encoders[w.coding] == nil || w.method == http.MethodHead || !w.eligible() {
+		return false
+	}
+	if n, err := strconv.Atoi(w.Header().Get(ContentLength)); err == nil && n < w.compressor.MinLength {
+		return false
+	}
+	return (!final || len(w.buf) >= w.compressor.MinLength)
+}
+
+// decide sends the response headers, compressing the response when it can be compressed, and writes the buffered data.
+func (w *compressWriter) decide(final bool) error {
+	w.decided = true
+	var h http.Header = w.Header()
+	if _, ok := h[ContentType]; !ok && len(w.buf) != 0 {
+		h.Set(ContentType, http.DetectContentType(w.buf))
+	}
+	if w.eligible() {
+		addVary(h, AcceptEncoding)
+	}
+	if w.compress(final) {
+		h.Set(ContentEncoding, w.coding)
+		h.Del(ContentLength)
+		h.Del(AcceptRanges)
+		if etag := h.Get(ETag); len(etag) != 0 && !strings.HasPrefix(etag, "W/") {
+			h.Set(ETag, "W/"+etag)
+		}
+		w.encoder = w.compressor.Encoders[w.coding](w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+	if len(w.buf) == 0 {
+		return nil
+	}
+	var buf []byte = w.buf
+	w.buf = nil
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// eligible reports whether the status code and headers of the response allow it to be compressed, whatever the
+// negotiated content coding.
+func (w *compressWriter) eligible() bool {
+	var h http.Header = w.Header()
+	switch {
+	case w.code < 200, w.code == http.StatusNoContent, w.code == http.StatusPartialContent, w.code == http.StatusNotModified:
+		return false
+	case len(h.Get(ContentEncoding)) != 0, strings.Contains(strings.ToLower(h.Get(CacheControl)), "no-transform"):
+		return false
+	}
+	if v := h.Get(ContentType); len(v) != 0 {
+		var m, err = ParseMediaType(v)
+		return (err == nil && m.Compressible())
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+package w3g_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	var available []string = []string{"gzip", "deflate"}
+	for _, test := range []struct {
+		accept string
+		coding string
+		ok     bool
+	}{
+		{"gzip, deflate", "gzip", true},
+		{"deflate, gzip;q=0.9", "deflate", true},
+		{"gzip;q=0.5, identity", "identity", true},
+		{"identity;q=0, gzip;q=0.1", "gzip", true},
+		{"br", "identity", true},
+		{"*", "gzip", true},
+		{"*;q=0", "", false},
+		{"*;q=0, deflate;q=0.5", "deflate", true},
+		{"gzip;q=0, deflate;q=0", "identity", true},
+		{"", "identity", true},
+	} {
+		var accept, err = w3g.ParseAcceptEncoding(test.accept)
+		if err != nil {
+			t.Fatalf("ParseAcceptEncoding(%q): %v", test.accept, err)
+		}
+		if coding, ok := w3g.NegotiateEncoding(accept, available); coding != test.coding || ok != test.ok {
+			t.Errorf("NegotiateEncoding(%q) = %q, %v, want %q, %v", test.accept, coding, ok, test.coding, test.ok)
+		}
+	}
+	if _, err := w3g.ParseAcceptEncoding("gzip;q=1.5"); err == nil {
+		t.Error("ParseAcceptEncoding(\"gzip;q=1.5\") did not return an error")
+	}
+}
+
+func TestCompressor(t *testing.T) {
+	var body string = strings.Repeat("compressible text ", 200)
+	var handler http.Handler = w3g.NewCompressor().Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small":
+			w.Write([]byte("small"))
+		case "/image":
+			w.Header().Set(w3g.ContentType, "image/png")
+			w.Write([]byte(body))
+		case "/no-transform":
+			w.Header().Set(w3g.CacheControl, "no-transform")
+			w.Write([]byte(body))
+		default:
+			w.Header().Set(w3g.ContentType, "text/plain; charset=utf-8")
+			w.Header().Set(w3g.ContentLength, "3600")
+			w.Header().Set(w3g.ETag, `"v1"`)
+			w.Write([]byte(body[:1000]))
+			w.Write([]byte(body[1000:]))
+		}
+	}))
+	var serve = func(path, accept string) *httptest.ResponseRecorder {
+		var r *http.Request = httptest.NewRequest(http.MethodGet, path, nil)
+		if len(accept) != 0 {
+			r.Header.Set(w3g.AcceptEncoding, accept)
+		}
+		var w *httptest.ResponseRecorder = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	var w *httptest.ResponseRecorder = serve("/text", "gzip, deflate")
+	if got := w.Header().Get(w3g.ContentEncoding); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := w.Header().Get(w3g.ContentLength); len(got) != 0 {
+		t.Errorf("Content-Length = %q, want none", got)
+	}
+	if got := w.Header().Get(w3g.ETag); got != `W/"v1"` {
+		t.Errorf("ETag = %q, want W/\"v1\"", got)
+	}
+	if got := w.Header().Get(w3g.Vary); got != w3g.AcceptEncoding {
+		t.Errorf("Vary = %q, want Accept-Encoding", got)
+	}
+	var zr, err = gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(zr); string(b) != body {
+		t.Errorf("decompressed body has %d bytes, want %d", len(b), len(body))
+	}
+
+	w = serve("/text", "")
+	if got := w.Header().Get(w3g.ContentEncoding); len(got) != 0 {
+		t.Errorf("Content-Encoding = %q without Accept-Encoding", got)
+	}
+	if got := w.Header().Get(w3g.Vary); got != w3g.AcceptEncoding {
+		t.Errorf("Vary = %q without Accept-Encoding, want Accept-Encoding", got)
+	}
+	if w.Body.String() != body || w.Header().Get(w3g.ETag) != `"v1"` {
+		t.Error("identity response was modified")
+	}
+
+	for _, path := range []string{"/small", "/image", "/no-transform"} {
+		w = serve(path, "gzip")
+		if got := w.Header().Get(w3g.ContentEncoding); len(got) != 0 {
+			t.Errorf("%s: Content-Encoding = %q, want none", path, got)
+		}
+		if path != "/small" && w.Body.String() != body {
+			t.Errorf("%s: body was modified", path)
+		}
+	}
+	if got := serve("/small", "gzip").Body.String(); got != "small" {
+		t.Errorf("/small: body = %q", got)
+	}
+	if got := serve("/small", "gzip").Header().Get(w3g.ContentType); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("/small: Content-Type = %q, want a sniffed text/plain", got)
+	}
+}
+
+func TestCompressorRegister(t *testing.T) {
+	var c *w3g.Compressor = w3g.NewCompressor()
+	c.MinLength = 0
+	c.Register("upper", func(w io.Writer) io.WriteCloser {
+		return &upperWriter{w: w}
+	})
+	var handler http.Handler = c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(w3g.ContentType, "text/plain")
+		w.Write([]byte("hello"))
+	}))
+	var r *http.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(w3g.AcceptEncoding, "gzip, upper")
+	var w *httptest.ResponseRecorder = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if got := w.Header().Get(w3g.ContentEncoding); got != "upper" {
+		t.Fatalf("Content-Encoding = %q, want upper", got)
+	}
+	if got := w.Body.String(); got != "HELLO" {
+		t.Errorf("body = %q, want HELLO", got)
+	}
+}
+
+type upperWriter struct {
+	w io.Writer
+}
+
+func (u *upperWriter) Close() error {
+	return nil
+}
+
+func (u *upperWriter) Write(b []byte) (int, error) {
+	return u.w.Write(bytes.ToUpper(b))
+}
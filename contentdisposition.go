@@ -0,0 +1,145 @@
+package w3g
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxFileNameLength is the length in bytes to which SanitizeFileName truncates a file name.
+const maxFileNameLength int = 255
+
+// windowsReservedNames are file names that cannot be used on Windows, with or without an extension.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// ParseContentDisposition parses a Content-Disposition HTTP header value as defined by RFC 6266. A filename*
+// parameter is preferred over a filename parameter. Disposition types other than inline and form-data are treated as
+// attachment, as RFC 6266 requires. The FileName of the result is not sanitised; use SafeFileName before using it
+// on disk.
+func ParseContentDisposition(s string) (ContentDispositionHeader, error) {
+	var c ContentDispositionHeader
+	var params []string = splitQuoted(s, ';')
+	var disposition string = strings.ToLower(params[0])
+	if !isToken(disposition) {
+		return c, &HeaderValueError{Header: ContentDisposition, Value: s}
+	}
+	switch disposition {
+	case "inline":
+		c.Inline = true
+	case "form-data":
+		c.FormData = true
+	default:
+		c.Attachment = true
+	}
+	var extended bool
+	var seen map[string]bool = map[string]bool{}
+	for _, param := range params[1:] {
+		if len(param) == 0 {
+			continue
+		}
+		var name, value, ok = strings.Cut(param, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if !ok || !isToken(name) {
+			return ContentDispositionHeader{}, &HeaderValueError{Header: ContentDisposition, Value: s}
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		switch name {
+		case "filename*":
+			if v, ok := decodeExtValue(value); ok {
+				c.FileName = v
+				extended = true
+			}
+		case "filename", "name":
+			if unquoted, ok := unquoteString(value); ok {
+				value = unquoted
+			} else if strings.HasPrefix(value, "\"") {
+				return ContentDispositionHeader{}, &HeaderValueError{Header: ContentDisposition, Value: s}
+			}
+			if name == "name" {
+				c.Name = value
+			} else if !extended {
+				c.FileName = value
+			}
+		}
+	}
+	return c, nil
+}
+
+// SanitizeFileName returns a file name that is safe to create in a directory: the last element of a path, without
+// control characters, characters that Windows does not allow, leading dots or trailing dots and spaces, and at most
+// 255 bytes long. An empty string is returned when nothing of the name remains.
+func SanitizeFileName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if name = path.Base(name); name == "/" {
+		return ""
+	}
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+		case strings.ContainsRune("<>:\"/|?*", r):
+			b.WriteByte('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	name = strings.TrimRight(strings.TrimLeft(b.String(), ". "), ". ")
+	if len(name) > maxFileNameLength {
+		var ext string = path.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		var i int = maxFileNameLength - len(ext)
+		for i > 0 && !utf8.RuneStart(name[i]) {
+			i--
+		}
+		name = name[:i] + ext
+	}
+	var stem string = strings.ToUpper(strings.TrimSpace(strings.SplitN(name, ".", 2)[0]))
+	if windowsReservedNames[stem] {
+		name = "_" + name
+	}
+	return name
+}
+
+// SafeFileName returns the FileName of a Content-Disposition HTTP header sanitised by SanitizeFileName.
+func (c ContentDispositionHeader) SafeFileName() string {
+	return SanitizeFileName(c.FileName)
+}
+
+// asciiFileName returns a file name with every character that is not printable ASCII replaced by an underscore.
+func asciiFileName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r < 0x20 || r > 0x7e {
+			b.WriteByte('_')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// escapeFormDataName escapes the name of a form-data field as the HTML specification requires, percent-encoding
+// double quotes, CR and LF, and every other control character so that none reaches the quoted name parameter.
+func escapeFormDataName(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c == '"' || c < 0x20 || c == 0x7f {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
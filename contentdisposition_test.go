@@ -0,0 +1,78 @@
+package w3g_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestContentDispositionHeader(t *testing.T) {
+	var tests = []struct {
+		header w3g.ContentDispositionHeader
+		want   string
+	}{
+		{w3g.ContentDispositionHeader{Attachment: true, FileName: "hello.html"}, `attachment; filename="hello.html"`},
+		{w3g.ContentDispositionHeader{Attachment: true, FileName: `say "hi".txt`}, `attachment; filename="say \"hi\".txt"`},
+		{w3g.ContentDispositionHeader{Attachment: true, FileName: "€ rates.pdf"}, `attachment; filename="_ rates.pdf"; filename*=UTF-8''%E2%82%AC%20rates.pdf`},
+		{w3g.ContentDispositionHeader{Inline: true}, "inline"},
+		{w3g.ContentDispositionHeader{FormData: true, Name: "field\"\r\n", FileName: "a.txt"}, `form-data; name="field%22%0D%0A"; filename="a.txt"`},
+		{w3g.ContentDispositionHeader{FormData: true, Name: "a\x00b\tc\x7f"}, `form-data; name="a%00b%09c%7F"`},
+	}
+	for _, test := range tests {
+		if got := test.header.String(); got != test.want {
+			t.Errorf("%+v.String() = %q, want %q", test.header, got, test.want)
+		}
+	}
+}
+
+func TestParseContentDisposition(t *testing.T) {
+	var tests = []struct {
+		s    string
+		want w3g.ContentDispositionHeader
+	}{
+		{`attachment; filename="EURO rates"; filename*=utf-8''%e2%82%ac%20rates`, w3g.ContentDispositionHeader{Attachment: true, FileName: "€ rates"}},
+		{`attachment; filename*=UTF-8''%E2%82%AC%20rates; filename="EURO rates"`, w3g.ContentDispositionHeader{Attachment: true, FileName: "€ rates"}},
+		{`attachment; filename*=iso-8859-1'en'%A3%20rates`, w3g.ContentDispositionHeader{Attachment: true, FileName: "£ rates"}},
+		{`INLINE; FILENAME= "an example.html"`, w3g.ContentDispositionHeader{Inline: true, FileName: "an example.html"}},
+		{`form-data; name="file; name"; filename=a.txt`, w3g.ContentDispositionHeader{FormData: true, Name: "file; name", FileName: "a.txt"}},
+		{`unknown; filename=foo.html`, w3g.ContentDispositionHeader{Attachment: true, FileName: "foo.html"}},
+	}
+	for _, test := range tests {
+		if got, err := w3g.ParseContentDisposition(test.s); err != nil || got != test.want {
+			t.Errorf("ParseContentDisposition(%q) = %+v, %v, want %+v", test.s, got, err, test.want)
+		}
+	}
+	for _, invalid := range []string{`"attachment"`, `attachment; filename="unterminated`, `attachment; filename`} {
+		if _, err := w3g.ParseContentDisposition(invalid); err == nil {
+			t.Errorf("ParseContentDisposition(%q) succeeded, want error", invalid)
+		}
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	var tests = []struct {
+		name string
+		want string
+	}{
+		{"report.pdf", "report.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Windows\system32\evil.dll`, "evil.dll"},
+		{"..", ""},
+		{"/", ""},
+		{".htaccess", "htaccess"},
+		{"name\x00with\r\ncontrols\u202e.txt", "namewithcontrols.txt"},
+		{"what?.txt ", "what_.txt"},
+		{"con.txt", "_con.txt"},
+		{strings.Repeat("é", 200) + ".txt", strings.Repeat("é", 125) + ".txt"},
+	}
+	for _, test := range tests {
+		if got := w3g.SanitizeFileName(test.name); got != test.want {
+			t.Errorf("SanitizeFileName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+	var c, _ = w3g.ParseContentDisposition(`attachment; filename="../secret.txt"`)
+	if got := c.SafeFileName(); got != "secret.txt" {
+		t.Errorf("SafeFileName() = %q, want %q", got, "secret.txt")
+	}
+}
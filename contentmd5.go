@@ -0,0 +1,181 @@
+package w3g
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ContentMD5Error is returned when the MD5 digest of a body does not match its Content-MD5 HTTP header.
+type ContentMD5Error struct {
+	Actual   string `json:"actual"`
+	Expected string `json:"expected"`
+}
+
+// ContentMD5Reader is a io.ReadCloser that computes the MD5 digest of a body as it is read, and returns a
+// *ContentMD5Error instead of io.EOF when the body does not match its Content-MD5 HTTP header.
+type ContentMD5Reader struct {
+	body     io.ReadCloser
+	err      error
+	expected ContentMD5Header
+	hash     hash.Hash
+}
+
+// ContentMD5Verifier is a struct to configure a middleware that verifies the bodies of requests with a Content-MD5
+// HTTP header as the handler reads them. Requests with an invalid Content-MD5 HTTP header receive a 400 Bad Request
+// response. When ReprDigest is set, the Content-MD5 HTTP header is also translated to a Repr-Digest HTTP header, so
+// that handlers only need to handle digests of RFC 9530.
+type ContentMD5Verifier struct {
+	ReprDigest bool `json:"repr_digest"`
+}
+
+// ComputeContentMD5 reads r to the end and returns the Content-MD5 HTTP header of what was read.
+func ComputeContentMD5(r io.Reader) (ContentMD5Header, error) {
+	var h hash.Hash = md5.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return ContentMD5Header{}, err
+	}
+	return ContentMD5Header{MD5: base64.StdEncoding.EncodeToString(h.Sum(nil))}, nil
+}
+
+// NewContentMD5 returns the Content-MD5 HTTP header of a body.
+func NewContentMD5(b []byte) ContentMD5Header {
+	var sum [md5.Size]byte = md5.Sum(b)
+	return ContentMD5Header{MD5: base64.StdEncoding.EncodeToString(sum[:])}
+}
+
+// NewContentMD5Reader returns a ContentMD5Reader that verifies body against a Content-MD5 HTTP header.
+func NewContentMD5Reader(body io.ReadCloser, expected ContentMD5Header) *ContentMD5Reader {
+	return &ContentMD5Reader{body: body, expected: expected, hash: md5.New()}
+}
+
+// ParseContentMD5 parses a Content-MD5 HTTP header value, which must be the base64 encoding of a 16 byte digest.
+func ParseContentMD5(s string) (ContentMD5Header, error) {
+	var c ContentMD5Header = ContentMD5Header{MD5: strings.TrimSpace(s)}
+	if _, err := c.Sum(); err != nil {
+		return ContentMD5Header{}, err
+	}
+	return c, nil
+}
+
+// SetContentMD5 sets the Content-MD5 HTTP header of a HTTP request to the MD5 digest of its body. The body is read
+// from GetBody when the request has one, and is otherwise read into memory and replaced, together with GetBody.
+func SetContentMD5(r *http.Request) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		r.Header.Set(ContentMD5, NewContentMD5(nil).String())
+		return nil
+	}
+	if r.GetBody != nil {
+		var body, err = r.GetBody()
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		var c ContentMD5Header
+		if c, err = ComputeContentMD5(body); err != nil {
+			return err
+		}
+		r.Header.Set(ContentMD5, c.String())
+		return nil
+	}
+	var data, err = io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	r.ContentLength = int64(len(data))
+	r.Header.Set(ContentMD5, NewContentMD5(data).String())
+	return nil
+}
+
+// Error returns a string representation of a ContentMD5Error.
+func (c *ContentMD5Error) Error() string {
+	return (fmt.Sprintf("w3g: Content-MD5 mismatch: expected %s, got %s", c.Expected, c.Actual))
+}
+
+// ReprDigest returns the Repr-Digest HTTP header equivalent to a Content-MD5 HTTP header, with the deprecated md5
+// algorithm. Content-MD5 covers the body with its content codings applied, which is the representation data of RFC
+// 9530 for complete messages.
+func (c ContentMD5Header) ReprDigest() (IntegrityHeader, error) {
+	var sum, err = c.Sum()
+	if err != nil {
+		return IntegrityHeader{}, err
+	}
+	return IntegrityHeader{Digests: []DigestValue{{Algorithm: "md5", Value: sum}}}, nil
+}
+
+// Sum returns the MD5 digest of a Content-MD5 HTTP header.
+func (c ContentMD5Header) Sum() ([]byte, error) {
+	var sum, err = base64.StdEncoding.DecodeString(c.MD5)
+	if err != nil || len(sum) != md5.Size {
+		return nil, &HeaderValueError{Header: ContentMD5, Value: c.MD5}
+	}
+	return sum, nil
+}
+
+// Verify reads r to the end and reports whether it matches a Content-MD5 HTTP header, returning a *ContentMD5Error
+// when it does not.
+func (c ContentMD5Header) Verify(r io.Reader) error {
+	var actual, err = ComputeContentMD5(r)
+	if err != nil {
+		return err
+	}
+	if actual.MD5 != c.MD5 {
+		return &ContentMD5Error{Actual: actual.MD5, Expected: c.MD5}
+	}
+	return nil
+}
+
+// Close closes the body.
+func (c *ContentMD5Reader) Close() error {
+	return c.body.Close()
+}
+
+// Read reads from the body. At the end of the body, it returns a *ContentMD5Error when the body does not match.
+func (c *ContentMD5Reader) Read(b []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	var n, err = c.body.Read(b)
+	c.hash.Write(b[:n])
+	if err == io.EOF {
+		var actual string = base64.StdEncoding.EncodeToString(c.hash.Sum(nil))
+		if actual != c.expected.MD5 {
+			err = &ContentMD5Error{Actual: actual, Expected: c.expected.MD5}
+		}
+	}
+	c.err = err
+	return n, err
+}
+
+// Handler returns a http.Handler that verifies the bodies of requests with a Content-MD5 HTTP header.
+func (v ContentMD5Verifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var value string = r.Header.Get(ContentMD5)
+		if len(value) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var c, err = ParseContentMD5(value)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		r = r.Clone(r.Context())
+		r.Body = NewContentMD5Reader(r.Body, c)
+		if v.ReprDigest && len(r.Header.Values(ReprDigest)) == 0 {
+			var repr, _ = c.ReprDigest()
+			r.Header.Set(ReprDigest, repr.String())
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,97 @@
+package w3g_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestContentMD5(t *testing.T) {
+	if got := w3g.NewContentMD5([]byte("hello world")).String(); got != "XrY7u+Ae7tCTyyK7j1rNww==" {
+		t.Errorf("NewContentMD5() = %q", got)
+	}
+	var c, err = w3g.ComputeContentMD5(strings.NewReader(""))
+	if err != nil || c.MD5 != "1B2M2Y8AsgTpgAmY7PhCfg==" {
+		t.Errorf("ComputeContentMD5() = %q, %v", c.MD5, err)
+	}
+	if err := c.Verify(strings.NewReader("x")); err == nil {
+		t.Error("Verify() did not return an error")
+	}
+	var repr w3g.IntegrityHeader
+	if repr, err = c.ReprDigest(); err != nil || repr.String() != "md5=:1B2M2Y8AsgTpgAmY7PhCfg==:" {
+		t.Errorf("ReprDigest() = %q, %v", repr.String(), err)
+	}
+	for _, invalid := range []string{"hello", "AAAA", "1B2M2Y8AsgTpgAmY7PhCfg"} {
+		if _, err := w3g.ParseContentMD5(invalid); err == nil {
+			t.Errorf("ParseContentMD5(%q) did not return an error", invalid)
+		}
+	}
+}
+
+func TestContentMD5Reader(t *testing.T) {
+	var r *w3g.ContentMD5Reader = w3g.NewContentMD5Reader(io.NopCloser(strings.NewReader("hello world")), w3g.ContentMD5Header{MD5: "XrY7u+Ae7tCTyyK7j1rNww=="})
+	if b, err := io.ReadAll(r); err != nil || string(b) != "hello world" {
+		t.Errorf("ReadAll() = %q, %v", b, err)
+	}
+	r = w3g.NewContentMD5Reader(io.NopCloser(strings.NewReader("hello there")), w3g.ContentMD5Header{MD5: "XrY7u+Ae7tCTyyK7j1rNww=="})
+	var _, err = io.ReadAll(r)
+	var mismatch *w3g.ContentMD5Error
+	if !errors.As(err, &mismatch) || mismatch.Expected != "XrY7u+Ae7tCTyyK7j1rNww==" {
+		t.Errorf("ReadAll() error = %v, want a *ContentMD5Error", err)
+	}
+}
+
+func TestContentMD5Verifier(t *testing.T) {
+	var handler http.Handler = w3g.ContentMD5Verifier{ReprDigest: true}.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(r.Header.Get(w3g.ReprDigest)))
+	}))
+	for _, test := range []struct {
+		body string
+		md5  string
+		code int
+	}{
+		{"hello world", "XrY7u+Ae7tCTyyK7j1rNww==", http.StatusOK},
+		{"hello there", "XrY7u+Ae7tCTyyK7j1rNww==", http.StatusBadRequest},
+		{"hello world", "not base64", http.StatusBadRequest},
+	} {
+		var r *http.Request = httptest.NewRequest(http.MethodPut, "/", strings.NewReader(test.body))
+		r.Header.Set(w3g.ContentMD5, test.md5)
+		var w *httptest.ResponseRecorder = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("%q: status = %d, want %d", test.body, w.Code, test.code)
+		}
+		if test.code == http.StatusOK && w.Body.String() != "md5=:XrY7u+Ae7tCTyyK7j1rNww==:" {
+			t.Errorf("%q: Repr-Digest = %q", test.body, w.Body.String())
+		}
+	}
+}
+
+func TestSetContentMD5(t *testing.T) {
+	var r, _ = http.NewRequest(http.MethodPut, "http://example.com/bucket/key", strings.NewReader("hello world"))
+	if err := w3g.SetContentMD5(r); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Header.Get(w3g.ContentMD5); got != "XrY7u+Ae7tCTyyK7j1rNww==" {
+		t.Errorf("Content-MD5 = %q", got)
+	}
+	if b, _ := io.ReadAll(r.Body); string(b) != "hello world" {
+		t.Errorf("body = %q after SetContentMD5", b)
+	}
+	r, _ = http.NewRequest(http.MethodPut, "http://example.com/bucket/key", io.MultiReader(strings.NewReader("hello world")))
+	if err := w3g.SetContentMD5(r); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(r.Body); string(b) != "hello world" || r.Header.Get(w3g.ContentMD5) != "XrY7u+Ae7tCTyyK7j1rNww==" || r.ContentLength != 11 {
+		t.Errorf("body = %q, Content-MD5 = %q", b, r.Header.Get(w3g.ContentMD5))
+	}
+}
@@ -0,0 +1,445 @@
+package w3g
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxCookieAge is the longest lifetime a user agent gives a cookie, whatever its Expires or Max-Age attribute.
+const MaxCookieAge time.Duration = 400 * 24 * time.Hour
+
+// MaxCookieAttributeSize is the largest size, in bytes, of the value of a cookie attribute. User agents ignore
+// attributes with larger values.
+const MaxCookieAttributeSize int = 1024
+
+// MaxCookieSize is the largest size, in bytes, of the name and value of a cookie. User agents ignore larger cookies.
+const MaxCookieSize int = 4096
+
+// ErrCookiePrefix is returned when a cookie with a __Secure- or __Host- name prefix does not have the attributes
+// the prefix requires.
+var ErrCookiePrefix = errors.New("w3g: cookie prefix requirements not met")
+
+// ErrCookieTooLarge is returned when the name and value of a cookie are larger than MaxCookieSize, or the value of
+// one of its attributes is larger than MaxCookieAttributeSize.
+var ErrCookieTooLarge = errors.New("w3g: cookie too large")
+
+// ErrInsecureCookie is returned when a cookie that is Partitioned, or whose SameSite is None, is not Secure.
+var ErrInsecureCookie = errors.New("w3g: cookie attribute requires Secure")
+
+// ErrInvalidCookie is returned when the name, value or an attribute of a cookie does not match the grammar of a
+// Set-Cookie HTTP header.
+var ErrInvalidCookie = errors.New("w3g: invalid cookie")
+
+// cookieMonths are the months of a cookie date, by the first three letters of their name in lower case.
+var cookieMonths = map[string]time.Month{
+	"apr": time.April,
+	"aug": time.August,
+	"dec": time.December,
+	"feb": time.February,
+	"jan": time.January,
+	"jul": time.July,
+	"jun": time.June,
+	"mar": time.March,
+	"may": time.May,
+	"nov": time.November,
+	"oct": time.October,
+	"sep": time.September}
+
+// ResponseCookie is a struct to prepare a cookie of a Set-Cookie HTTP header as defined by RFC 6265bis, with the
+// Partitioned attribute of CHIPS, and the Priority attribute, which http.Cookie does not have. As with http.Cookie, a
+// MaxAge of 0 means that there is no Max-Age attribute, and a negative MaxAge means Max-Age=0, which deletes the
+// cookie. A SameSite that is empty means that there is no SameSite attribute.
+type ResponseCookie struct {
+	Domain      string              `json:"domain"`
+	Expires     time.Time           `json:"expires"`
+	HttpOnly    bool                `json:"http_only"`
+	MaxAge      int                 `json:"max_age"`
+	Name        string              `json:"name"`
+	Partitioned bool                `json:"partitioned"`
+	Path        string              `json:"path"`
+	Priority    CookiePriorityValue `json:"priority"`
+	SameSite    SameSiteValue       `json:"same_site"`
+	Secure      bool                `json:"secure"`
+	Value       string              `json:"value"`
+}
+
+// NewResponseCookie returns the ResponseCookie of a http.Cookie. The Priority attribute, which http.Cookie does not
+// have, is read from the Unparsed attributes of the http.Cookie, where net/http keeps it.
+func NewResponseCookie(c *http.Cookie) ResponseCookie {
+	var r ResponseCookie = ResponseCookie{
+		Domain:      strings.TrimPrefix(c.Domain, "."),
+		Expires:     c.Expires,
+		HttpOnly:    c.HttpOnly,
+		MaxAge:      c.MaxAge,
+		Name:        c.Name,
+		Partitioned: c.Partitioned,
+		Path:        c.Path,
+		Secure:      c.Secure,
+		Value:       c.Value}
+	switch c.SameSite {
+	case http.SameSiteLaxMode:
+		r.SameSite = SameSiteLax
+	case http.SameSiteNoneMode:
+		r.SameSite = SameSiteNone
+	case http.SameSiteStrictMode:
+		r.SameSite = SameSiteStrict
+	}
+	for _, attribute := range c.Unparsed {
+		var name, value, _ = strings.Cut(attribute, "=")
+		if strings.ToLower(strings.TrimSpace(name)) != "priority" {
+			continue
+		}
+		if p, err := ParseCookiePriority(value); err == nil {
+			r.Priority = p
+		}
+	}
+	return r
+}
+
+// ParseSetCookie parses a Set-Cookie HTTP header value with the algorithm of RFC 6265bis that user agents use: a
+// cookie without a "=" has an empty name, attribute names are case-insensitive, the last of an attribute wins, and
+// attributes that are unknown, invalid or larger than MaxCookieAttributeSize are ignored. Dates of the Expires
+// attribute are parsed in any of the formats that user agents accept, and a Max-Age that is not positive is returned
+// as a negative MaxAge. An error is returned for a cookie that user agents ignore: one with a control character, with
+// an empty name and value, or with a name and value larger than MaxCookieSize. The cookie is not checked with Valid.
+func ParseSetCookie(s string) (ResponseCookie, error) {
+	for i := 0; i < len(s); i++ {
+		if (s[i] < ' ' && s[i] != '\t') || s[i] == 0x7f {
+			return ResponseCookie{}, fmt.Errorf("%w: control character", ErrInvalidCookie)
+		}
+	}
+	var pair, attributes, _ = strings.Cut(s, ";")
+	var c ResponseCookie
+	if name, value, ok := strings.Cut(pair, "="); ok {
+		c.Name, c.Value = strings.Trim(name, " \t"), strings.Trim(value, " \t")
+	} else {
+		c.Value = strings.Trim(pair, " \t")
+	}
+	if len(c.Name) == 0 && len(c.Value) == 0 {
+		return ResponseCookie{}, fmt.Errorf("%w: empty name and value", ErrInvalidCookie)
+	}
+	if len(c.Name)+len(c.Value) > MaxCookieSize {
+		return ResponseCookie{}, ErrCookieTooLarge
+	}
+	for _, attribute := range strings.Split(attributes, ";") {
+		var name, value, _ = strings.Cut(attribute, "=")
+		name, value = strings.ToLower(strings.Trim(name, " \t")), strings.Trim(value, " \t")
+		if len(value) > MaxCookieAttributeSize {
+			continue
+		}
+		switch name {
+		case "domain":
+			if len(value) != 0 {
+				c.Domain = strings.ToLower(strings.TrimPrefix(value, "."))
+			}
+		case "expires":
+			if t, ok := parseCookieDate(value); ok {
+				c.Expires = t
+			}
+		case "httponly":
+			c.HttpOnly = true
+		case "max-age":
+			if n, ok := parseMaxAge(value); ok {
+				c.MaxAge = n
+			}
+		case "partitioned":
+			c.Partitioned = true
+		case "path":
+			if strings.HasPrefix(value, "/") {
+				c.Path = value
+			} else {
+				c.Path = ""
+			}
+		case "priority":
+			if p, err := ParseCookiePriority(value); err == nil {
+				c.Priority = p
+			}
+		case "samesite":
+			if v, err := ParseSameSite(value); err == nil {
+				c.SameSite = v
+			} else {
+				c.SameSite = ""
+			}
+		case "secure":
+			c.Secure = true
+		}
+	}
+	return c, nil
+}
+
+// EffectiveSameSite returns the SameSite of a cookie, or SameSiteLax for a cookie without a valid SameSite
+// attribute, which user agents that follow RFC 6265bis treat as Lax.
+func (c ResponseCookie) EffectiveSameSite() SameSiteValue {
+	if !c.SameSite.Valid() {
+		return SameSiteLax
+	}
+	return c.SameSite
+}
+
+// Expired reports whether a persistent cookie has expired at a time. Session cookies never expire.
+func (c ResponseCookie) Expired(now time.Time) bool {
+	var t, ok = c.ExpiresAt(now)
+	return ok && !t.After(now)
+}
+
+// ExpiresAt returns the time a cookie received at a time expires, and false for a session cookie, which expires
+// when the session ends. Max-Age takes precedence over Expires, a negative MaxAge expires the cookie at once, and
+// neither can make a cookie live longer than MaxCookieAge.
+func (c ResponseCookie) ExpiresAt(now time.Time) (time.Time, bool) {
+	var limit time.Time = now.Add(MaxCookieAge)
+	var t time.Time
+	switch {
+	case c.MaxAge < 0:
+		return time.Time{}, true
+	case c.MaxAge > 0:
+		if int64(c.MaxAge) >= int64(MaxCookieAge/time.Second) {
+			return limit, true
+		}
+		t = now.Add(time.Duration(c.MaxAge) * time.Second)
+	case !c.Expires.IsZero():
+		t = c.Expires
+	default:
+		return time.Time{}, false
+	}
+	if t.After(limit) {
+		return limit, true
+	}
+	return t, true
+}
+
+// HTTPCookie returns the http.Cookie of a cookie. The Priority attribute, which http.Cookie does not have, is kept in
+// its Unparsed attributes but is not written by its String method.
+func (c ResponseCookie) HTTPCookie() *http.Cookie {
+	var h *http.Cookie = &http.Cookie{
+		Domain:      c.Domain,
+		Expires:     c.Expires,
+		HttpOnly:    c.HttpOnly,
+		MaxAge:      c.MaxAge,
+		Name:        c.Name,
+		Partitioned: c.Partitioned,
+		Path:        c.Path,
+		Secure:      c.Secure,
+		Value:       c.Value}
+	switch c.SameSite {
+	case SameSiteLax:
+		h.SameSite = http.SameSiteLaxMode
+	case SameSiteNone:
+		h.SameSite = http.SameSiteNoneMode
+	case SameSiteStrict:
+		h.SameSite = http.SameSiteStrictMode
+	}
+	if len(c.Priority) != 0 {
+		(h.Unparsed) = (append(h.Unparsed, fmt.Sprintf("Priority=%s", c.Priority)))
+	}
+	return h
+}
+
+// String returns a string representation of a Set-Cookie HTTP header. The cookie is written as it is; Valid reports
+// whether it can be sent.
+func (c ResponseCookie) String() string {
+	var substrings ([]string) = ([]string{fmt.Sprintf("%s=%s", c.Name, c.Value)})
+	if len(c.Path) != 0 {
+		(substrings) = (append(substrings, fmt.Sprintf("Path=%s", c.Path)))
+	}
+	if len(c.Domain) != 0 {
+		(substrings) = (append(substrings, fmt.Sprintf("Domain=%s", c.Domain)))
+	}
+	if !c.Expires.IsZero() {
+		(substrings) = (append(substrings, fmt.Sprintf("Expires=%s", c.Expires.UTC().Format(http.TimeFormat))))
+	}
+	if c.MaxAge > 0 {
+		(substrings) = (append(substrings, fmt.Sprintf("Max-Age=%d", c.MaxAge)))
+	} else if c.MaxAge < 0 {
+		(substrings) = (append(substrings, "Max-Age=0"))
+	}
+	if c.HttpOnly {
+		(substrings) = (append(substrings, "HttpOnly"))
+	}
+	if c.Secure {
+		(substrings) = (append(substrings, "Secure"))
+	}
+	if len(c.SameSite) != 0 {
+		(substrings) = (append(substrings, fmt.Sprintf("SameSite=%s", c.SameSite)))
+	}
+	if c.Partitioned {
+		(substrings) = (append(substrings, "Partitioned"))
+	}
+	if len(c.Priority) != 0 {
+		(substrings) = (append(substrings, fmt.Sprintf("Priority=%s", c.Priority)))
+	}
+	return strings.Join(substrings, "; ")
+}
+
+// Valid reports whether a cookie can be sent in a Set-Cookie HTTP header and will be stored by user agents that
+// follow RFC 6265bis. Its name must be a token and its value cookie-octets, optionally quoted, together no larger
+// than MaxCookieSize, and its Domain and Path no larger than MaxCookieAttributeSize. A cookie whose name starts with
+// __Secure- must be Secure, and one whose name starts with __Host- must also have a Path of "/" and no Domain; the
+// prefixes are case-insensitive. Partitioned cookies and cookies whose SameSite is None must be Secure.
+func (c ResponseCookie) Valid() error {
+	if !isToken(c.Name) {
+		return fmt.Errorf("%w: name %q", ErrInvalidCookie, c.Name)
+	}
+	if !isCookieValue(c.Value) {
+		return fmt.Errorf("%w: value %q", ErrInvalidCookie, c.Value)
+	}
+	if len(c.Name)+len(c.Value) > MaxCookieSize || len(c.Domain) > MaxCookieAttributeSize || len(c.Path) > MaxCookieAttributeSize {
+		return ErrCookieTooLarge
+	}
+	for _, value := range []string{c.Domain, c.Path} {
+		if strings.ContainsRune(value, ';') || !isFieldValue(value) {
+			return fmt.Errorf("%w: attribute %q", ErrInvalidCookie, value)
+		}
+	}
+	if len(c.Path) != 0 && !strings.HasPrefix(c.Path, "/") {
+		return fmt.Errorf("%w: path %q", ErrInvalidCookie, c.Path)
+	}
+	if (len(c.SameSite) != 0 && !c.SameSite.Valid()) || (len(c.Priority) != 0 && !c.Priority.Valid()) {
+		return fmt.Errorf("%w: attribute", ErrInvalidCookie)
+	}
+	var name string = strings.ToLower(c.Name)
+	if strings.HasPrefix(name, "__secure-") && !c.Secure {
+		return fmt.Errorf("%w: __Secure- requires Secure", ErrCookiePrefix)
+	}
+	if strings.HasPrefix(name, "__host-") && (!c.Secure || len(c.Domain) != 0 || c.Path != "/") {
+		return fmt.Errorf("%w: __Host- requires Secure, Path=/ and no Domain", ErrCookiePrefix)
+	}
+	if c.Partitioned && !c.Secure {
+		return fmt.Errorf("%w: Partitioned", ErrInsecureCookie)
+	}
+	if c.SameSite == SameSiteNone && !c.Secure {
+		return fmt.Errorf("%w: SameSite=None", ErrInsecureCookie)
+	}
+	return nil
+}
+
+// validate reports whether a cookie is valid, for SafeString.
+func (c ResponseCookie) validate() error {
+	if err := c.Valid(); err != nil {
+		return &HeaderValueError{Header: SetCookie, Value: c.String()}
+	}
+	return nil
+}
+
+// isCookieDelimiter reports whether a byte separates the tokens of a cookie date.
+func isCookieDelimiter(c byte) bool {
+	return c == '\t' || (c >= 0x20 && c <= 0x2f) || (c >= 0x3b && c <= 0x40) || (c >= 0x5b && c <= 0x60) || (c >= 0x7b && c <= 0x7e)
+}
+
+// isCookieValue reports whether a string is a cookie value: cookie-octets, optionally enclosed in double quotes.
+func isCookieValue(s string) bool {
+	if len(s) > 1 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c >= 0x7f || c == '"' || c == ',' || c == ';' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// leadingDigits parses the digits at the start of a token of a cookie date. The returned bool is false when there
+// are fewer than min or more than max digits, or they are followed by a digit.
+func leadingDigits(s string, min, max int) (int, bool) {
+	var i int
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i < min || i > max {
+		return 0, false
+	}
+	var n, _ = strconv.Atoi(s[:i])
+	return n, true
+}
+
+// parseCookieDate parses the date of an Expires attribute with the algorithm of RFC 6265bis, which finds a time,
+// day of month, month and year among the tokens of the date in any order.
+func parseCookieDate(s string) (time.Time, bool) {
+	var hour, minute, second, day, year int
+	var month time.Month
+	var foundTime, foundDay, foundMonth, foundYear bool
+	for _, token := range strings.FieldsFunc(s, func(r rune) bool { return r < 0x80 && isCookieDelimiter(byte(r)) }) {
+		if !foundTime {
+			if h, m, sec, ok := parseCookieTime(token); ok {
+				hour, minute, second, foundTime = h, m, sec, true
+				continue
+			}
+		}
+		if !foundDay {
+			if n, ok := leadingDigits(token, 1, 2); ok {
+				day, foundDay = n, true
+				continue
+			}
+		}
+		if !foundMonth && len(token) >= 3 {
+			if m, ok := cookieMonths[strings.ToLower(token[:3])]; ok {
+				month, foundMonth = m, true
+				continue
+			}
+		}
+		if !foundYear {
+			if n, ok := leadingDigits(token, 2, 4); ok {
+				year, foundYear = n, true
+				continue
+			}
+		}
+	}
+	if year >= 70 && year <= 99 {
+		year += 1900
+	} else if year >= 0 && year <= 69 {
+		year += 2000
+	}
+	if !foundTime || !foundDay || !foundMonth || !foundYear || day < 1 || day > 31 || year < 1601 || hour > 23 || minute > 59 || second > 59 {
+		return time.Time{}, false
+	}
+	var t time.Time = time.Date(year, month, day, hour, minute, second, 0, time.UTC)
+	if t.Day() != day {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// parseCookieTime parses a token of a cookie date that is a time, hh:mm:ss with one or two digits each, optionally
+// followed by characters that are not digits.
+func parseCookieTime(s string) (int, int, int, bool) {
+	var values [3]int
+	for i := range values {
+		var n, ok = leadingDigits(s, 1, 2)
+		if !ok {
+			return 0, 0, 0, false
+		}
+		values[i] = n
+		s = strings.TrimLeft(s, "0123456789")
+		if i < 2 {
+			if !strings.HasPrefix(s, ":") {
+				return 0, 0, 0, false
+			}
+			s = s[1:]
+		}
+	}
+	return values[0], values[1], values[2], true
+}
+
+// parseMaxAge parses the value of a Max-Age attribute: digits with an optional leading "-". A value that is not
+// positive is returned as -1, and one too large for an int as the largest int.
+func parseMaxAge(s string) (int, bool) {
+	var digits string = strings.TrimPrefix(s, "-")
+	if len(digits) == 0 || strings.Trim(digits, "0123456789") != "" {
+		return 0, false
+	}
+	var n, err = strconv.Atoi(s)
+	if err != nil {
+		if len(digits) == len(s) {
+			return int(^uint(0) >> 1), true
+		}
+		return -1, true
+	}
+	if n <= 0 {
+		return -1, true
+	}
+	return n, true
+}
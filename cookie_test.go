@@ -0,0 +1,170 @@
+package w3g_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gellel/w3g"
+)
+
+func TestParseSetCookie(t *testing.T) {
+	var c, err = w3g.ParseSetCookie(`__Host-id=a3fWa; path=/; SECURE; HttpOnly; SameSite=strict; Partitioned; Priority=High; Domain=.Example.COM; Expires=Wed, 21 Oct 2015 07:28:00 GMT; Max-Age=3600; Unknown=1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want w3g.ResponseCookie = w3g.ResponseCookie{
+		Domain:      "example.com",
+		Expires:     time.Date(2015, time.October, 21, 7, 28, 0, 0, time.UTC),
+		HttpOnly:    true,
+		MaxAge:      3600,
+		Name:        "__Host-id",
+		Partitioned: true,
+		Path:        "/",
+		Priority:    w3g.CookiePriorityHigh,
+		SameSite:    w3g.SameSiteStrict,
+		Secure:      true,
+		Value:       "a3fWa"}
+	if c != want {
+		t.Errorf("ParseSetCookie() = %+v, want %+v", c, want)
+	}
+	if !errors.Is(c.Valid(), w3g.ErrCookiePrefix) {
+		t.Errorf("Valid() = %v, want ErrCookiePrefix for a __Host- cookie with a Domain", c.Valid())
+	}
+
+	c, err = w3g.ParseSetCookie(`lone; Path=relative; SameSite=Bogus; Max-Age=-5; Domain=` + strings.Repeat("a", 1025))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "" || c.Value != "lone" || c.Path != "" || c.SameSite != "" || c.MaxAge != -1 || c.Domain != "" {
+		t.Errorf("ParseSetCookie() = %+v", c)
+	}
+	if c.EffectiveSameSite() != w3g.SameSiteLax {
+		t.Errorf("EffectiveSameSite() = %q, want Lax", c.EffectiveSameSite())
+	}
+
+	for _, test := range []struct {
+		s   string
+		err error
+	}{
+		{"a=b\x01", w3g.ErrInvalidCookie},
+		{"=; Secure", w3g.ErrInvalidCookie},
+		{"a=" + strings.Repeat("b", 4096), w3g.ErrCookieTooLarge},
+	} {
+		if _, err := w3g.ParseSetCookie(test.s); !errors.Is(err, test.err) {
+			t.Errorf("ParseSetCookie(%.40q) = %v, want %v", test.s, err, test.err)
+		}
+	}
+}
+
+func TestParseSetCookieExpires(t *testing.T) {
+	var want time.Time = time.Date(1994, time.November, 6, 8, 49, 37, 0, time.UTC)
+	for _, date := range []string{"Sun, 06 Nov 1994 08:49:37 GMT", "Sunday, 06-Nov-94 08:49:37 GMT", "Sun Nov  6 08:49:37 1994", "6 november 1994 8:49:37"} {
+		var c, err = w3g.ParseSetCookie("a=b; Expires=" + date)
+		if err != nil || !c.Expires.Equal(want) {
+			t.Errorf("Expires=%q = %v, %v", date, c.Expires, err)
+		}
+	}
+	for _, date := range []string{"Sun, 31 Feb 1994 08:49:37 GMT", "06 Nov 1600 08:49:37", "06 Nov 1994", "06 Nov 1994 24:00:00"} {
+		if c, _ := w3g.ParseSetCookie("a=b; Expires=" + date); !c.Expires.IsZero() {
+			t.Errorf("Expires=%q = %v, want it ignored", date, c.Expires)
+		}
+	}
+}
+
+func TestResponseCookieExpiresAt(t *testing.T) {
+	var now time.Time = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		c    w3g.ResponseCookie
+		want time.Time
+		ok   bool
+	}{
+		{w3g.ResponseCookie{}, time.Time{}, false},
+		{w3g.ResponseCookie{Expires: now.Add(time.Hour), MaxAge: 60}, now.Add(time.Minute), true},
+		{w3g.ResponseCookie{Expires: now.Add(time.Hour)}, now.Add(time.Hour), true},
+		{w3g.ResponseCookie{Expires: now.Add(time.Hour), MaxAge: -1}, time.Time{}, true},
+		{w3g.ResponseCookie{Expires: now.AddDate(2, 0, 0)}, now.Add(w3g.MaxCookieAge), true},
+		{w3g.ResponseCookie{MaxAge: 1 << 40}, now.Add(w3g.MaxCookieAge), true},
+	} {
+		if got, ok := test.c.ExpiresAt(now); !got.Equal(test.want) || ok != test.ok {
+			t.Errorf("%+v.ExpiresAt() = %v, %v, want %v, %v", test.c, got, ok, test.want, test.ok)
+		}
+	}
+	if !(w3g.ResponseCookie{MaxAge: -1}).Expired(now) || (w3g.ResponseCookie{}).Expired(now) {
+		t.Error("Expired() is wrong")
+	}
+}
+
+func TestResponseCookieValid(t *testing.T) {
+	for _, test := range []struct {
+		c   w3g.ResponseCookie
+		err error
+	}{
+		{w3g.ResponseCookie{Name: "__Host-id", Value: "1", Path: "/", Secure: true, Partitioned: true, SameSite: w3g.SameSiteNone}, nil},
+		{w3g.ResponseCookie{Name: "id", Value: `"quoted"`}, nil},
+		{w3g.ResponseCookie{Name: "__Secure-id", Value: "1"}, w3g.ErrCookiePrefix},
+		{w3g.ResponseCookie{Name: "__HOST-id", Value: "1", Secure: true, Path: "/a"}, w3g.ErrCookiePrefix},
+		{w3g.ResponseCookie{Name: "id", Value: "1", Partitioned: true}, w3g.ErrInsecureCookie},
+		{w3g.ResponseCookie{Name: "id", Value: "1", SameSite: w3g.SameSiteNone}, w3g.ErrInsecureCookie},
+		{w3g.ResponseCookie{Name: "id", Value: "a b"}, w3g.ErrInvalidCookie},
+		{w3g.ResponseCookie{Name: "id", Value: "1", Path: "/; Domain=evil.example"}, w3g.ErrInvalidCookie},
+		{w3g.ResponseCookie{Name: "", Value: "1"}, w3g.ErrInvalidCookie},
+		{w3g.ResponseCookie{Name: "id", Value: strings.Repeat("a", 4095)}, w3g.ErrCookieTooLarge},
+	} {
+		if err := test.c.Valid(); !errors.Is(err, test.err) {
+			t.Errorf("%+v.Valid() = %v, want %v", test.c, err, test.err)
+		}
+	}
+}
+
+func TestResponseCookieString(t *testing.T) {
+	var c w3g.ResponseCookie = w3g.ResponseCookie{
+		Domain:      "example.com",
+		Expires:     time.Date(2015, time.October, 21, 7, 28, 0, 0, time.UTC),
+		HttpOnly:    true,
+		MaxAge:      -1,
+		Name:        "id",
+		Partitioned: true,
+		Path:        "/",
+		Priority:    w3g.CookiePriorityLow,
+		SameSite:    w3g.SameSiteNone,
+		Secure:      true,
+		Value:       "a3fWa"}
+	var want string = "id=a3fWa; Path=/; Domain=example.com; Expires=Wed, 21 Oct 2015 07:28:00 GMT; Max-Age=0; HttpOnly; Secure; SameSite=None; Partitioned; Priority=Low"
+	if got := c.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, err := w3g.ParseSetCookie(want); err != nil || got != c {
+		t.Errorf("ParseSetCookie(String()) = %+v, %v", got, err)
+	}
+	var w *httptest.ResponseRecorder = httptest.NewRecorder()
+	if err := w3g.SetHeader(w, c); err != nil || w.Header().Get(w3g.SetCookie) != want {
+		t.Errorf("SetHeader() = %v, headers = %v", err, w.Header())
+	}
+	if err := w3g.SetHeader(w, w3g.ResponseCookie{Name: "__Secure-id", Value: "1"}); err == nil {
+		t.Error("SetHeader() of an invalid cookie did not return an error")
+	}
+}
+
+func TestResponseCookieHTTPCookie(t *testing.T) {
+	var c w3g.ResponseCookie = w3g.ResponseCookie{Name: "id", Value: "1", Path: "/", Secure: true, SameSite: w3g.SameSiteStrict, Partitioned: true, Priority: w3g.CookiePriorityHigh}
+	var h *http.Cookie = c.HTTPCookie()
+	if h.SameSite != http.SameSiteStrictMode || !h.Partitioned || h.String() != "id=1; Path=/; Secure; SameSite=Strict; Partitioned" {
+		t.Errorf("HTTPCookie() = %q", h.String())
+	}
+	if got := w3g.NewResponseCookie(h); got != c {
+		t.Errorf("NewResponseCookie() = %+v, want %+v", got, c)
+	}
+
+	var res *http.Response = &http.Response{Header: http.Header{w3g.SetCookie: {"id=1; Path=/; Secure; SameSite=Strict; Partitioned; Priority=High"}}}
+	var cookies []*http.Cookie = res.Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Cookies() = %v", cookies)
+	}
+	if got := w3g.NewResponseCookie(cookies[0]); got != c {
+		t.Errorf("NewResponseCookie() of a cookie parsed by net/http = %+v, want %+v", got, c)
+	}
+}
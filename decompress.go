@@ -0,0 +1,418 @@
+package w3g
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrDecompressionBomb is returned when reading a body decoded by a Decompressor whose decoded size exceeds its
+// MaxSize, or whose ratio of decoded to encoded size exceeds its MaxRatio.
+var ErrDecompressionBomb = errors.New("w3g: decoded body exceeds the decompression limits")
+
+// errInvalidCompressData is returned when reading a body with the compress content coding that is not valid.
+var errInvalidCompressData = errors.New("w3g: invalid compress data")
+
+// ContentDecoder returns a reader that decodes what is read from r with a content coding.
+type ContentDecoder func(r io.Reader) (io.ReadCloser, error)
+
+// Decompressor is a struct to configure the decoding of request and response bodies with the content codings listed
+// in their Content-Encoding HTTP header. Decoding stops with ErrDecompressionBomb once more than MaxSize bytes are
+// decoded, or once the decoded body is more than MaxRatio times as large as the encoded body read so far. A limit of
+// 0 disables it. Transport sends the requests of RoundTrip, and http.DefaultTransport is used when it is nil.
+type Decompressor struct {
+	Decoders  map[string]ContentDecoder `json:"-"`
+	MaxRatio  float64                   `json:"max_ratio"`
+	MaxSize   int64                     `json:"max_size"`
+	Transport http.RoundTripper         `json:"-"`
+}
+
+// countingReader is a io.Reader that counts the bytes read from its underlying reader.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+// decodedBody is a io.ReadCloser that decodes a body with a stack of content codings when it is first read.
+type decodedBody struct {
+	body         io.ReadCloser
+	closers      []io.Closer
+	codings      []string
+	decoded      int64
+	decompressor *Decompressor
+	encoded      *countingReader
+	err          error
+	reader       io.Reader
+}
+
+// lzwReader is a io.ReadCloser that decodes the compress content coding: the LZW format of the Unix compress program.
+// It differs from the format of the compress/lzw package in its header, its codes of up to 16 bits and the padding
+// that follows each change of code width.
+type lzwReader struct {
+	bits   uint
+	block  bool
+	buf    uint32
+	end    int
+	final  byte
+	left   uint
+	mask   int
+	max    uint
+	n      int
+	out    []byte
+	prefix [1 << 16]uint16
+	prev   int
+	r      io.ByteReader
+	stack  []byte
+	suffix [1 << 16]byte
+}
+
+// NewDecompressor returns a Decompressor that decodes the gzip, deflate, compress and identity content codings, and
+// the x-gzip and x-compress aliases, up to 64 MiB and an expansion ratio of 100.
+func NewDecompressor() *Decompressor {
+	var d *Decompressor = &Decompressor{Decoders: map[string]ContentDecoder{}, MaxRatio: 100, MaxSize: 64 << 20}
+	var gzipDecoder ContentDecoder = func(r io.Reader) (io.ReadCloser, error) {
+		var z, err = gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return z, nil
+	}
+	var compressDecoder ContentDecoder = func(r io.Reader) (io.ReadCloser, error) {
+		return newLZWReader(r)
+	}
+	d.Register("compress", compressDecoder)
+	d.Register("deflate", func(r io.Reader) (io.ReadCloser, error) {
+		var br *bufio.Reader = bufio.NewReader(r)
+		if header, err := br.Peek(2); err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	})
+	d.Register("gzip", gzipDecoder)
+	d.Register("x-compress", compressDecoder)
+	d.Register("x-gzip", gzipDecoder)
+	return d
+}
+
+// ParseContentEncoding parses a Content-Encoding HTTP header value. The Codings of the returned ContentEncodingHeader
+// are lower case and in the order they were applied.
+func ParseContentEncoding(s string) (ContentEncodingHeader, error) {
+	var c ContentEncodingHeader
+	for _, coding := range strings.Split(s, ",") {
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if len(coding) == 0 {
+			continue
+		}
+		if !isToken(coding) {
+			return ContentEncodingHeader{}, &HeaderValueError{Header: ContentEncoding, Value: s}
+		}
+		switch coding {
+		case "br":
+			c.Br = true
+		case "compress", "x-compress":
+			c.Compress = true
+		case "deflate":
+			c.Deflate = true
+		case "gzip", "x-gzip":
+			c.GZip = true
+		case "identity":
+			c.Identity = true
+		}
+		c.Codings = append(c.Codings, coding)
+	}
+	return c, nil
+}
+
+// Decode returns a reader of body decoded with content codings listed in the order they were applied, so that the
+// last coding is decoded first. It returns a *HeaderValueError when a coding other than identity does not have a
+// ContentDecoder. Closing the returned reader closes body.
+func (d *Decompressor) Decode(body io.ReadCloser, codings []string) (io.ReadCloser, error) {
+	for _, coding := range codings {
+		if coding = strings.ToLower(coding); coding != "identity" && d.Decoders[coding] == nil {
+			return nil, &HeaderValueError{Header: ContentEncoding, Value: coding}
+		}
+	}
+	return &decodedBody{body: body, codings: codings, decompressor: d}, nil
+}
+
+// Handler returns a http.Handler that decodes the bodies of requests with a Content-Encoding HTTP header before
+// calling next, removing their Content-Encoding and Content-Length HTTP headers. Requests with a content coding that
+// cannot be decoded receive a 415 Unsupported Media Type response listing the supported codings in its
+// Accept-Encoding HTTP header.
+func (d *Decompressor) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if values := r.Header.Values(ContentEncoding); len(values) != 0 {
+			var c, err = ParseContentEncoding(strings.Join(values, ", "))
+			var body io.ReadCloser
+			if err == nil {
+				body, err = d.Decode(r.Body, c.Codings)
+			}
+			if err != nil {
+				w.Header().Set(AcceptEncoding, d.acceptEncoding())
+				http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+				return
+			}
+			r = r.Clone(r.Context())
+			r.Body = body
+			r.ContentLength = -1
+			r.Header.Del(ContentEncoding)
+			r.Header.Del(ContentLength)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Register adds a ContentDecoder for a content coding, such as br or zstd.
+func (d *Decompressor) Register(coding string, decoder ContentDecoder) {
+	if d.Decoders == nil {
+		d.Decoders = map[string]ContentDecoder{}
+	}
+	d.Decoders[strings.ToLower(coding)] = decoder
+}
+
+// RoundTrip sends a HTTP request with an Accept-Encoding HTTP header listing the supported codings, unless it already
+// has one, and decodes the body of the response. Decoded responses have no Content-Encoding or Content-Length HTTP
+// headers, a ContentLength of -1 and Uncompressed set. Responses with a content coding that cannot be decoded and
+// partial responses are returned as they are.
+func (d *Decompressor) RoundTrip(r *http.Request) (*http.Response, error) {
+	var transport http.RoundTripper = d.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if len(r.Header.Values(AcceptEncoding)) == 0 {
+		r = r.Clone(r.Context())
+		r.Header.Set(AcceptEncoding, d.acceptEncoding())
+	}
+	var res, err = transport.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	var values []string = res.Header.Values(ContentEncoding)
+	if len(values) == 0 || r.Method == http.MethodHead || res.StatusCode == http.StatusPartialContent || res.Body == nil || res.Body == http.NoBody {
+		return res, nil
+	}
+	var c ContentEncodingHeader
+	if c, err = ParseContentEncoding(strings.Join(values, ", ")); err != nil {
+		return res, nil
+	}
+	var body io.ReadCloser
+	if body, err = d.Decode(res.Body, c.Codings); err != nil {
+		return res, nil
+	}
+	res.Body = body
+	res.ContentLength = -1
+	res.Header.Del(ContentEncoding)
+	res.Header.Del(ContentLength)
+	res.Uncompressed = true
+	return res, nil
+}
+
+// acceptEncoding returns an Accept-Encoding HTTP header value listing the supported content codings, without the
+// x-gzip and x-compress aliases.
+func (d *Decompressor) acceptEncoding() string {
+	var codings []string
+	for coding := range d.Decoders {
+		if !strings.HasPrefix(coding, "x-") {
+			codings = append(codings, coding)
+		}
+	}
+	sort.Strings(codings)
+	return (strings.Join(codings, ", "))
+}
+
+// exceeds reports whether a decoded size exceeds the limits of a Decompressor for an encoded size.
+func (d *Decompressor) exceeds(encoded, decoded int64) bool {
+	return ((d.MaxSize > 0 && decoded > d.MaxSize) || (d.MaxRatio > 0 && float64(decoded) > d.MaxRatio*float64(encoded)))
+}
+
+// Read reads from the underlying reader and counts the bytes read.
+func (c *countingReader) Read(b []byte) (int, error) {
+	var n, err = c.Reader.Read(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// Close closes the decoders and the encoded body.
+func (b *decodedBody) Close() error {
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		b.closers[i].Close()
+	}
+	return b.body.Close()
+}
+
+// Read reads decoded data, stopping with ErrDecompressionBomb when the limits of the Decompressor are exceeded.
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		b.err = b.init()
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	var n, err = b.reader.Read(p)
+	b.decoded += int64(n)
+	if b.decompressor.exceeds(b.encoded.n, b.decoded) {
+		b.err = ErrDecompressionBomb
+		return 0, b.err
+	}
+	return n, err
+}
+
+// init stacks the decoders of the content codings of the body, from the last coding applied to the first.
+func (b *decodedBody) init() error {
+	b.encoded = &countingReader{Reader: b.body}
+	var r io.Reader = b.encoded
+	for i := len(b.codings) - 1; i >= 0; i-- {
+		var coding string = strings.ToLower(b.codings[i])
+		if coding == "identity" {
+			continue
+		}
+		var decoder, err = b.decompressor.Decoders[coding](r)
+		if err != nil {
+			return err
+		}
+		b.closers = append(b.closers, decoder)
+		r = decoder
+	}
+	b.reader = r
+	return nil
+}
+
+// newLZWReader returns a lzwReader after reading the header of the compress data from r.
+func newLZWReader(r io.Reader) (*lzwReader, error) {
+	var br, ok = r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	var header [3]byte
+	for i := range header {
+		var c, err = br.ReadByte()
+		if err != nil {
+			return nil, errInvalidCompressData
+		}
+		header[i] = c
+	}
+	var max uint = uint(header[2] & 0x1f)
+	if header[0] != 0x1f || header[1] != 0x9d || header[2]&0x60 != 0 || max < 9 || max > 16 {
+		return nil, errInvalidCompressData
+	}
+	var z *lzwReader = &lzwReader{bits: 9, block: header[2]&0x80 != 0, end: 255, mask: 0x1ff, max: max, prev: -1, r: br}
+	if z.block {
+		z.end = 256
+	}
+	return z, nil
+}
+
+// Close does nothing, since a lzwReader does not own its underlying reader.
+func (z *lzwReader) Close() error {
+	return nil
+}
+
+// Read reads decoded data.
+func (z *lzwReader) Read(p []byte) (int, error) {
+	for len(z.out) == 0 {
+		if err := z.decode(); err != nil {
+			return 0, err
+		}
+	}
+	var n int = copy(p, z.out)
+	z.out = z.out[n:]
+	return n, nil
+}
+
+// code reads the next code. It returns io.EOF when the data ends before the code.
+func (z *lzwReader) code() (int, error) {
+	for i := 0; z.left < z.bits; i++ {
+		var c, err = z.r.ReadByte()
+		if err == io.EOF && i != 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		z.buf |= uint32(c) << z.left
+		z.left += 8
+		z.n++
+	}
+	var code int = int(z.buf & uint32(z.mask))
+	z.buf >>= z.bits
+	z.left -= z.bits
+	return code, nil
+}
+
+// decode decodes the next code into the output buffer.
+func (z *lzwReader) decode() error {
+	if z.prev < 0 {
+		var code, err = z.code()
+		if err != nil {
+			return err
+		}
+		if code > 255 {
+			return errInvalidCompressData
+		}
+		z.prev, z.final = code, byte(code)
+		z.out = append(z.out[:0], byte(code))
+		return nil
+	}
+	if z.end >= z.mask && z.bits < z.max {
+		if err := z.skip(); err != nil {
+			return err
+		}
+		z.bits++
+		z.mask = z.mask<<1 | 1
+	}
+	var code, err = z.code()
+	if err != nil {
+		return err
+	}
+	if code == 256 && z.block {
+		if err := z.skip(); err != nil {
+			return err
+		}
+		z.bits, z.end, z.mask = 9, 255, 0x1ff
+		return nil
+	}
+	var next int = code
+	z.stack = z.stack[:0]
+	if code > z.end {
+		if code != z.end+1 || z.prev > z.end {
+			return errInvalidCompressData
+		}
+		z.stack = append(z.stack, z.final)
+		code = z.prev
+	}
+	for code >= 256 {
+		z.stack = append(z.stack, z.suffix[code])
+		code = int(z.prefix[code])
+	}
+	z.stack = append(z.stack, byte(code))
+	z.final = byte(code)
+	if z.end < z.mask {
+		z.end++
+		z.prefix[z.end] = uint16(z.prev)
+		z.suffix[z.end] = z.final
+	}
+	z.prev = next
+	z.out = z.out[:0]
+	for i := len(z.stack) - 1; i >= 0; i-- {
+		z.out = append(z.out, z.stack[i])
+	}
+	return nil
+}
+
+// skip discards the rest of the current group of eight codes, which compress pads when the code width changes.
+func (z *lzwReader) skip() error {
+	z.buf, z.left = 0, 0
+	for ; z.n%int(z.bits) != 0; z.n++ {
+		if _, err := z.r.ReadByte(); err != nil {
+			return err
+		}
+	}
+	z.n = 0
+	return nil
+}
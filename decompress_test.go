@@ -0,0 +1,169 @@
+package w3g_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestParseContentEncoding(t *testing.T) {
+	var c, err = w3g.ParseContentEncoding("deflate, X-GZip, identity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"deflate", "x-gzip", "identity"}; !reflect.DeepEqual(c.Codings, want) {
+		t.Errorf("Codings = %v, want %v", c.Codings, want)
+	}
+	if !c.Deflate || !c.GZip || !c.Identity || c.Br || c.Compress {
+		t.Errorf("ParseContentEncoding() = %+v", c)
+	}
+	if got := c.String(); got != "deflate, x-gzip, identity" {
+		t.Errorf("String() = %q", got)
+	}
+	if _, err := w3g.ParseContentEncoding("gzip, x/y"); err == nil {
+		t.Error("ParseContentEncoding(\"gzip, x/y\") did not return an error")
+	}
+}
+
+func TestDecompressorDecode(t *testing.T) {
+	var d *w3g.Decompressor = w3g.NewDecompressor()
+	var text, err = os.ReadFile("testdata/compress.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed []byte
+	if compressed, err = os.ReadFile("testdata/compress.txt.Z"); err != nil {
+		t.Fatal(err)
+	}
+	var raw bytes.Buffer
+	var fw, _ = flate.NewWriter(&raw, flate.BestCompression)
+	fw.Write(text)
+	fw.Close()
+	for _, test := range []struct {
+		name    string
+		body    []byte
+		codings []string
+	}{
+		{"compress", compressed, []string{"compress"}},
+		{"raw deflate", raw.Bytes(), []string{"deflate"}},
+		{"deflate then gzip", gzipBytes(zlibBytes(text)), []string{"deflate", "identity", "gzip"}},
+		{"compress then x-gzip", gzipBytes(compressed), []string{"x-compress", "x-gzip"}},
+	} {
+		var body, err = d.Decode(io.NopCloser(bytes.NewReader(test.body)), test.codings)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		var decoded []byte
+		if decoded, err = io.ReadAll(body); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !bytes.Equal(decoded, text) {
+			t.Errorf("%s: decoded %d bytes, want %d", test.name, len(decoded), len(text))
+		}
+	}
+	if _, err := d.Decode(io.NopCloser(strings.NewReader("")), []string{"gzip", "br"}); err == nil {
+		t.Error("Decode() with br did not return an error")
+	}
+}
+
+func TestDecompressorLimits(t *testing.T) {
+	var bomb []byte = gzipBytes(make([]byte, 10<<20))
+	var d *w3g.Decompressor = w3g.NewDecompressor()
+	var body, _ = d.Decode(io.NopCloser(bytes.NewReader(bomb)), []string{"gzip"})
+	if _, err := io.ReadAll(body); !errors.Is(err, w3g.ErrDecompressionBomb) {
+		t.Errorf("ReadAll() error = %v with MaxRatio, want ErrDecompressionBomb", err)
+	}
+	d.MaxRatio, d.MaxSize = 0, 1<<20
+	body, _ = d.Decode(io.NopCloser(bytes.NewReader(bomb)), []string{"gzip"})
+	if b, err := io.ReadAll(body); !errors.Is(err, w3g.ErrDecompressionBomb) || len(b) > 1<<20 {
+		t.Errorf("ReadAll() = %d bytes, %v with MaxSize, want ErrDecompressionBomb", len(b), err)
+	}
+	d.MaxSize = 0
+	body, _ = d.Decode(io.NopCloser(bytes.NewReader(bomb)), []string{"gzip"})
+	if b, err := io.ReadAll(body); err != nil || len(b) != 10<<20 {
+		t.Errorf("ReadAll() = %d bytes, %v without limits", len(b), err)
+	}
+}
+
+func TestDecompressorHandler(t *testing.T) {
+	var handler http.Handler = w3g.NewDecompressor().Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(w3g.ContentEncoding) != "" || r.ContentLength != -1 {
+			t.Errorf("request was not rewritten: %v, %d", r.Header, r.ContentLength)
+		}
+		io.Copy(w, r.Body)
+	}))
+	var body []byte = gzipBytes([]byte("hello"))
+	var r *http.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	r.Header.Set(w3g.ContentEncoding, "gzip")
+	var w *httptest.ResponseRecorder = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if got := w.Body.String(); got != "hello" {
+		t.Errorf("body = %q, want hello", got)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("data"))
+	r.Header.Set(w3g.ContentEncoding, "br")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("status = %d, want 415", w.Code)
+	}
+	if got := w.Header().Get(w3g.AcceptEncoding); got != "compress, deflate, gzip" {
+		t.Errorf("Accept-Encoding = %q", got)
+	}
+}
+
+func TestDecompressorRoundTrip(t *testing.T) {
+	var server *httptest.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(w3g.AcceptEncoding); got != "compress, deflate, gzip" {
+			t.Errorf("Accept-Encoding = %q", got)
+		}
+		var body []byte = gzipBytes(zlibBytes([]byte("stacked")))
+		w.Header().Set(w3g.ContentEncoding, "deflate, gzip")
+		w.Write(body)
+	}))
+	defer server.Close()
+	var client *http.Client = &http.Client{Transport: &w3g.Decompressor{
+		Decoders:  w3g.NewDecompressor().Decoders,
+		Transport: server.Client().Transport,
+	}}
+	var res, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var b, _ = io.ReadAll(res.Body)
+	if string(b) != "stacked" {
+		t.Errorf("body = %q, want stacked", b)
+	}
+	if res.Header.Get(w3g.ContentEncoding) != "" || res.ContentLength != -1 || !res.Uncompressed {
+		t.Errorf("response was not rewritten: %v, %d", res.Header, res.ContentLength)
+	}
+}
+
+func gzipBytes(b []byte) []byte {
+	var buf bytes.Buffer
+	var w *gzip.Writer = gzip.NewWriter(&buf)
+	w.Write(b)
+	w.Close()
+	return buf.Bytes()
+}
+
+func zlibBytes(b []byte) []byte {
+	var buf bytes.Buffer
+	var w *zlib.Writer = zlib.NewWriter(&buf)
+	w.Write(b)
+	w.Close()
+	return buf.Bytes()
+}
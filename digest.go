@@ -0,0 +1,481 @@
+package w3g
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gellel/w3g/sfv"
+)
+
+// ErrDigestMismatch is returned when a digest of an IntegrityHeader does not match the content it was verified against.
+var ErrDigestMismatch = errors.New("w3g: digest does not match the content")
+
+// ErrDigestUnsupported is returned when an IntegrityHeader does not have a digest with a supported algorithm.
+var ErrDigestUnsupported = errors.New("w3g: no digest with a supported algorithm")
+
+// digestAlgorithms are the supported algorithms of the Hash Algorithms for HTTP Digest Fields registry. The insecure
+// algorithms of the registry, such as md5 and sha, are not supported.
+var digestAlgorithms = map[string]func() hash.Hash{
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// ContentDigester is a struct to configure a middleware that adds a Content-Digest HTTP header to responses, and a
+// Repr-Digest HTTP header to complete responses of requests with a Want-Repr-Digest HTTP header. The algorithm is
+// chosen from Algorithms, in order of the server's preference, with the Want-Content-Digest and Want-Repr-Digest
+// HTTP headers of the request. Responses are buffered until the handler returns, unless the handler flushes them, in
+// which case the digests are sent as trailers. Responses to HEAD requests have no body to digest and get neither HTTP
+// header.
+type ContentDigester struct {
+	Algorithms []string `json:"algorithms"`
+}
+
+// Digester is a io.Writer that computes digests of what is written to it with one or more algorithms.
+type Digester struct {
+	algorithms []string
+	hashes     []hash.Hash
+}
+
+// DigestPreference is a struct to hold an algorithm of a Want-Content-Digest or Want-Repr-Digest HTTP header and its
+// weight, from 0 for not acceptable to 10 for the most preferred.
+type DigestPreference struct {
+	Algorithm string `json:"algorithm"`
+	Weight    int    `json:"weight"`
+}
+
+// DigestValue is a struct to hold a single digest of a Content-Digest or Repr-Digest HTTP header.
+type DigestValue struct {
+	Algorithm string `json:"algorithm"`
+	Value     []byte `json:"value"`
+}
+
+// DigestVerifier is a struct to configure a middleware that verifies the Content-Digest and Repr-Digest HTTP headers of
+// requests against their bodies, and rejects requests whose body does not match with a 400 Bad Request response. The
+// two HTTP headers are verified separately, and every digest with a supported algorithm must match. Requests without a
+// digest with one of the supported algorithms are rejected when Required is set. Request bodies are read before the
+// handler is called, and bodies larger than MaxBodySize bytes are rejected with a 413 Content Too Large response. A
+// MaxBodySize of 0 does not limit the size of bodies.
+type DigestVerifier struct {
+	Algorithms  []string `json:"algorithms"`
+	MaxBodySize int64    `json:"max_body_size"`
+	Required    bool     `json:"required"`
+}
+
+// IntegrityHeader is a struct to prepare a Content-Digest or Repr-Digest HTTP header as defined by RFC 9530.
+type IntegrityHeader struct {
+	Digests []DigestValue `json:"digests"`
+}
+
+// IntegrityPreferenceHeader is a struct to prepare a Want-Content-Digest or Want-Repr-Digest HTTP header as defined
+// by RFC 9530.
+type IntegrityPreferenceHeader struct {
+	Preferences []DigestPreference `json:"preferences"`
+}
+
+// digestWriter is a http.ResponseWriter that computes the digests of a response and sends them as HTTP headers or,
+// once the response is flushed, as trailers.
+type digestWriter struct {
+	http.ResponseWriter
+	buf         []byte
+	code        int
+	content     string
+	digester    *Digester
+	repr        string
+	streaming   bool
+	wroteHeader bool
+}
+
+// ComputeDigests reads r to the end and returns its digests with each algorithm.
+func ComputeDigests(r io.Reader, algorithms ...string) (IntegrityHeader, error) {
+	var d, err = NewDigester(algorithms...)
+	if err != nil {
+		return IntegrityHeader{}, err
+	}
+	if _, err = io.Copy(d, r); err != nil {
+		return IntegrityHeader{}, err
+	}
+	return d.Sum(), nil
+}
+
+// NewDigester returns a Digester for the sha-256 and sha-512 algorithms. It returns ErrDigestUnsupported when an
+// algorithm is not supported.
+func NewDigester(algorithms ...string) (*Digester, error) {
+	var d *Digester = &Digester{}
+	for _, algorithm := range algorithms {
+		var h, ok = digestAlgorithms[algorithm]
+		if !ok {
+			return nil, ErrDigestUnsupported
+		}
+		d.algorithms = append(d.algorithms, algorithm)
+		d.hashes = append(d.hashes, h())
+	}
+	return d, nil
+}
+
+// ParseContentDigest parses a Content-Digest HTTP header value.
+func ParseContentDigest(s string) (IntegrityHeader, error) {
+	return parseIntegrity(ContentDigest, s)
+}
+
+// ParseReprDigest parses a Repr-Digest HTTP header value.
+func ParseReprDigest(s string) (IntegrityHeader, error) {
+	return parseIntegrity(ReprDigest, s)
+}
+
+// ParseWantContentDigest parses a Want-Content-Digest HTTP header value.
+func ParseWantContentDigest(s string) (IntegrityPreferenceHeader, error) {
+	return parseIntegrityPreference(WantContentDigest, s)
+}
+
+// ParseWantReprDigest parses a Want-Repr-Digest HTTP header value.
+func ParseWantReprDigest(s string) (IntegrityPreferenceHeader, error) {
+	return parseIntegrityPreference(WantReprDigest, s)
+}
+
+// Handler returns a http.Handler that adds digests to the responses of next.
+func (c ContentDigester) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var algorithms []string = c.algorithms()
+		var dw *digestWriter = &digestWriter{ResponseWriter: w, content: algorithms[0]}
+		if values := r.Header.Values(WantContentDigest); len(values) != 0 {
+			if want, err := ParseWantContentDigest(strings.Join(values, ", ")); err == nil {
+				if algorithm, ok := want.Choose(algorithms); ok {
+					dw.content = algorithm
+				}
+			}
+		}
+		if values := r.Header.Values(WantReprDigest); len(values) != 0 {
+			dw.repr = algorithms[0]
+			if want, err := ParseWantReprDigest(strings.Join(values, ", ")); err == nil {
+				if algorithm, ok := want.Choose(algorithms); ok {
+					dw.repr = algorithm
+				}
+			}
+		}
+		if r.Method == http.MethodHead {
+			dw.content, dw.repr = "", ""
+		}
+		var used []string
+		for _, algorithm := range []string{dw.content, dw.repr} {
+			if len(algorithm) != 0 && (len(used) == 0 || used[0] != algorithm) {
+				used = append(used, algorithm)
+			}
+		}
+		var err error
+		if dw.digester, err = NewDigester(used...); err != nil || len(used) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(dw, r)
+		dw.close()
+	})
+}
+
+// algorithms returns the Algorithms of a ContentDigester, or sha-256 when it has none.
+func (c ContentDigester) algorithms() []string {
+	if len(c.Algorithms) == 0 {
+		return []string{"sha-256"}
+	}
+	return c.Algorithms
+}
+
+// Sum returns the digests of what was written to a Digester.
+func (d *Digester) Sum() IntegrityHeader {
+	var h IntegrityHeader
+	for i, algorithm := range d.algorithms {
+		h.Digests = append(h.Digests, DigestValue{Algorithm: algorithm, Value: d.hashes[i].Sum(nil)})
+	}
+	return h
+}
+
+// Write adds data to the digests.
+func (d *Digester) Write(b []byte) (int, error) {
+	for _, h := range d.hashes {
+		h.Write(b)
+	}
+	return len(b), nil
+}
+
+// Handler returns a http.Handler that verifies the digests of requests before calling next.
+func (v DigestVerifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var headers []IntegrityHeader
+		for _, name := range []string{ContentDigest, ReprDigest} {
+			var values []string = r.Header.Values(name)
+			if len(values) == 0 {
+				continue
+			}
+			var h, err = parseIntegrity(name, strings.Join(values, ", "))
+			if err != nil {
+				v.reject(w, http.StatusBadRequest)
+				return
+			}
+			var supported IntegrityHeader
+			for _, d := range h.Digests {
+				if v.supports(d.Algorithm) {
+					supported.Digests = append(supported.Digests, d)
+				}
+			}
+			if len(supported.Digests) != 0 {
+				headers = append(headers, supported)
+			}
+		}
+		if len(headers) == 0 {
+			if v.Required {
+				v.reject(w, http.StatusBadRequest)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		var body io.Reader = r.Body
+		if v.MaxBodySize > 0 {
+			body = http.MaxBytesReader(w, r.Body, v.MaxBodySize)
+		}
+		var data, err = io.ReadAll(body)
+		if err != nil {
+			var maxBytesError *http.MaxBytesError
+			if errors.As(err, &maxBytesError) {
+				v.reject(w, http.StatusRequestEntityTooLarge)
+				return
+			}
+			v.reject(w, http.StatusBadRequest)
+			return
+		}
+		for _, h := range headers {
+			if h.Verify(bytes.NewReader(data)) != nil {
+				v.reject(w, http.StatusBadRequest)
+				return
+			}
+		}
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// algorithms returns the Algorithms of a DigestVerifier, or sha-256 and sha-512 when it has none.
+func (v DigestVerifier) algorithms() []string {
+	if len(v.Algorithms) == 0 {
+		return []string{"sha-256", "sha-512"}
+	}
+	return v.Algorithms
+}
+
+// reject sends an error response with a Want-Content-Digest HTTP header listing the supported algorithms.
+func (v DigestVerifier) reject(w http.ResponseWriter, code int) {
+	var want IntegrityPreferenceHeader
+	var weight int = 10
+	for _, algorithm := range v.algorithms() {
+		want.Preferences = append(want.Preferences, DigestPreference{Algorithm: algorithm, Weight: weight})
+		if weight > 1 {
+			weight--
+		}
+	}
+	w.Header().Set(WantContentDigest, want.String())
+	http.Error(w, http.StatusText(code), code)
+}
+
+// supports reports whether a DigestVerifier verifies digests with an algorithm.
+func (v DigestVerifier) supports(algorithm string) bool {
+	for _, a := range v.algorithms() {
+		if a == algorithm {
+			var _, ok = digestAlgorithms[a]
+			return ok
+		}
+	}
+	return false
+}
+
+// Get returns the digest of an IntegrityHeader with an algorithm.
+func (i IntegrityHeader) Get(algorithm string) ([]byte, bool) {
+	for _, d := range i.Digests {
+		if d.Algorithm == algorithm {
+			return d.Value, true
+		}
+	}
+	return nil, false
+}
+
+// String returns a string representation of a Content-Digest or Repr-Digest HTTP header.
+func (i IntegrityHeader) String() string {
+	var d sfv.Dictionary
+	for _, digest := range i.Digests {
+		d.Set(digest.Algorithm, sfv.Item{Value: digest.Value})
+	}
+	return (d.String())
+}
+
+// Verify reads r to the end and checks it against every digest of an IntegrityHeader with a supported algorithm,
+// including digests that share an algorithm. It returns ErrDigestMismatch when a digest does not match, and
+// ErrDigestUnsupported when no digest has a supported algorithm.
+func (i IntegrityHeader) Verify(r io.Reader) error {
+	var algorithms []string
+	var seen map[string]bool = map[string]bool{}
+	for _, d := range i.Digests {
+		if _, ok := digestAlgorithms[d.Algorithm]; ok && !seen[d.Algorithm] {
+			seen[d.Algorithm] = true
+			algorithms = append(algorithms, d.Algorithm)
+		}
+	}
+	if len(algorithms) == 0 {
+		return ErrDigestUnsupported
+	}
+	var computed, err = ComputeDigests(r, algorithms...)
+	if err != nil {
+		return err
+	}
+	for _, d := range i.Digests {
+		var actual, ok = computed.Get(d.Algorithm)
+		if ok && subtle.ConstantTimeCompare(d.Value, actual) != 1 {
+			return ErrDigestMismatch
+		}
+	}
+	return nil
+}
+
+// Choose returns the algorithm with the highest weight among the algorithms a server supports, in order of its
+// preference. Algorithms with a weight of 0 are not acceptable. The returned bool is false when no supported
+// algorithm is acceptable.
+func (i IntegrityPreferenceHeader) Choose(supported []string) (string, bool) {
+	var best string
+	var bestWeight int
+	for _, algorithm := range supported {
+		for _, p := range i.Preferences {
+			if p.Algorithm == algorithm && p.Weight > bestWeight {
+				best, bestWeight = algorithm, p.Weight
+			}
+		}
+	}
+	return best, len(best) != 0
+}
+
+// String returns a string representation of a Want-Content-Digest or Want-Repr-Digest HTTP header.
+func (i IntegrityPreferenceHeader) String() string {
+	var d sfv.Dictionary
+	for _, p := range i.Preferences {
+		d.Set(p.Algorithm, sfv.Item{Value: int64(p.Weight)})
+	}
+	return (d.String())
+}
+
+// Flush sends the response headers and buffered data to the client. The digests are then sent as trailers.
+func (w *digestWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.streaming {
+		w.streaming = true
+		w.ResponseWriter.WriteHeader(w.code)
+		w.ResponseWriter.Write(w.buf)
+		w.buf = nil
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use by http.ResponseController.
+func (w *digestWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Write adds data to the digests and buffers it, or writes it once the response has been flushed.
+func (w *digestWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.digester.Write(b)
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	return len(b), nil
+}
+
+// WriteHeader records the status code of the response. Informational responses are sent immediately.
+func (w *digestWriter) WriteHeader(code int) {
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.code = code
+}
+
+// close adds the digests of the response as HTTP headers, or as trailers when the response has been flushed, and
+// sends the buffered response.
+func (w *digestWriter) close() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	var prefix string
+	if w.streaming {
+		prefix = http.TrailerPrefix
+	}
+	if w.code >= 200 && w.code != http.StatusNoContent && w.code != http.StatusNotModified {
+		var sum IntegrityHeader = w.digester.Sum()
+		var h http.Header = w.Header()
+		if value, ok := sum.Get(w.content); ok && len(w.content) != 0 {
+			h.Set(prefix+ContentDigest, IntegrityHeader{Digests: []DigestValue{{Algorithm: w.content, Value: value}}}.String())
+		}
+		if value, ok := sum.Get(w.repr); ok && len(w.repr) != 0 && w.code != http.StatusPartialContent {
+			h.Set(prefix+ReprDigest, IntegrityHeader{Digests: []DigestValue{{Algorithm: w.repr, Value: value}}}.String())
+		}
+	}
+	if !w.streaming {
+		w.ResponseWriter.WriteHeader(w.code)
+		w.ResponseWriter.Write(w.buf)
+	}
+}
+
+// parseIntegrity parses a Content-Digest or Repr-Digest HTTP header value.
+func parseIntegrity(header, s string) (IntegrityHeader, error) {
+	var d, err = sfv.ParseDictionary(s)
+	if err != nil {
+		return IntegrityHeader{}, &HeaderValueError{Header: header, Value: s}
+	}
+	var i IntegrityHeader
+	for _, m := range d {
+		var item, ok = m.Value.(sfv.Item)
+		if !ok {
+			return IntegrityHeader{}, &HeaderValueError{Header: header, Value: s}
+		}
+		var value, isBytes = item.Value.([]byte)
+		if !isBytes {
+			return IntegrityHeader{}, &HeaderValueError{Header: header, Value: s}
+		}
+		i.Digests = append(i.Digests, DigestValue{Algorithm: m.Key, Value: value})
+	}
+	return i, nil
+}
+
+// parseIntegrityPreference parses a Want-Content-Digest or Want-Repr-Digest HTTP header value.
+func parseIntegrityPreference(header, s string) (IntegrityPreferenceHeader, error) {
+	var d, err = sfv.ParseDictionary(s)
+	if err != nil {
+		return IntegrityPreferenceHeader{}, &HeaderValueError{Header: header, Value: s}
+	}
+	var i IntegrityPreferenceHeader
+	for _, m := range d {
+		var item, ok = m.Value.(sfv.Item)
+		if !ok {
+			return IntegrityPreferenceHeader{}, &HeaderValueError{Header: header, Value: s}
+		}
+		var weight, isInteger = item.Value.(int64)
+		if !isInteger || weight < 0 || weight > 10 {
+			return IntegrityPreferenceHeader{}, &HeaderValueError{Header: header, Value: s}
+		}
+		i.Preferences = append(i.Preferences, DigestPreference{Algorithm: m.Key, Weight: int(weight)})
+	}
+	return i, nil
+}
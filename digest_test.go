@@ -0,0 +1,161 @@
+package w3g_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+const helloWorldSHA256 string = "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"
+
+const helloWorldSHA512 string = "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:"
+
+func TestComputeDigests(t *testing.T) {
+	var h, err = w3g.ComputeDigests(strings.NewReader(`{"hello": "world"}`), "sha-256", "sha-512")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := h.String(), helloWorldSHA256+", "+helloWorldSHA512; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if _, err := w3g.ComputeDigests(strings.NewReader(""), "md5"); !errors.Is(err, w3g.ErrDigestUnsupported) {
+		t.Errorf("ComputeDigests() with md5 error = %v", err)
+	}
+}
+
+func TestIntegrityHeaderVerify(t *testing.T) {
+	var h, err = w3g.ParseContentDigest(helloWorldSHA512 + ", md5=:AAAA:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Verify(strings.NewReader(`{"hello": "world"}`)); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+	if err := h.Verify(strings.NewReader(`{"hello": "there"}`)); !errors.Is(err, w3g.ErrDigestMismatch) {
+		t.Errorf("Verify() = %v, want ErrDigestMismatch", err)
+	}
+	h.Digests = append(h.Digests, w3g.DigestValue{Algorithm: "sha-512", Value: make([]byte, 64)})
+	if err := h.Verify(strings.NewReader(`{"hello": "world"}`)); !errors.Is(err, w3g.ErrDigestMismatch) {
+		t.Errorf("Verify() with a second wrong sha-512 digest = %v, want ErrDigestMismatch", err)
+	}
+	h, _ = w3g.ParseReprDigest("md5=:AAAA:")
+	if err := h.Verify(strings.NewReader("")); !errors.Is(err, w3g.ErrDigestUnsupported) {
+		t.Errorf("Verify() = %v, want ErrDigestUnsupported", err)
+	}
+	for _, invalid := range []string{"sha-256=abc", "sha-256=(:AAAA:)", "SHA-256=:AAAA:"} {
+		if _, err := w3g.ParseContentDigest(invalid); err == nil {
+			t.Errorf("ParseContentDigest(%q) did not return an error", invalid)
+		}
+	}
+}
+
+func TestIntegrityPreferenceHeader(t *testing.T) {
+	var want, err = w3g.ParseWantContentDigest("sha-256=1, sha-512=3, md5=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if algorithm, ok := want.Choose([]string{"sha-256", "sha-512"}); algorithm != "sha-512" || !ok {
+		t.Errorf("Choose() = %q, %v, want sha-512", algorithm, ok)
+	}
+	want, _ = w3g.ParseWantReprDigest("sha-256=0")
+	if algorithm, ok := want.Choose([]string{"sha-256"}); ok {
+		t.Errorf("Choose() = %q with a weight of 0", algorithm)
+	}
+	if got := want.String(); got != "sha-256=0" {
+		t.Errorf("String() = %q", got)
+	}
+	if _, err := w3g.ParseWantContentDigest("sha-256=11"); err == nil {
+		t.Error("ParseWantContentDigest(\"sha-256=11\") did not return an error")
+	}
+}
+
+func TestContentDigester(t *testing.T) {
+	var handler http.Handler = w3g.ContentDigester{Algorithms: []string{"sha-256", "sha-512"}}.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"hello": `)
+		if r.URL.Path == "/stream" {
+			w.(http.Flusher).Flush()
+		}
+		io.WriteString(w, `"world"}`)
+	}))
+	var r *http.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(w3g.WantReprDigest, "sha-512=5, sha-256=2")
+	var w *httptest.ResponseRecorder = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if got := w.Header().Get(w3g.ContentDigest); got != helloWorldSHA256 {
+		t.Errorf("Content-Digest = %q, want %q", got, helloWorldSHA256)
+	}
+	if got := w.Header().Get(w3g.ReprDigest); got != helloWorldSHA512 {
+		t.Errorf("Repr-Digest = %q, want %q", got, helloWorldSHA512)
+	}
+	if got := w.Body.String(); got != `{"hello": "world"}` {
+		t.Errorf("body = %q", got)
+	}
+
+	var server *httptest.Server = httptest.NewServer(handler)
+	defer server.Close()
+	var res, err = server.Client().Get(server.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadAll(res.Body)
+	res.Body.Close()
+	if got := res.Trailer.Get(w3g.ContentDigest); got != helloWorldSHA256 {
+		t.Errorf("Content-Digest trailer = %q, want %q", got, helloWorldSHA256)
+	}
+
+	r = httptest.NewRequest(http.MethodHead, "/", nil)
+	r.Header.Set(w3g.WantReprDigest, "sha-256=1")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if got := w.Header().Values(w3g.ReprDigest); len(got) != 0 {
+		t.Errorf("Repr-Digest of a HEAD response = %q", got)
+	}
+	if got := w.Header().Values(w3g.ContentDigest); len(got) != 0 {
+		t.Errorf("Content-Digest of a HEAD response = %q", got)
+	}
+}
+
+func TestDigestVerifier(t *testing.T) {
+	var handler http.Handler = w3g.DigestVerifier{MaxBodySize: 64, Required: true}.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+	for _, test := range []struct {
+		body   string
+		digest string
+		repr   string
+		code   int
+	}{
+		{`{"hello": "world"}`, helloWorldSHA256, "", http.StatusOK},
+		{`{"hello": "world"}`, helloWorldSHA512 + ", md5=:AAAA:", "", http.StatusOK},
+		{`{"hello": "there"}`, helloWorldSHA256, "", http.StatusBadRequest},
+		{`{"hello": "world"}`, "", "", http.StatusBadRequest},
+		{`{"hello": "world"}`, "md5=:AAAA:", "", http.StatusBadRequest},
+		{strings.Repeat("a", 100), helloWorldSHA256, "", http.StatusRequestEntityTooLarge},
+		{`{"hello": "world"}`, helloWorldSHA256, "sha-256=:" + strings.Repeat("A", 43) + "=:", http.StatusBadRequest},
+		{`{"hello": "world"}`, "", helloWorldSHA512, http.StatusOK},
+	} {
+		var r *http.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+		if len(test.digest) != 0 {
+			r.Header.Set(w3g.ContentDigest, test.digest)
+		}
+		if len(test.repr) != 0 {
+			r.Header.Set(w3g.ReprDigest, test.repr)
+		}
+		var w *httptest.ResponseRecorder = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("%q: status = %d, want %d", test.digest, w.Code, test.code)
+		}
+		if test.code == http.StatusOK && w.Body.String() != test.body {
+			t.Errorf("%q: handler read %q", test.digest, w.Body.String())
+		}
+		if test.code != http.StatusOK && w.Header().Get(w3g.WantContentDigest) != "sha-256=10, sha-512=9" {
+			t.Errorf("%q: Want-Content-Digest = %q", test.digest, w.Header().Get(w3g.WantContentDigest))
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package w3g
+
+import (
+	"container/list"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// earlyHintRels are the relation types of the links that user agents act on in a 103 Early Hints response.
+var earlyHintRels = []string{"modulepreload", "preconnect", "preload"}
+
+// EarlyHintsLearner is a struct to configure a middleware that learns which preload, preconnect and modulepreload
+// links each route sends in its successful responses, and sends those links as a 103 Early Hints response on the
+// following requests to the route, while the handler is still computing the final response. The links learned from
+// the response to one user are sent to every user, so only responses that a shared cache could store are learned:
+// responses with a Set-Cookie HTTP header, a Cache-Control of private or no-store, a Vary of Cookie, Authorization or
+// *, or to a request with an Authorization HTTP header and without a Cache-Control of public are not learned. When
+// MaxRoutes routes are known, the least recently used route is forgotten to learn a new one.
+type EarlyHintsLearner struct {
+	MaxLinks  int                          `json:"max_links"`
+	MaxRoutes int                          `json:"max_routes"`
+	Route     func(r *http.Request) string `json:"-"`
+	mu        sync.Mutex
+	order     *list.List
+	routes    map[string]*list.Element
+}
+
+// earlyHintsRoute is a route learned by an EarlyHintsLearner and its links.
+type earlyHintsRoute struct {
+	links []LinkValue
+	route string
+}
+
+// earlyHintsWriter is a http.ResponseWriter that records the early hint links of a successful response.
+type earlyHintsWriter struct {
+	http.ResponseWriter
+	authorized  bool
+	learner     *EarlyHintsLearner
+	route       string
+	wroteHeader bool
+}
+
+// NewEarlyHintsLearner returns an EarlyHintsLearner that learns up to 16 links for each of the 1000 most recently
+// used routes, identified by the path of the request URL.
+func NewEarlyHintsLearner() *EarlyHintsLearner {
+	return &EarlyHintsLearner{MaxLinks: 16, MaxRoutes: 1000}
+}
+
+// WriteEarlyHints sends the preload, preconnect and modulepreload links among links as a 103 Early Hints response.
+// Other links are ignored. The Link HTTP header of w is restored afterwards, so that the final response only carries
+// the links its handler adds. It returns false when nothing is sent, because no link can be sent or because the
+// request is a HTTP/1.0 request, which cannot receive informational responses.
+func WriteEarlyHints(w http.ResponseWriter, r *http.Request, links ...LinkValue) bool {
+	links = earlyHintLinks(links)
+	if len(links) == 0 || !r.ProtoAtLeast(1, 1) {
+		return false
+	}
+	var h http.Header = w.Header()
+	var previous []string = h.Values(Link)
+	for _, l := range links {
+		h.Add(Link, l.String())
+	}
+	w.WriteHeader(http.StatusEarlyHints)
+	h.Del(Link)
+	for _, v := range previous {
+		h.Add(Link, v)
+	}
+	return true
+}
+
+// Forget discards the links learned for every route.
+func (e *EarlyHintsLearner) Forget() {
+	e.mu.Lock()
+	e.order, e.routes = nil, nil
+	e.mu.Unlock()
+}
+
+// Handler returns a http.Handler that sends the links learned for the route of a GET request as a 103 Early Hints
+// response before calling next, and learns the links of the route from the response of next.
+func (e *EarlyHintsLearner) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var route string = e.route(r)
+		WriteEarlyHints(w, r, e.Links(route)...)
+		var ew *earlyHintsWriter = &earlyHintsWriter{ResponseWriter: w, authorized: len(r.Header.Get(Authorization)) != 0, learner: e, route: route}
+		next.ServeHTTP(ew, r)
+		if !ew.wroteHeader {
+			e.learn(route, w.Header(), ew.authorized)
+		}
+	})
+}
+
+// Links returns the links learned for a route.
+func (e *EarlyHintsLearner) Links(route string) []LinkValue {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var element, ok = e.routes[route]
+	if !ok {
+		return nil
+	}
+	e.order.MoveToFront(element)
+	return element.Value.(*earlyHintsRoute).links
+}
+
+// learn records the early hint links of a response to a route. Routes that do not send any early hint links, or
+// whose response may be personalized, are forgotten, and the least recently used route is forgotten once MaxRoutes
+// routes are known.
+func (e *EarlyHintsLearner) learn(route string, h http.Header, authorized bool) {
+	var links, err = ParseLink(strings.Join(h.Values(Link), ", "))
+	if err != nil {
+		return
+	}
+	var learned []LinkValue = earlyHintLinks(links.Links)
+	if e.MaxLinks > 0 && len(learned) > e.MaxLinks {
+		learned = learned[:e.MaxLinks]
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(learned) == 0 || !sharedResponse(h, authorized) {
+		if element, ok := e.routes[route]; ok {
+			e.order.Remove(element)
+			delete(e.routes, route)
+		}
+		return
+	}
+	if element, ok := e.routes[route]; ok {
+		element.Value.(*earlyHintsRoute).links = learned
+		e.order.MoveToFront(element)
+		return
+	}
+	if e.routes == nil {
+		e.order, e.routes = list.New(), map[string]*list.Element{}
+	}
+	e.routes[route] = e.order.PushFront(&earlyHintsRoute{links: learned, route: route})
+	for e.MaxRoutes > 0 && len(e.routes) > e.MaxRoutes {
+		var oldest *list.Element = e.order.Back()
+		e.order.Remove(oldest)
+		delete(e.routes, oldest.Value.(*earlyHintsRoute).route)
+	}
+}
+
+// route returns the route of a HTTP request.
+func (e *EarlyHintsLearner) route(r *http.Request) string {
+	if e.Route != nil {
+		return e.Route(r)
+	}
+	return r.URL.Path
+}
+
+// Flush sends any buffered data to the client.
+func (w *earlyHintsWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use by http.ResponseController.
+func (w *earlyHintsWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Write writes the response headers, when they have not been written yet, and then data.
+func (w *earlyHintsWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteHeader learns the early hint links of a successful response and writes the response headers.
+func (w *earlyHintsWriter) WriteHeader(code int) {
+	if !w.wroteHeader && code >= 200 {
+		w.wroteHeader = true
+		if code < 300 {
+			w.learner.learn(w.route, w.Header(), w.authorized)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// earlyHintLinks returns the links that user agents act on in a 103 Early Hints response.
+func earlyHintLinks(links []LinkValue) []LinkValue {
+	var hints []LinkValue
+	for _, l := range links {
+		for _, rel := range earlyHintRels {
+			if l.HasRel(rel) {
+				hints = append(hints, l)
+				break
+			}
+		}
+	}
+	return hints
+}
+
+// sharedResponse reports whether a shared cache could store a response, so that what it links to is the same for
+// every user. Authorized reports whether the request had an Authorization HTTP header.
+func sharedResponse(h http.Header, authorized bool) bool {
+	if len(h.Values(SetCookie)) != 0 {
+		return false
+	}
+	var public bool
+	for _, value := range h.Values(CacheControl) {
+		for _, directive := range strings.Split(value, ",") {
+			var name, _, _ = strings.Cut(strings.TrimSpace(directive), "=")
+			switch strings.ToLower(name) {
+			case "no-store", "private":
+				return false
+			case "public":
+				public = true
+			}
+		}
+	}
+	for _, value := range h.Values(Vary) {
+		for _, name := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "*", "authorization", "cookie":
+				return false
+			}
+		}
+	}
+	return !authorized || public
+}
@@ -0,0 +1,111 @@
+package w3g_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestEarlyHintsLearner(t *testing.T) {
+	var learner *w3g.EarlyHintsLearner = w3g.NewEarlyHintsLearner()
+	var server *httptest.Server = httptest.NewServer(learner.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var h w3g.LinkHeader = w3g.LinkHeader{Links: []w3g.LinkValue{
+			{Rel: []string{"preload"}, Target: url.URL{Path: "/app.css"}, Params: map[string]string{"as": "style"}},
+			{Rel: []string{"preconnect"}, Target: url.URL{Scheme: "https", Host: "cdn.example.com"}},
+			{Rel: []string{"canonical"}, Target: url.URL{Path: "/page"}},
+		}}
+		w.Header().Set(w3g.Link, h.String())
+		w.Write([]byte("page"))
+	})))
+	defer server.Close()
+	var get = func() []string {
+		var hints []string
+		var trace *httptrace.ClientTrace = &httptrace.ClientTrace{
+			Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+				if code == http.StatusEarlyHints {
+					hints = append(hints, header.Values(w3g.Link)...)
+				}
+				return nil
+			},
+		}
+		var r, _ = http.NewRequest(http.MethodGet, server.URL+"/page", nil)
+		r = r.WithContext(httptrace.WithClientTrace(r.Context(), trace))
+		var res, err = server.Client().Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if got := res.Header.Values(w3g.Link); len(got) != 1 {
+			t.Errorf("final response Link headers = %q, want the handler's only", got)
+		}
+		return hints
+	}
+	if hints := get(); len(hints) != 0 {
+		t.Errorf("first request received early hints %q", hints)
+	}
+	var want []string = []string{"</app.css>; rel=preload; as=style", "<https://cdn.example.com>; rel=preconnect"}
+	if hints := get(); !reflect.DeepEqual(hints, want) {
+		t.Errorf("second request received early hints %q, want %q", hints, want)
+	}
+	learner.Forget()
+	if links := learner.Links("/page"); links != nil {
+		t.Errorf("Links() after Forget() = %v", links)
+	}
+}
+
+func TestEarlyHintsLearnerRoutes(t *testing.T) {
+	var learner *w3g.EarlyHintsLearner = &w3g.EarlyHintsLearner{MaxRoutes: 2}
+	var handler http.Handler = learner.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(w3g.Link, "</app.js>; rel=modulepreload")
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set(w3g.CacheControl, "max-age=60, private")
+		case "/vary":
+			w.Header().Set(w3g.Vary, "Accept-Encoding, Cookie")
+		case "/cookie":
+			w.Header().Set(w3g.SetCookie, "id=1")
+		}
+	}))
+	var serve = func(path string, authorization string) {
+		var r *http.Request = httptest.NewRequest(http.MethodGet, path, nil)
+		if len(authorization) != 0 {
+			r.Header.Set(w3g.Authorization, authorization)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	for _, path := range []string{"/private", "/vary", "/cookie"} {
+		serve(path, "")
+		if links := learner.Links(path); links != nil {
+			t.Errorf("Links(%q) = %v, want a personalized response not to be learned", path, links)
+		}
+	}
+	serve("/account", "Bearer token")
+	if links := learner.Links("/account"); links != nil {
+		t.Errorf("Links(/account) = %v, want an authorized response not to be learned", links)
+	}
+	serve("/a", "")
+	serve("/b", "")
+	learner.Links("/a")
+	serve("/c", "")
+	if learner.Links("/a") == nil || learner.Links("/b") != nil || learner.Links("/c") == nil {
+		t.Errorf("Links() after eviction: /a = %v, /b = %v, /c = %v, want /b evicted", learner.Links("/a"), learner.Links("/b"), learner.Links("/c"))
+	}
+}
+
+func TestWriteEarlyHints(t *testing.T) {
+	var w *httptest.ResponseRecorder = httptest.NewRecorder()
+	var r *http.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if w3g.WriteEarlyHints(w, r, w3g.LinkValue{Rel: []string{"next"}, Target: url.URL{Path: "/2"}}) {
+		t.Error("WriteEarlyHints() sent a link that is not an early hint")
+	}
+	r.ProtoMajor, r.ProtoMinor = 1, 0
+	if w3g.WriteEarlyHints(w, r, w3g.LinkValue{Rel: []string{"preload"}, Target: url.URL{Path: "/a.js"}}) {
+		t.Error("WriteEarlyHints() sent early hints to a HTTP/1.0 request")
+	}
+}
@@ -0,0 +1,150 @@
+package w3g
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gellel/w3g/sfv"
+)
+
+// FetchMetadata is a struct to hold the Sec-Fetch-Dest, Sec-Fetch-Mode, Sec-Fetch-Site and Sec-Fetch-User HTTP request headers.
+type FetchMetadata struct {
+	Dest SecFetchDestValue  `json:"dest"`
+	Mode SecFetchModeValue  `json:"mode"`
+	Site SecFetchSiteValue  `json:"site"`
+	User SecFetchUserHeader `json:"user"`
+}
+
+// HeaderValueError is returned when a HTTP header value is not one of the values its grammar permits.
+type HeaderValueError struct {
+	Header string `json:"header"`
+	Value  string `json:"value"`
+}
+
+// Error returns a string representation of a HeaderValueError.
+func (h *HeaderValueError) Error() string {
+	return (fmt.Sprintf("w3g: invalid %s header value %q", h.Header, h.Value))
+}
+
+// ParseFetchMetadata parses the Fetch Metadata HTTP request headers. Headers that are absent are left as their zero value.
+func ParseFetchMetadata(h http.Header) (FetchMetadata, error) {
+	var f FetchMetadata
+	var err error
+	var v string
+	if v = h.Get(SecFetchDest); len(v) != 0 {
+		if f.Dest, err = ParseSecFetchDest(v); err != nil {
+			return f, err
+		}
+	}
+	if v = h.Get(SecFetchMode); len(v) != 0 {
+		if f.Mode, err = ParseSecFetchMode(v); err != nil {
+			return f, err
+		}
+	}
+	if v = h.Get(SecFetchSite); len(v) != 0 {
+		if f.Site, err = ParseSecFetchSite(v); err != nil {
+			return f, err
+		}
+	}
+	if v = h.Get(SecFetchUser); len(v) != 0 {
+		var item, err = sfv.ParseItem(v)
+		var activated, ok = item.Value.(bool)
+		if err != nil || !ok {
+			return f, &HeaderValueError{Header: SecFetchUser, Value: v}
+		}
+		f.User.Activated = activated
+	}
+	return f, nil
+}
+
+// ResourceIsolationPolicy is a struct to configure a Fetch Metadata Resource Isolation Policy, which rejects
+// cross-site requests for resources that are only meant to be loaded by the site itself. A path of ExemptPaths
+// exempts itself and the paths below it: "/api" exempts "/api" and "/api/users", but not "/api-internal".
+type ResourceIsolationPolicy struct {
+	AllowNavigation bool                `json:"allow_navigation"`
+	AllowedSites    []SecFetchSiteValue `json:"allowed_sites"`
+	ExemptPaths     []string            `json:"exempt_paths"`
+	Logger          *log.Logger         `json:"-"`
+	Rejected        http.Handler        `json:"-"`
+	ReportOnly      bool                `json:"report_only"`
+}
+
+// NewResourceIsolationPolicy returns a ResourceIsolationPolicy that allows same-origin and same-site requests,
+// requests the user agent initiated itself, such as a typed URL or a bookmark, and top-level GET navigations, and
+// rejects every other cross-site request.
+func NewResourceIsolationPolicy() *ResourceIsolationPolicy {
+	return &ResourceIsolationPolicy{
+		AllowNavigation: true,
+		AllowedSites:    []SecFetchSiteValue{SecFetchSiteSameOrigin, SecFetchSiteSameSite, SecFetchSiteNone}}
+}
+
+// Allow reports whether the ResourceIsolationPolicy permits a HTTP request. When the request is not permitted,
+// the returned string describes why. Same-origin requests, requests the user agent initiated itself and requests
+// from AllowedSites are permitted whatever their Sec-Fetch-Dest and Sec-Fetch-Mode, and values of those headers
+// that are not known are treated as unknown.
+func (p *ResourceIsolationPolicy) Allow(r *http.Request) (bool, string) {
+	for _, path := range p.ExemptPaths {
+		if r.URL.Path == path || strings.HasPrefix(r.URL.Path, strings.TrimSuffix(path, "/")+"/") {
+			return true, ""
+		}
+	}
+	var v string = r.Header.Get(SecFetchSite)
+	if len(v) == 0 {
+		return true, ""
+	}
+	var site, err = ParseSecFetchSite(v)
+	if err != nil {
+		return false, err.Error()
+	}
+	if site == SecFetchSiteSameOrigin || site == SecFetchSiteNone {
+		return true, ""
+	}
+	for _, allowed := range p.AllowedSites {
+		if site == allowed {
+			return true, ""
+		}
+	}
+	// Destinations and modes are added to Fetch Metadata as browsers ship new features, so values that are not
+	// known are treated as unknown rather than as an error.
+	var mode, _ = ParseSecFetchMode(r.Header.Get(SecFetchMode))
+	var dest, _ = ParseSecFetchDest(r.Header.Get(SecFetchDest))
+	if p.AllowNavigation && mode == SecFetchModeNavigate && r.Method == http.MethodGet && dest != SecFetchDestObject && dest != SecFetchDestEmbed {
+		return true, ""
+	}
+	return false, (fmt.Sprintf("%s request with %s %q and %s %q", site, SecFetchMode, r.Header.Get(SecFetchMode), SecFetchDest, r.Header.Get(SecFetchDest)))
+}
+
+// Handler returns a http.Handler that enforces the ResourceIsolationPolicy before calling next. Rejected requests are
+// passed to the Rejected handler, or answered with 403 Forbidden when it is nil. In report-only mode rejected requests
+// are logged and passed to next.
+func (p *ResourceIsolationPolicy) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ok, reason = p.Allow(r)
+		if ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if p.ReportOnly {
+			p.logf("w3g: resource isolation policy would block %s %s: %s", r.Method, r.URL.Path, reason)
+			next.ServeHTTP(w, r)
+			return
+		}
+		p.logf("w3g: resource isolation policy blocked %s %s: %s", r.Method, r.URL.Path, reason)
+		if p.Rejected != nil {
+			p.Rejected.ServeHTTP(w, r)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	})
+}
+
+// logf writes to the Logger of the ResourceIsolationPolicy. Report-only policies without a Logger use the standard logger.
+func (p *ResourceIsolationPolicy) logf(format string, v ...interface{}) {
+	if p.Logger != nil {
+		p.Logger.Printf(format, v...)
+	} else if p.ReportOnly {
+		log.Printf(format, v...)
+	}
+}
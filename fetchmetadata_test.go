@@ -0,0 +1,85 @@
+package w3g_test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestParseFetchMetadata(t *testing.T) {
+	var h http.Header = http.Header{}
+	h.Set(w3g.SecFetchDest, "iframe")
+	h.Set(w3g.SecFetchMode, "navigate")
+	h.Set(w3g.SecFetchSite, "cross-site")
+	h.Set(w3g.SecFetchUser, "?1")
+	var f, err = w3g.ParseFetchMetadata(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Dest != w3g.SecFetchDestIFrame || f.Mode != w3g.SecFetchModeNavigate || f.Site != w3g.SecFetchSiteCrossSite || !f.User.Activated {
+		t.Errorf("unexpected fetch metadata %+v", f)
+	}
+	h.Set(w3g.SecFetchSite, "cross-site, same-site")
+	if _, err = w3g.ParseFetchMetadata(h); err == nil {
+		t.Error("expected an error for a list valued Sec-Fetch-Site")
+	}
+}
+
+func TestResourceIsolationPolicy(t *testing.T) {
+	var buffer bytes.Buffer
+	var policy *w3g.ResourceIsolationPolicy = w3g.NewResourceIsolationPolicy()
+	policy.ExemptPaths = []string{"/public/", "/health"}
+	var handler http.Handler = policy.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	var tests = []struct {
+		method, path, site, mode, dest string
+		status                         int
+	}{
+		{http.MethodGet, "/api", "", "", "", http.StatusOK},
+		{http.MethodGet, "/api", "same-origin", "cors", "empty", http.StatusOK},
+		{http.MethodPost, "/api", "same-site", "cors", "empty", http.StatusOK},
+		{http.MethodGet, "/", "none", "navigate", "document", http.StatusOK},
+		{http.MethodGet, "/", "cross-site", "navigate", "document", http.StatusOK},
+		{http.MethodPost, "/", "cross-site", "navigate", "document", http.StatusForbidden},
+		{http.MethodGet, "/", "cross-site", "navigate", "object", http.StatusForbidden},
+		{http.MethodGet, "/api", "cross-site", "no-cors", "image", http.StatusForbidden},
+		{http.MethodGet, "/api", "bogus", "no-cors", "image", http.StatusForbidden},
+		{http.MethodGet, "/api", "same-origin", "no-cors", "speculationrules", http.StatusOK},
+		{http.MethodGet, "/api", "same-origin", "future-mode", "sharedstorageworklet", http.StatusOK},
+		{http.MethodGet, "/api", "cross-site", "no-cors", "speculationrules", http.StatusForbidden},
+		{http.MethodGet, "/", "cross-site", "navigate", "future-dest", http.StatusOK},
+		{http.MethodGet, "/public/logo.png", "cross-site", "no-cors", "image", http.StatusOK},
+		{http.MethodGet, "/health", "cross-site", "no-cors", "image", http.StatusOK},
+		{http.MethodGet, "/health/live", "cross-site", "no-cors", "image", http.StatusOK},
+		{http.MethodGet, "/healthz", "cross-site", "no-cors", "image", http.StatusForbidden},
+		{http.MethodGet, "/public-internal", "cross-site", "no-cors", "image", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		var r *http.Request = httptest.NewRequest(test.method, test.path, nil)
+		for header, value := range map[string]string{w3g.SecFetchSite: test.site, w3g.SecFetchMode: test.mode, w3g.SecFetchDest: test.dest} {
+			if len(value) != 0 {
+				r.Header.Set(header, value)
+			}
+		}
+		var w *httptest.ResponseRecorder = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != test.status {
+			t.Errorf("%s %s (%s, %s, %s): got status %d, want %d", test.method, test.path, test.site, test.mode, test.dest, w.Code, test.status)
+		}
+	}
+	policy.ReportOnly = true
+	policy.Logger = log.New(&buffer, "", 0)
+	var r *http.Request = httptest.NewRequest(http.MethodGet, "/api", nil)
+	r.Header.Set(w3g.SecFetchSite, "cross-site")
+	r.Header.Set(w3g.SecFetchMode, "no-cors")
+	r.Header.Set(w3g.SecFetchDest, "script")
+	var w *httptest.ResponseRecorder = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(buffer.String(), "would block") {
+		t.Errorf("report-only: got status %d and log %q", w.Code, buffer.String())
+	}
+}
@@ -0,0 +1,282 @@
+package w3g
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ForwardedChain is a struct to hold the elements of the Forwarded HTTP headers of a request, one for each proxy,
+// from the one closest to the client to the one closest to the server.
+type ForwardedChain struct {
+	Elements []ForwardedHeader `json:"elements"`
+}
+
+// ForwardedNormalizer is a struct to configure a middleware that normalizes the Forwarded and X-Forwarded-For,
+// X-Forwarded-Host and X-Forwarded-Proto HTTP headers of requests into one representation before handlers run: the
+// Forwarded HTTP header, or the X-Forwarded-* HTTP headers when XForwarded is set. The other representation is
+// removed, and Forwarded is used when a request has both. Requests with an invalid header receive a 400 Bad Request
+// response. The headers are only meaningful when all the proxies in front of the server are trusted to set them.
+type ForwardedNormalizer struct {
+	XForwarded bool `json:"x_forwarded"`
+}
+
+// ParseForwarded parses a Forwarded HTTP header value, a comma-separated list of elements. Unknown parameters are
+// ignored, and the proto parameter must be a URI scheme.
+func ParseForwarded(s string) (ForwardedChain, error) {
+	var c ForwardedChain
+	for _, element := range splitQuoted(s, ',') {
+		if len(element) == 0 {
+			continue
+		}
+		var f ForwardedHeader
+		var seen map[string]bool = make(map[string]bool)
+		for _, pair := range splitQuoted(element, ';') {
+			if len(pair) == 0 {
+				continue
+			}
+			var name, value, _ = strings.Cut(pair, "=")
+			name = strings.ToLower(name)
+			var v, ok = unquoteString(value)
+			if !isToken(name) || !ok || seen[name] {
+				return ForwardedChain{}, &HeaderValueError{Header: Forwarded, Value: s}
+			}
+			seen[name] = true
+			switch name {
+			case "by":
+				f.By = v
+			case "for":
+				if f.Identifier, f.Port, f.For, ok = parseForwardedNode(v); !ok {
+					return ForwardedChain{}, &HeaderValueError{Header: Forwarded, Value: s}
+				}
+			case "host":
+				f.Host = v
+			case "proto":
+				if f.Proto = strings.ToLower(v); !isURIScheme(f.Proto) {
+					return ForwardedChain{}, &HeaderValueError{Header: Forwarded, Value: s}
+				}
+			}
+		}
+		(c.Elements) = (append(c.Elements, f))
+	}
+	return c, nil
+}
+
+// ParseForwardedChain parses the Forwarded HTTP headers of a request, in order.
+func ParseForwardedChain(h http.Header) (ForwardedChain, error) {
+	return ParseForwarded(strings.Join(h.Values(Forwarded), ", "))
+}
+
+// ParseXForwarded returns the ForwardedChain of the X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto HTTP
+// headers of a request. Each address of X-Forwarded-For is an element, and X-Forwarded-Host and X-Forwarded-Proto,
+// which were set by the proxy closest to the client, are the Host and Proto of the first element. X-Forwarded-Proto
+// must be a URI scheme.
+func ParseXForwarded(h http.Header) (ForwardedChain, error) {
+	var c ForwardedChain
+	for _, value := range h.Values(XForwardedFor) {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); len(item) == 0 {
+				continue
+			}
+			var f, ok = parseXForwardedFor(item)
+			if !ok {
+				return ForwardedChain{}, &HeaderValueError{Header: XForwardedFor, Value: value}
+			}
+			(c.Elements) = (append(c.Elements, f))
+		}
+	}
+	var host, proto string = firstListItem(h.Get(XForwardedHost)), strings.ToLower(firstListItem(h.Get(XForwardedProto)))
+	if len(proto) != 0 && !isURIScheme(proto) {
+		return ForwardedChain{}, &HeaderValueError{Header: XForwardedProto, Value: h.Get(XForwardedProto)}
+	}
+	if len(host) == 0 && len(proto) == 0 {
+		return c, nil
+	}
+	if len(c.Elements) == 0 {
+		(c.Elements) = (append(c.Elements, ForwardedHeader{}))
+	}
+	c.Elements[0].Host = host
+	c.Elements[0].Proto = proto
+	return c, nil
+}
+
+// SetXForwarded sets the X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto HTTP headers of a request to a
+// ForwardedChain, removing those that it has no value for. IP addresses with a numeric port are written as ip:port,
+// with brackets around IPv6 addresses, and the elements without a node are written as "unknown" so that the
+// addresses keep their position in the chain.
+func (c ForwardedChain) SetXForwarded(h http.Header) {
+	var addresses []string
+	var known bool
+	var host, proto string
+	for _, f := range c.Elements {
+		var address string = "unknown"
+		if f.Identifier != nil && f.Identifier.To16() != nil {
+			address = f.Identifier.String()
+			if len(f.Port) != 0 && strings.Trim(f.Port, "0123456789") == "" {
+				address = net.JoinHostPort(address, f.Port)
+			}
+		} else if len(f.For) != 0 {
+			address = f.For
+		}
+		known = known || address != "unknown"
+		(addresses) = (append(addresses, address))
+		if len(host) == 0 {
+			host = f.Host
+		}
+		if len(proto) == 0 {
+			proto = f.Proto
+		}
+	}
+	if !known {
+		addresses = nil
+	}
+	for name, value := range map[string]string{XForwardedFor: strings.Join(addresses, ", "), XForwardedHost: host, XForwardedProto: proto} {
+		if len(value) == 0 {
+			h.Del(name)
+		} else {
+			h.Set(name, value)
+		}
+	}
+}
+
+// String returns a string representation of a Forwarded HTTP header.
+func (c ForwardedChain) String() string {
+	var substrings ([]string) = (make([]string, 0, len(c.Elements)))
+	for _, f := range c.Elements {
+		(substrings) = (append(substrings, f.String()))
+	}
+	return strings.Join(substrings, ", ")
+}
+
+// Handler returns a http.Handler that normalizes the Forwarded and X-Forwarded-* HTTP headers of requests.
+func (n ForwardedNormalizer) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var c ForwardedChain
+		var err error
+		if len(r.Header.Values(Forwarded)) != 0 {
+			c, err = ParseForwardedChain(r.Header)
+		} else {
+			c, err = ParseXForwarded(r.Header)
+		}
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		r = r.Clone(r.Context())
+		if n.XForwarded {
+			r.Header.Del(Forwarded)
+			c.SetXForwarded(r.Header)
+		} else {
+			r.Header.Del(XForwardedFor)
+			r.Header.Del(XForwardedHost)
+			r.Header.Del(XForwardedProto)
+			if len(c.Elements) != 0 {
+				r.Header.Set(Forwarded, c.String())
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// node returns the node of the for parameter of a Forwarded HTTP header.
+func (f ForwardedHeader) node() string {
+	if f.Identifier == nil || f.Identifier.To16() == nil {
+		return f.For
+	}
+	var node string = f.Identifier.String()
+	if f.Identifier.To4() == nil {
+		node = "[" + node + "]"
+	}
+	if len(f.Port) != 0 {
+		node += ":" + f.Port
+	}
+	return node
+}
+
+// firstListItem returns the first item of a comma-separated list, trimmed of whitespace.
+func firstListItem(s string) string {
+	var item, _, _ = strings.Cut(s, ",")
+	return strings.TrimSpace(item)
+}
+
+// isURIScheme reports whether a string is a URI scheme as defined by RFC 3986.
+func isURIScheme(s string) bool {
+	if len(s) == 0 || s[0]|0x20 < 'a' || s[0]|0x20 > 'z' {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if c := s[i]; (c|0x20 < 'a' || c|0x20 > 'z') && (c < '0' || c > '9') && c != '+' && c != '-' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// isObfuscatedNode reports whether a string is an obfuscated node name or port as defined by RFC 7239.
+func isObfuscatedNode(s string) bool {
+	if len(s) < 2 || s[0] != '_' {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if c := s[i]; (c|0x20 < 'a' || c|0x20 > 'z') && (c < '0' || c > '9') && c != '.' && c != '_' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseForwardedNode parses a node as defined by RFC 7239 into an IP address and port, or into a string when it is
+// "unknown" or obfuscated. The returned bool is false when it is not a node.
+func parseForwardedNode(s string) (net.IP, string, string, bool) {
+	var name, port string = s, ""
+	if strings.HasPrefix(s, "[") {
+		var end int = strings.IndexByte(s, ']')
+		if end == -1 || (end+1 < len(s) && s[end+1] != ':') {
+			return nil, "", "", false
+		}
+		name = s[1:end]
+		if end+1 < len(s) {
+			port = s[end+2:]
+		}
+	} else if i := strings.IndexByte(s, ':'); i != -1 {
+		name, port = s[:i], s[i+1:]
+	}
+	if len(port) != 0 && strings.Trim(port, "0123456789") != "" && !isObfuscatedNode(port) {
+		return nil, "", "", false
+	}
+	if strings.HasPrefix(s, ":") || strings.HasSuffix(s, ":") {
+		return nil, "", "", false
+	}
+	if ip := net.ParseIP(name); ip != nil {
+		if strings.Contains(name, ":") != strings.HasPrefix(s, "[") {
+			return nil, "", "", false
+		}
+		return ip, port, "", true
+	}
+	if strings.EqualFold(name, "unknown") || isObfuscatedNode(name) {
+		return nil, "", s, true
+	}
+	return nil, "", "", false
+}
+
+// parseXForwardedFor parses an address of a X-Forwarded-For HTTP header, an IP address with an optional port, with
+// or without brackets around IPv6 addresses, or a token such as "unknown".
+func parseXForwardedFor(s string) (ForwardedHeader, bool) {
+	if ip := net.ParseIP(s); ip != nil {
+		return ForwardedHeader{Identifier: ip}, true
+	}
+	if host, port, err := net.SplitHostPort(s); err == nil {
+		if ip := net.ParseIP(host); ip != nil && strings.Trim(port, "0123456789") == "" {
+			return ForwardedHeader{Identifier: ip, Port: port}, true
+		}
+	}
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		if ip := net.ParseIP(s[1 : len(s)-1]); ip != nil {
+			return ForwardedHeader{Identifier: ip}, true
+		}
+	}
+	if isToken(s) {
+		return ForwardedHeader{For: s}, true
+	}
+	return ForwardedHeader{}, false
+}
@@ -0,0 +1,129 @@
+package w3g_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestForwardedHeader(t *testing.T) {
+	for _, test := range []struct {
+		f    w3g.ForwardedHeader
+		want string
+	}{
+		{w3g.ForwardedHeader{Identifier: net.ParseIP("192.0.2.60"), Proto: "http", By: "203.0.113.43"}, "by=203.0.113.43;for=192.0.2.60;proto=http"},
+		{w3g.ForwardedHeader{Identifier: net.ParseIP("2001:db8:cafe::17"), Port: "4711"}, `for="[2001:db8:cafe::17]:4711"`},
+		{w3g.ForwardedHeader{For: "_hidden", Host: "example.com:8080"}, `for=_hidden;host="example.com:8080"`},
+		{w3g.ForwardedHeader{Identifier: net.ParseIP("10.0.0.1"), Proto: "https;by=x"}, `for=10.0.0.1;proto="https;by=x"`},
+	} {
+		if got := test.f.String(); got != test.want {
+			t.Errorf("String() = %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestParseForwarded(t *testing.T) {
+	var c, err = w3g.ParseForwarded(`for="192.0.2.43:8080";proto=HTTPS;host="example.com", For="[2001:db8:cafe::17]";by=_proxy, for=unknown`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Elements) != 3 {
+		t.Fatalf("ParseForwarded() = %+v", c.Elements)
+	}
+	if e := c.Elements[0]; !e.Identifier.Equal(net.ParseIP("192.0.2.43")) || e.Port != "8080" || e.Proto != "https" || e.Host != "example.com" {
+		t.Errorf("element 0 = %+v", e)
+	}
+	if e := c.Elements[1]; !e.Identifier.Equal(net.ParseIP("2001:db8:cafe::17")) || e.By != "_proxy" {
+		t.Errorf("element 1 = %+v", e)
+	}
+	if e := c.Elements[2]; e.For != "unknown" || e.Identifier != nil {
+		t.Errorf("element 2 = %+v", e)
+	}
+	if got := c.String(); got != `for="192.0.2.43:8080";host=example.com;proto=https, by=_proxy;for="[2001:db8:cafe::17]", for=unknown` {
+		t.Errorf("String() = %q", got)
+	}
+	for _, invalid := range []string{`proto="https;by=x"`, `proto=1http`, `for=2001:db8::1`, `for="[192.0.2.1]"`, `for=a;for=b`, `for=example.com`, `for="192.0.2.1:http"`, `host`} {
+		if _, err := w3g.ParseForwarded(invalid); err == nil {
+			t.Errorf("ParseForwarded(%q) did not return an error", invalid)
+		}
+	}
+}
+
+func TestXForwarded(t *testing.T) {
+	var h http.Header = http.Header{}
+	h.Add(w3g.XForwardedFor, "203.0.113.195, [2001:db8::1]:443")
+	h.Add(w3g.XForwardedFor, "198.51.100.1:8080, unknown")
+	h.Set(w3g.XForwardedHost, "example.com")
+	h.Set(w3g.XForwardedProto, "HTTPS")
+	var c, err = w3g.ParseXForwarded(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String(); got != `for=203.0.113.195;host=example.com;proto=https, for="[2001:db8::1]:443", for="198.51.100.1:8080", for=unknown` {
+		t.Errorf("String() = %q", got)
+	}
+	var out http.Header = http.Header{}
+	c.SetXForwarded(out)
+	if got := out.Get(w3g.XForwardedFor); got != "203.0.113.195, [2001:db8::1]:443, 198.51.100.1:8080, unknown" {
+		t.Errorf("X-Forwarded-For = %q", got)
+	}
+	if out.Get(w3g.XForwardedHost) != "example.com" || out.Get(w3g.XForwardedProto) != "https" {
+		t.Errorf("SetXForwarded() = %v", out)
+	}
+	out = http.Header{}
+	w3g.ForwardedChain{Elements: []w3g.ForwardedHeader{{By: "_proxy"}, {Identifier: net.ParseIP("192.0.2.1")}}}.SetXForwarded(out)
+	if got := out.Get(w3g.XForwardedFor); got != "unknown, 192.0.2.1" {
+		t.Errorf("X-Forwarded-For = %q, want an unknown element for the element without a node", got)
+	}
+	h.Set(w3g.XForwardedFor, "not an address")
+	if _, err := w3g.ParseXForwarded(h); err == nil {
+		t.Error("ParseXForwarded() did not return an error")
+	}
+	h.Set(w3g.XForwardedFor, "192.0.2.1")
+	h.Set(w3g.XForwardedProto, "https;for=6.6.6.6")
+	if _, err := w3g.ParseXForwarded(h); err == nil {
+		t.Error("ParseXForwarded() with an invalid X-Forwarded-Proto did not return an error")
+	}
+}
+
+func TestForwardedNormalizer(t *testing.T) {
+	var echo http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, name := range []string{w3g.Forwarded, w3g.XForwardedFor, w3g.XForwardedHost, w3g.XForwardedProto} {
+			w.Header()[name] = r.Header[name]
+		}
+	})
+	var r *http.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(w3g.XForwardedFor, "2001:db8::1")
+	r.Header.Set(w3g.XForwardedProto, "https")
+	var w *httptest.ResponseRecorder = httptest.NewRecorder()
+	w3g.ForwardedNormalizer{}.Handler(echo).ServeHTTP(w, r)
+	if got := w.Header().Get(w3g.Forwarded); got != `for="[2001:db8::1]";proto=https` || len(w.Header().Values(w3g.XForwardedFor)) != 0 {
+		t.Errorf("Forwarded = %q, headers = %v", got, w.Header())
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(w3g.Forwarded, `for=192.0.2.43;host=example.com, for=198.51.100.17`)
+	r.Header.Set(w3g.XForwardedFor, "10.0.0.1")
+	w = httptest.NewRecorder()
+	w3g.ForwardedNormalizer{XForwarded: true}.Handler(echo).ServeHTTP(w, r)
+	if w.Header().Get(w3g.XForwardedFor) != "192.0.2.43, 198.51.100.17" || w.Header().Get(w3g.XForwardedHost) != "example.com" || len(w.Header().Values(w3g.Forwarded)) != 0 {
+		t.Errorf("headers = %v", w.Header())
+	}
+
+	for _, header := range []http.Header{
+		{w3g.Forwarded: {`for=example.com`}},
+		{w3g.Forwarded: {`for=10.0.0.1;proto="https;by=x"`}},
+		{w3g.XForwardedFor: {"10.0.0.1"}, w3g.XForwardedProto: {"https;for=6.6.6.6"}},
+	} {
+		r = httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header = header
+		w = httptest.NewRecorder()
+		w3g.ForwardedNormalizer{}.Handler(echo).ServeHTTP(w, r)
+		if w.Code != http.StatusBadRequest || len(w.Header().Values(w3g.Forwarded)) != 0 {
+			t.Errorf("%v: status = %d, headers = %v, want 400", header, w.Code, w.Header())
+		}
+	}
+}
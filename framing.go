@@ -0,0 +1,439 @@
+package w3g
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrAmbiguousFraming is returned when a message has both a Content-Length and a Transfer-Encoding HTTP header.
+var ErrAmbiguousFraming = errors.New("w3g: both Content-Length and Transfer-Encoding")
+
+// ErrDuplicateHost is returned when a request has more than one Host HTTP header.
+var ErrDuplicateHost = errors.New("w3g: duplicate Host")
+
+// ErrHeaderTooLarge is returned when a header section is larger than the limit of a HeaderValidator.
+var ErrHeaderTooLarge = errors.New("w3g: header section too large")
+
+// ErrInvalidContentLength is returned when a Content-Length HTTP header is not a number, or has differing values.
+var ErrInvalidContentLength = errors.New("w3g: invalid Content-Length")
+
+// ErrInvalidFieldName is returned when a field name is not a token, or a field line has no colon.
+var ErrInvalidFieldName = errors.New("w3g: invalid field name")
+
+// ErrInvalidFieldValue is returned when a field value has a CR, LF or NUL character.
+var ErrInvalidFieldValue = errors.New("w3g: invalid field value")
+
+// ErrInvalidTransferEncoding is returned when a Transfer-Encoding HTTP header has an unknown transfer coding, or does
+// not end with a single chunked transfer coding.
+var ErrInvalidTransferEncoding = errors.New("w3g: invalid Transfer-Encoding")
+
+// ErrObsFold is returned when a header section has a field line folded over multiple lines.
+var ErrObsFold = errors.New("w3g: obsolete line folding")
+
+// The states of a headerValidatorConn: reading a header section, the bytes of a body or chunk, a chunk-size line, the
+// trailer section of a chunked body, or a tunnel after a protocol upgrade or CONNECT request.
+const (
+	framingHeader int = iota
+	framingBody
+	framingChunkSize
+	framingTrailer
+	framingTunnel
+)
+
+// headerValidatorRejection replaces an invalid request on a connection of HeaderValidator.Listener. It is not a valid
+// request line, so net/http answers it with a 400 Bad Request response and closes the connection.
+var headerValidatorRejection = []byte("REJECTED\r\n\r\n")
+
+// transferCodings are the transfer codings of the HTTP Transfer Coding registry that are understood.
+var transferCodings = map[string]bool{
+	"chunked":    true,
+	"compress":   true,
+	"deflate":    true,
+	"gzip":       true,
+	"x-compress": true,
+	"x-gzip":     true}
+
+// HeaderValidator is a struct to configure the validation of request headers against ambiguous message framing, which
+// lets an attacker smuggle a request past a proxy that frames a message differently than the server behind it.
+// Header sections larger than MaxHeaderBytes, http.DefaultMaxHeaderBytes when it is 0, are rejected.
+type HeaderValidator struct {
+	MaxHeaderBytes int `json:"max_header_bytes"`
+}
+
+// headerValidatorConn is a net.Conn that validates the raw header section of each HTTP/1.1 request read from it, and
+// follows the framing of the request bodies to find the next header section.
+type headerValidatorConn struct {
+	net.Conn
+	buf       []byte
+	connect   bool
+	err       error
+	fields    int
+	line      int
+	mu        sync.Mutex
+	pending   []byte
+	r         *bufio.Reader
+	remaining int64
+	state     int
+	then      int
+	upgrade   bool
+	upgraded  bool
+	validator HeaderValidator
+}
+
+// headerValidatorListener is a net.Listener whose connections are headerValidatorConns.
+type headerValidatorListener struct {
+	net.Listener
+	validator HeaderValidator
+}
+
+// ParseTransferEncoding parses a Transfer-Encoding HTTP header value. The Codings of the returned
+// TransferEncodingHeader are lower case and in the order they were applied.
+func ParseTransferEncoding(s string) (TransferEncodingHeader, error) {
+	var t TransferEncodingHeader
+	for _, coding := range strings.Split(s, ",") {
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if len(coding) == 0 {
+			continue
+		}
+		if !isToken(coding) {
+			return TransferEncodingHeader{}, &HeaderValueError{Header: TransferEncoding, Value: s}
+		}
+		switch coding {
+		case "chunked":
+			t.Chunked = true
+		case "compress", "x-compress":
+			t.Compress = true
+		case "deflate":
+			t.Deflate = true
+		case "gzip", "x-gzip":
+			t.GZip = true
+		case "identity":
+			t.Identity = true
+		}
+		t.Codings = append(t.Codings, coding)
+	}
+	return t, nil
+}
+
+// Handler returns a http.Handler that validates the headers of requests before calling next. Requests with ambiguous
+// framing receive a 400 Bad Request response that names the problem, and their connection is closed. Handler only
+// sees what net/http leaves of a request: net/http unfolds obs-fold lines and drops the Content-Length HTTP header
+// of chunked requests before handlers run, so those smuggling attempts must be caught by Listener or ValidateBlock.
+func (v HeaderValidator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := v.ValidateRequest(r); err != nil {
+			w.Header().Set(Connection, "close")
+			http.Error(w, strings.TrimPrefix(err.Error(), "w3g: "), http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Listener returns a net.Listener whose connections validate the raw header section of each HTTP/1.1 request with
+// ValidateBlock before net/http parses it, so that the smuggling attempts that net/http would normalize away are
+// seen. The body of each request is followed to find the next one on the connection. An invalid request is not
+// passed on: it is replaced with a malformed request line, which net/http answers with a 400 Bad Request response in
+// order with the responses to the requests before it, and then the connection is closed. After a CONNECT request
+// or a request with an Upgrade HTTP header, the connection is passed through once the server has switched protocols.
+// l must deliver plaintext HTTP/1.1, such as the connections from a TLS-terminating proxy in front of the server.
+func (v HeaderValidator) Listener(l net.Listener) net.Listener {
+	return &headerValidatorListener{Listener: l, validator: v}
+}
+
+// ValidateBlock validates a raw header section, the field lines of a message up to an optional empty line, separated
+// by CRLF or LF.
+func (v HeaderValidator) ValidateBlock(b []byte) error {
+	var h, err = v.parseBlock(b)
+	if err != nil {
+		return err
+	}
+	return v.ValidateHeader(h)
+}
+
+// ValidateHeader validates the header of a message: its field names and values, its size, and that its Host,
+// Content-Length and Transfer-Encoding HTTP headers frame the message unambiguously.
+func (v HeaderValidator) ValidateHeader(h http.Header) error {
+	var size int
+	for name, values := range h {
+		if !isToken(name) {
+			return fmt.Errorf("%w: %q", ErrInvalidFieldName, name)
+		}
+		for _, value := range values {
+			if strings.ContainsAny(value, "\r\n\x00") {
+				return fmt.Errorf("%w: %s", ErrInvalidFieldValue, name)
+			}
+			size += len(name) + len(value) + len(": \r\n")
+		}
+	}
+	if size > v.maxHeaderBytes() {
+		return ErrHeaderTooLarge
+	}
+	if hosts := h.Values(Host); len(hosts) > 1 || (len(hosts) == 1 && strings.Contains(hosts[0], ",")) {
+		return ErrDuplicateHost
+	}
+	var contentLength string
+	for _, value := range h.Values(ContentLength) {
+		for _, length := range strings.Split(value, ",") {
+			length = strings.TrimSpace(length)
+			if len(length) == 0 || strings.Trim(length, "0123456789") != "" || (len(contentLength) != 0 && length != contentLength) {
+				return ErrInvalidContentLength
+			}
+			contentLength = length
+		}
+	}
+	var values []string = h.Values(TransferEncoding)
+	if len(values) == 0 {
+		return nil
+	}
+	if len(h.Values(ContentLength)) != 0 {
+		return ErrAmbiguousFraming
+	}
+	var t, err = ParseTransferEncoding(strings.Join(values, ","))
+	if err != nil || len(t.Codings) == 0 {
+		return ErrInvalidTransferEncoding
+	}
+	for i, coding := range t.Codings {
+		if !transferCodings[coding] || (coding == "chunked") != (i == len(t.Codings)-1) {
+			return fmt.Errorf("%w: %s", ErrInvalidTransferEncoding, coding)
+		}
+	}
+	return nil
+}
+
+// ValidateRequest validates the headers of a HTTP request with ValidateHeader. The Host and Transfer-Encoding HTTP
+// headers, which net/http moves out of the Header of a request, are validated as well.
+func (v HeaderValidator) ValidateRequest(r *http.Request) error {
+	var h http.Header = r.Header.Clone()
+	if h == nil {
+		h = make(http.Header)
+	}
+	if len(r.TransferEncoding) != 0 && len(h.Values(TransferEncoding)) == 0 {
+		h[TransferEncoding] = []string{strings.Join(r.TransferEncoding, ", ")}
+	}
+	if len(r.Host) != 0 && len(h.Values(Host)) == 0 {
+		h[Host] = []string{r.Host}
+	}
+	return v.ValidateHeader(h)
+}
+
+// parseBlock parses a raw header section into a http.Header without merging or normalizing its field lines.
+func (v HeaderValidator) parseBlock(b []byte) (http.Header, error) {
+	if len(b) > v.maxHeaderBytes() {
+		return nil, ErrHeaderTooLarge
+	}
+	var h http.Header = make(http.Header)
+	for _, line := range bytes.Split(b, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(line) == 0 {
+			break
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return nil, ErrObsFold
+		}
+		var name, value, ok = bytes.Cut(line, []byte(":"))
+		if !ok || !isToken(string(name)) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidFieldName, name)
+		}
+		var key string = textproto.CanonicalMIMEHeaderKey(string(name))
+		h[key] = append(h[key], string(bytes.Trim(value, " \t")))
+	}
+	return h, nil
+}
+
+// maxHeaderBytes returns the MaxHeaderBytes of a HeaderValidator, or http.DefaultMaxHeaderBytes when it is 0.
+func (v HeaderValidator) maxHeaderBytes() int {
+	if v.MaxHeaderBytes <= 0 {
+		return http.DefaultMaxHeaderBytes
+	}
+	return v.MaxHeaderBytes
+}
+
+// Read reads the bytes of validated requests from a connection. An invalid request is replaced with
+// headerValidatorRejection, after which the connection reads io.EOF.
+func (c *headerValidatorConn) Read(b []byte) (int, error) {
+	for len(c.pending) == 0 {
+		if c.err != nil {
+			return 0, c.err
+		}
+		switch c.state {
+		case framingTunnel:
+			return c.r.Read(b)
+		case framingBody:
+			if c.remaining == 0 {
+				c.state = c.then
+				continue
+			}
+			if int64(len(b)) > c.remaining {
+				b = b[:c.remaining]
+			}
+			var n, err = c.r.Read(b)
+			c.remaining -= int64(n)
+			return n, err
+		}
+		if err := c.next(); err != nil {
+			return 0, err
+		}
+	}
+	var n int = copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// Write writes to a connection, and records whether the server switched protocols after a CONNECT request or a
+// request with an Upgrade HTTP header.
+func (c *headerValidatorConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	if c.upgrade && !c.upgraded && bytes.HasPrefix(b, []byte("HTTP/1.")) && len(b) >= len("HTTP/1.1 200") {
+		var code string = string(b[len("HTTP/1.1 "):len("HTTP/1.1 200")])
+		if code == "101" || (c.connect && code[0] == '2') {
+			c.upgraded = true
+		} else if code[0] != '1' {
+			c.upgrade = false
+		}
+	}
+	c.mu.Unlock()
+	return c.Conn.Write(b)
+}
+
+// next reads the next header section, chunk-size line or trailer section of a connection into pending, or rejects the
+// request when it is invalid. Read errors are returned so that an interrupted read can be resumed.
+func (c *headerValidatorConn) next() error {
+	var limit int = c.validator.maxHeaderBytes()
+	if c.state == framingHeader && len(c.buf) == 0 {
+		c.mu.Lock()
+		var upgrade bool = c.upgrade
+		c.mu.Unlock()
+		if upgrade {
+			if _, err := c.r.Peek(1); err != nil {
+				return err
+			}
+			c.mu.Lock()
+			if c.upgraded {
+				c.state = framingTunnel
+			}
+			c.upgrade, c.connect = false, false
+			c.mu.Unlock()
+			if c.state == framingTunnel {
+				return nil
+			}
+		}
+		c.fields = -1
+	}
+	for {
+		var line, err = c.readLine(limit + 4096)
+		if err == ErrHeaderTooLarge {
+			c.reject()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var empty bool = len(bytes.TrimRight(line, "\r\n")) == 0
+		switch {
+		case c.state == framingChunkSize:
+			var size, _, _ = strings.Cut(string(bytes.TrimRight(line, "\r\n")), ";")
+			var n, err = strconv.ParseInt(strings.TrimSpace(size), 16, 64)
+			if err != nil || n < 0 {
+				c.reject()
+				return nil
+			}
+			c.state, c.remaining, c.then = framingBody, n+int64(len("\r\n")), framingChunkSize
+			if n == 0 {
+				c.state = framingTrailer
+			}
+		case c.state == framingTrailer && !empty:
+			continue
+		case c.state == framingTrailer:
+			c.state = framingHeader
+		case c.fields == -1 && empty:
+			continue
+		case c.fields == -1:
+			c.fields = len(c.buf)
+			var method, _, _ = strings.Cut(string(line), " ")
+			c.mu.Lock()
+			c.connect = method == http.MethodConnect
+			c.mu.Unlock()
+			continue
+		case !empty:
+			continue
+		default:
+			if c.frame(c.buf[c.fields:]) != nil {
+				c.reject()
+				return nil
+			}
+		}
+		c.pending, c.buf, c.line = c.buf, nil, 0
+		return nil
+	}
+}
+
+// frame validates the header section of a request and sets the state of a connection to read its body.
+func (c *headerValidatorConn) frame(block []byte) error {
+	var h, err = c.validator.parseBlock(block)
+	if err == nil {
+		err = c.validator.ValidateHeader(h)
+	}
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.upgrade, c.upgraded = c.connect || len(h.Values(Upgrade)) != 0, false
+	c.mu.Unlock()
+	c.state, c.then = framingHeader, framingHeader
+	if len(h.Values(TransferEncoding)) != 0 {
+		c.state = framingChunkSize
+	} else if value := h.Get(ContentLength); len(value) != 0 {
+		var length, _, _ = strings.Cut(value, ",")
+		var n, err = strconv.ParseInt(strings.TrimSpace(length), 10, 64)
+		if err != nil {
+			return ErrInvalidContentLength
+		}
+		c.state, c.remaining = framingBody, n
+	}
+	return nil
+}
+
+// reject replaces the rest of a connection with headerValidatorRejection.
+func (c *headerValidatorConn) reject() {
+	c.pending, c.buf, c.line, c.err = headerValidatorRejection, nil, 0, io.EOF
+}
+
+// readLine reads a line of a connection into buf and returns it once it is complete, so that a line cut short by a
+// read error is completed by the next call. It returns ErrHeaderTooLarge when buf grows larger than limit bytes.
+func (c *headerValidatorConn) readLine(limit int) ([]byte, error) {
+	for {
+		var b, err = c.r.ReadSlice('\n')
+		c.buf = append(c.buf, b...)
+		if len(c.buf) > limit {
+			return nil, ErrHeaderTooLarge
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var line []byte = c.buf[c.line:]
+		c.line = len(c.buf)
+		return line, nil
+	}
+}
+
+// Accept waits for and returns the next connection to a listener, which validates the requests read from it.
+func (l *headerValidatorListener) Accept() (net.Conn, error) {
+	var conn, err = l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &headerValidatorConn{Conn: conn, r: bufio.NewReader(conn), validator: l.validator}, nil
+}
@@ -0,0 +1,141 @@
+package w3g_test
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gellel/w3g"
+)
+
+func TestParseTransferEncoding(t *testing.T) {
+	var te, err = w3g.ParseTransferEncoding("GZip, chunked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !te.GZip || !te.Chunked || te.String() != "gzip, chunked" {
+		t.Errorf("ParseTransferEncoding() = %+v", te)
+	}
+	if got := (w3g.TransferEncodingHeader{Chunked: true, GZip: true}).String(); got != "gzip, chunked" {
+		t.Errorf("String() = %q, want chunked last", got)
+	}
+	if _, err := w3g.ParseTransferEncoding("chunked, a b"); err == nil {
+		t.Error("ParseTransferEncoding() did not return an error")
+	}
+}
+
+func TestHeaderValidatorValidateBlock(t *testing.T) {
+	var v w3g.HeaderValidator = w3g.HeaderValidator{MaxHeaderBytes: 256}
+	for _, test := range []struct {
+		block string
+		err   error
+	}{
+		{"Host: example.com\r\nContent-Length: 5\r\n\r\n", nil},
+		{"Host: example.com\nTransfer-Encoding: gzip\nTransfer-Encoding: chunked\n", nil},
+		{"Host: example.com\r\nContent-Length: 5, 5\r\n\r\n", nil},
+		{"Host: example.com\r\nContent-Length: 5\r\nTransfer-Encoding: chunked\r\n\r\n", w3g.ErrAmbiguousFraming},
+		{"Host: example.com\r\nContent-Length: 5\r\nContent-Length: 6\r\n\r\n", w3g.ErrInvalidContentLength},
+		{"Host: example.com\r\nContent-Length: -1\r\n\r\n", w3g.ErrInvalidContentLength},
+		{"Host: example.com\r\nTransfer-Encoding: chunked, gzip\r\n\r\n", w3g.ErrInvalidTransferEncoding},
+		{"Host: example.com\r\nTransfer-Encoding: chunked, chunked\r\n\r\n", w3g.ErrInvalidTransferEncoding},
+		{"Host: example.com\r\nTransfer-Encoding: identity\r\n\r\n", w3g.ErrInvalidTransferEncoding},
+		{"Host: example.com\r\nTransfer-Encoding: xchunked\r\n\r\n", w3g.ErrInvalidTransferEncoding},
+		{"Host: example.com\r\nHost: evil.example\r\n\r\n", w3g.ErrDuplicateHost},
+		{"Host: example.com\r\nX-Folded: a\r\n b\r\n\r\n", w3g.ErrObsFold},
+		{"Host: example.com\r\nTransfer-Encoding : chunked\r\n\r\n", w3g.ErrInvalidFieldName},
+		{"Host: example.com\r\nno colon\r\n\r\n", w3g.ErrInvalidFieldName},
+		{"Host: example.com\r\nX-Value: a\rb\r\n\r\n", w3g.ErrInvalidFieldValue},
+		{"Host: example.com\r\nX-Value: a\x00b\r\n\r\n", w3g.ErrInvalidFieldValue},
+		{"Host: example.com\r\nX-Large: " + strings.Repeat("a", 256) + "\r\n\r\n", w3g.ErrHeaderTooLarge},
+	} {
+		if err := v.ValidateBlock([]byte(test.block)); !errors.Is(err, test.err) {
+			t.Errorf("ValidateBlock(%q) = %v, want %v", test.block, err, test.err)
+		}
+	}
+}
+
+func TestHeaderValidatorListener(t *testing.T) {
+	var server *httptest.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(w3g.Upgrade) == "echo" {
+			w.Header().Set(w3g.Connection, "upgrade")
+			w.Header().Set(w3g.Upgrade, "echo")
+			w.WriteHeader(http.StatusSwitchingProtocols)
+			var conn, rw, _ = w.(http.Hijacker).Hijack()
+			defer conn.Close()
+			io.Copy(conn, rw)
+			return
+		}
+		var body, _ = io.ReadAll(r.Body)
+		io.WriteString(w, r.URL.Path+" "+string(body))
+	}))
+	server.Listener = w3g.HeaderValidator{}.Listener(server.Listener)
+	server.Start()
+	defer server.Close()
+
+	for _, test := range []struct {
+		name     string
+		requests string
+		want     []string
+	}{
+		{"pipelined", "POST /a HTTP/1.1\r\nHost: example.com\r\nContent-Length: 5\r\n\r\nhello" +
+			"POST /b HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n2;ext=1\r\nhe\r\n3\r\nllo\r\n0\r\nX-Trailer: 1\r\n\r\n" +
+			"GET /c HTTP/1.1\r\nHost: example.com\r\n\r\n", []string{"200 /a hello", "200 /b hello", "200 /c "}},
+		{"CL.TE", "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 4\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\nGET /smuggled HTTP/1.1\r\nHost: example.com\r\n\r\n", []string{"400"}},
+		{"obs-fold", "GET / HTTP/1.1\r\nHost: example.com\r\nX-Folded: a\r\n b\r\n\r\n", []string{"400"}},
+		{"smuggled after a valid request", "POST /a HTTP/1.1\r\nHost: example.com\r\nContent-Length: 2\r\n\r\nhi" +
+			"POST / HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\nContent-Length: 3\r\n\r\n0\r\n\r\n", []string{"200 /a hi", "400"}},
+	} {
+		var conn, err = net.Dial("tcp", server.Listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		io.WriteString(conn, test.requests)
+		var br *bufio.Reader = bufio.NewReader(conn)
+		for _, want := range test.want {
+			var res, err = http.ReadResponse(br, nil)
+			if err != nil {
+				t.Errorf("%s: ReadResponse() = %v, want %q", test.name, err, want)
+				break
+			}
+			var body, _ = io.ReadAll(res.Body)
+			res.Body.Close()
+			var got string = strconv.Itoa(res.StatusCode)
+			if res.StatusCode == http.StatusOK {
+				got += " " + string(body)
+			}
+			if got != want {
+				t.Errorf("%s: response = %q, want %q", test.name, got, want)
+			}
+		}
+		if test.want[len(test.want)-1] != "400" {
+			conn.Close()
+			continue
+		}
+		if _, err := http.ReadResponse(br, nil); err != io.ErrUnexpectedEOF && err != io.EOF {
+			t.Errorf("%s: ReadResponse() after a 400 response = %v, want the connection closed", test.name, err)
+		}
+		conn.Close()
+	}
+
+	var conn, _ = net.Dial("tcp", server.Listener.Addr().String())
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+	var br *bufio.Reader = bufio.NewReader(conn)
+	if res, err := http.ReadResponse(br, nil); err != nil || res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("upgrade = %v", err)
+	}
+	io.WriteString(conn, "ping\r\n \r\n")
+	var echo []byte = make([]byte, 9)
+	if _, err := io.ReadFull(br, echo); err != nil || string(echo) != "ping\r\n \r\n" {
+		t.Errorf("echo = %q, %v", echo, err)
+	}
+}
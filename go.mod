@@ -0,0 +1,3 @@
+module github.com/gellel/w3g
+
+go 1.23
@@ -0,0 +1,211 @@
+package w3g
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LanguageNegotiation is a struct to hold the outcome of choosing the language of a response from an Accept-Language
+// HTTP header: the chosen language tag and the Content-Language and Vary HTTP headers to send with it.
+type LanguageNegotiation struct {
+	ContentLanguage ContentLanguageHeader `json:"content_language"`
+	Tag             string                `json:"tag"`
+	Vary            VaryHeader            `json:"vary"`
+}
+
+// CanonicalLanguageTag returns a BCP 47 language tag in its canonical case: a lower case language, a title case
+// script, an upper case region and lower case variants and extensions. Underscores are replaced with hyphens.
+func CanonicalLanguageTag(tag string) string {
+	var subtags []string = strings.Split(strings.ToLower(strings.ReplaceAll(tag, "_", "-")), "-")
+	for i := 1; i < len(subtags); i++ {
+		var subtag string = subtags[i]
+		if len(subtags[i-1]) == 1 {
+			break
+		}
+		switch {
+		case len(subtag) == 4 && isAlphaString(subtag):
+			subtags[i] = strings.ToUpper(subtag[:1]) + subtag[1:]
+		case len(subtag) == 2 && isAlphaString(subtag):
+			subtags[i] = strings.ToUpper(subtag)
+		}
+	}
+	return (strings.Join(subtags, "-"))
+}
+
+// FilterLanguages returns the language tags that match the language ranges of an Accept-Language HTTP header using
+// the basic filtering of RFC 4647, in the order of preference of the ranges. A range matches a tag when it is equal
+// to the tag or a prefix of it ending at a hyphen, and the range "*" matches every tag. Ranges with a quality value of
+// 0 exclude the tags they match unless a more specific range accepts them, so that "fr;q=0, *" matches every tag but
+// fr and "en, *;q=0" only matches en.
+func FilterLanguages(ranges []AcceptLanguageHeader, tags []string) []string {
+	var matched []string
+	var seen map[string]bool = map[string]bool{}
+	for _, r := range ranges {
+		if r.Q == 0 {
+			continue
+		}
+		for _, tag := range tags {
+			var t string = strings.ToLower(tag)
+			if seen[t] {
+				continue
+			}
+			if matchLanguageRange(r.Language, t) && !excludedLanguage(ranges, t) {
+				seen[t] = true
+				matched = append(matched, tag)
+			}
+		}
+	}
+	return matched
+}
+
+// LookupLanguage returns the supported language tag that best matches the language ranges of an Accept-Language
+// HTTP header using the lookup of RFC 4647: each range is truncated one subtag at a time, so that zh-Hant-TW is
+// tried as zh-Hant and then zh, until a supported tag is found. Tags excluded by a range with a quality value of 0 are
+// never returned, as for FilterLanguages. The fallback is returned when no range matches.
+func LookupLanguage(ranges []AcceptLanguageHeader, supported []string, fallback string) string {
+	for _, r := range ranges {
+		if r.Language == "*" || r.Q == 0 {
+			continue
+		}
+		var subtags []string = strings.Split(strings.ToLower(r.Language), "-")
+		for len(subtags) != 0 {
+			var candidate string = strings.Join(subtags, "-")
+			for _, tag := range supported {
+				if strings.ToLower(tag) == candidate && !excludedLanguage(ranges, candidate) {
+					return tag
+				}
+			}
+			subtags = subtags[:len(subtags)-1]
+			if len(subtags) != 0 && len(subtags[len(subtags)-1]) == 1 {
+				subtags = subtags[:len(subtags)-1]
+			}
+		}
+	}
+	return fallback
+}
+
+// NegotiateLanguage chooses the language of a response from the Accept-Language HTTP headers of a request with
+// LookupLanguage. Requests without a valid Accept-Language HTTP header receive the fallback.
+func NegotiateLanguage(h http.Header, supported []string, fallback string) LanguageNegotiation {
+	var ranges, _ = ParseAcceptLanguage(strings.Join(h.Values(AcceptLanguage), ", "))
+	var tag string = LookupLanguage(ranges, supported, fallback)
+	var l LanguageNegotiation = LanguageNegotiation{Tag: tag, Vary: VaryHeader{Headers: []string{AcceptLanguage}}}
+	if len(tag) != 0 {
+		l.ContentLanguage = ContentLanguageHeader{LanguageTags: []string{tag}}
+	}
+	return l
+}
+
+// ParseAcceptLanguage parses an Accept-Language HTTP header value into its language ranges, ordered from the most to
+// the least preferred. Ranges with equal quality values keep their order, and ranges with a quality value of 0, which
+// mark the languages they match as not acceptable, come last. A range without a quality value has a Q of 1.
+func ParseAcceptLanguage(s string) ([]AcceptLanguageHeader, error) {
+	var ranges []AcceptLanguageHeader
+	for _, member := range strings.Split(s, ",") {
+		var params []string = strings.Split(member, ";")
+		var r AcceptLanguageHeader = AcceptLanguageHeader{Language: strings.TrimSpace(params[0]), Q: 1}
+		if len(r.Language) == 0 && len(params) == 1 {
+			continue
+		}
+		if !validLanguageRange(r.Language) {
+			return nil, &HeaderValueError{Header: AcceptLanguage, Value: s}
+		}
+		for _, param := range params[1:] {
+			var name, value, _ = strings.Cut(strings.TrimSpace(param), "=")
+			if strings.ToLower(strings.TrimSpace(name)) != "q" {
+				continue
+			}
+			var q, err = strconv.ParseFloat(strings.TrimSpace(value), 32)
+			if err != nil || q < 0 || q > 1 {
+				return nil, &HeaderValueError{Header: AcceptLanguage, Value: s}
+			}
+			r.Q = float32(q)
+		}
+		ranges = append(ranges, r)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].Q > ranges[j].Q
+	})
+	return ranges, nil
+}
+
+// Apply sets the Content-Language HTTP header of a response and adds Accept-Language to its Vary HTTP header.
+func (l LanguageNegotiation) Apply(w http.ResponseWriter) {
+	var h http.Header = w.Header()
+	if len(l.ContentLanguage.LanguageTags) != 0 {
+		h.Set(ContentLanguage, l.ContentLanguage.String())
+	}
+	for _, header := range l.Vary.Headers {
+		addVary(h, header)
+	}
+}
+
+// addVary adds a header name to the Vary HTTP header when it is not listed already.
+func addVary(h http.Header, name string) {
+	for _, v := range h.Values(Vary) {
+		for _, listed := range strings.Split(v, ",") {
+			if listed = strings.TrimSpace(listed); listed == "*" || strings.EqualFold(listed, name) {
+				return
+			}
+		}
+	}
+	h.Add(Vary, name)
+}
+
+// excludedLanguage reports whether the most specific language range that matches a lowercase language tag has a
+// quality value of 0.
+func excludedLanguage(ranges []AcceptLanguageHeader, tag string) bool {
+	var excluded bool
+	var specificity int = -1
+	for _, r := range ranges {
+		if !matchLanguageRange(r.Language, tag) {
+			continue
+		}
+		var n int = len(r.Language)
+		if r.Language == "*" {
+			n = 0
+		}
+		if n > specificity {
+			excluded, specificity = r.Q == 0, n
+		}
+	}
+	return excluded
+}
+
+// isAlphaString reports whether a string only contains ASCII letters.
+func isAlphaString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i] | 0x20; c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// matchLanguageRange reports whether a language range matches a lowercase language tag using the basic filtering of
+// RFC 4647.
+func matchLanguageRange(languageRange string, tag string) bool {
+	var prefix string = strings.ToLower(languageRange)
+	return (prefix == "*" || tag == prefix || strings.HasPrefix(tag, prefix+"-"))
+}
+
+// validLanguageRange reports whether a string is a language-range as defined by RFC 4647.
+func validLanguageRange(s string) bool {
+	if s == "*" {
+		return true
+	}
+	for i, subtag := range strings.Split(s, "-") {
+		if len(subtag) == 0 || len(subtag) > 8 {
+			return false
+		}
+		for j := 0; j < len(subtag); j++ {
+			var c byte = subtag[j] | 0x20
+			if (c < 'a' || c > 'z') && (i == 0 || subtag[j] < '0' || subtag[j] > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package w3g_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	var ranges, err = w3g.ParseAcceptLanguage("da, en-gb;q=0.8, fr;q=0, en;q=0.7, *;q=0.1, de-CH;q=0.8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []w3g.AcceptLanguageHeader = []w3g.AcceptLanguageHeader{
+		{Language: "da", Q: 1},
+		{Language: "en-gb", Q: 0.8},
+		{Language: "de-CH", Q: 0.8},
+		{Language: "en", Q: 0.7},
+		{Language: "*", Q: 0.1},
+		{Language: "fr", Q: 0},
+	}
+	if !reflect.DeepEqual(ranges, want) {
+		t.Errorf("ParseAcceptLanguage() = %v, want %v", ranges, want)
+	}
+	if got := ranges[1].String(); got != "en-gb;q=0.8" {
+		t.Errorf("AcceptLanguageHeader.String() = %q", got)
+	}
+	for _, invalid := range []string{"en;q=2", "en_US", "toolonglanguage", "1en"} {
+		if _, err = w3g.ParseAcceptLanguage(invalid); err == nil {
+			t.Errorf("ParseAcceptLanguage(%q) succeeded, want error", invalid)
+		}
+	}
+}
+
+func TestCanonicalLanguageTag(t *testing.T) {
+	for tag, want := range map[string]string{
+		"EN-us":              "en-US",
+		"zh_hant_tw":         "zh-Hant-TW",
+		"sr-latn-rs":         "sr-Latn-RS",
+		"de-ch-1996":         "de-CH-1996",
+		"en-US-x-TWAIN-abcd": "en-US-x-twain-abcd",
+		"es-419":             "es-419",
+	} {
+		if got := w3g.CanonicalLanguageTag(tag); got != want {
+			t.Errorf("CanonicalLanguageTag(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestLanguageMatching(t *testing.T) {
+	var supported []string = []string{"en", "en-GB", "zh", "zh-Hant", "de-CH-1996", "fr"}
+	var tests = []struct {
+		header string
+		lookup string
+		filter []string
+	}{
+		{"zh-Hant-TW", "zh-Hant", nil},
+		{"zh-Hans-CN, fr;q=0.5", "zh", []string{"fr"}},
+		{"de-CH-x-phonebk", "en", nil},
+		{"de-CH-1996-x-phonebk", "de-CH-1996", nil},
+		{"en", "en", []string{"en", "en-GB"}},
+		{"*", "en", supported},
+		{"", "en", nil},
+		{"fr;q=0, *", "en", []string{"en", "en-GB", "zh", "zh-Hant", "de-CH-1996"}},
+		{"en, *;q=0", "en", []string{"en", "en-GB"}},
+		{"en-GB, en;q=0", "en-GB", []string{"en-GB"}},
+		{"zh-Hant-TW, zh-Hant;q=0", "zh", nil},
+	}
+	for _, test := range tests {
+		var ranges, _ = w3g.ParseAcceptLanguage(test.header)
+		if got := w3g.LookupLanguage(ranges, supported, "en"); got != test.lookup {
+			t.Errorf("LookupLanguage(%q) = %q, want %q", test.header, got, test.lookup)
+		}
+		if got := w3g.FilterLanguages(ranges, supported); !reflect.DeepEqual(got, test.filter) {
+			t.Errorf("FilterLanguages(%q) = %q, want %q", test.header, got, test.filter)
+		}
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	var r *http.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(w3g.AcceptLanguage, "pt-BR, en;q=0.5")
+	var l w3g.LanguageNegotiation = w3g.NegotiateLanguage(r.Header, []string{"en", "pt"}, "en")
+	if l.Tag != "pt" {
+		t.Errorf("NegotiateLanguage() chose %q, want %q", l.Tag, "pt")
+	}
+	var w *httptest.ResponseRecorder = httptest.NewRecorder()
+	w.Header().Set(w3g.Vary, "Accept-Encoding")
+	l.Apply(w)
+	l.Apply(w)
+	if got := w.Header().Get(w3g.ContentLanguage); got != "pt" {
+		t.Errorf("Content-Language = %q, want %q", got, "pt")
+	}
+	if got := w.Header().Values(w3g.Vary); !reflect.DeepEqual(got, []string{"Accept-Encoding", "Accept-Language"}) {
+		t.Errorf("Vary = %q", got)
+	}
+}
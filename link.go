@@ -0,0 +1,308 @@
+package w3g
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ErrPageLoop is returned by a PageIterator when a paginated API links back to a page that was already fetched.
+var ErrPageLoop = errors.New("w3g: pagination links back to a fetched page")
+
+// pageCredentialHeaders are the HTTP headers of the first request of a PageIterator that are not sent to pages of
+// another origin, as http.Client does not send them when it follows a redirect to another host.
+var pageCredentialHeaders = []string{Authorization, Cookie, ProxyAuthorization, WWWAuthenticate}
+
+// LinkValue is a struct to hold a single link of a Link HTTP header as defined by RFC 8288. Title holds the decoded
+// value of the title* parameter when a link has one. Params holds the extension parameters of a link.
+type LinkValue struct {
+	Anchor   string            `json:"anchor"`
+	HrefLang []string          `json:"hreflang"`
+	Params   map[string]string `json:"params"`
+	Rel      []string          `json:"rel"`
+	Target   url.URL           `json:"target"`
+	Title    string            `json:"title"`
+	Type     string            `json:"type"`
+}
+
+// Pagination is a struct to hold the links between the pages of a paginated API, resolved against the URL of the
+// page that returned them. A link that is not present is nil.
+type Pagination struct {
+	First *url.URL `json:"first"`
+	Last  *url.URL `json:"last"`
+	Next  *url.URL `json:"next"`
+	Prev  *url.URL `json:"prev"`
+}
+
+// PageIterator is a struct to walk a paginated API by following the next links of its responses.
+//
+//	var it *w3g.PageIterator = w3g.NewPageIterator(http.DefaultClient, req)
+//	for it.Next() {
+//		var res *http.Response = it.Response()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type PageIterator struct {
+	client  *http.Client
+	err     error
+	next    *url.URL
+	request *http.Request
+	res     *http.Response
+	visited map[string]bool
+}
+
+// NewPageIterator returns a PageIterator whose first page is requested by r. Every following page is requested with
+// the method and headers of r, except for the Authorization, Cookie, Proxy-Authorization and WWW-Authenticate HTTP
+// headers, which are only sent to pages of the same origin as r. A nil client uses http.DefaultClient.
+func NewPageIterator(client *http.Client, r *http.Request) *PageIterator {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &PageIterator{client: client, next: r.URL, request: r, visited: map[string]bool{}}
+}
+
+// ParseLink parses a Link HTTP header value. Commas and semicolons inside quoted parameter values and inside the
+// target URI are not treated as separators.
+func ParseLink(s string) (LinkHeader, error) {
+	var h LinkHeader
+	for _, link := range splitQuoted(s, ',') {
+		if len(link) == 0 {
+			continue
+		}
+		var l, err = parseLinkValue(link)
+		if err != nil {
+			return LinkHeader{}, &HeaderValueError{Header: Link, Value: s}
+		}
+		h.Links = append(h.Links, l)
+	}
+	return h, nil
+}
+
+// ParsePagination returns the first, last, next and previous links of the Link HTTP headers of a response, resolved
+// against base, which is usually the URL of the request.
+func ParsePagination(h http.Header, base *url.URL) (Pagination, error) {
+	var p Pagination
+	var links, err = ParseLink(strings.Join(h.Values(Link), ", "))
+	if err != nil {
+		return p, err
+	}
+	for _, l := range links.Links {
+		var u url.URL = l.Target
+		var target *url.URL = &u
+		if base != nil {
+			target = base.ResolveReference(&u)
+		}
+		for _, rel := range l.Rel {
+			switch strings.ToLower(rel) {
+			case "first":
+				p.First = target
+			case "last":
+				p.Last = target
+			case "next":
+				p.Next = target
+			case "prev", "previous":
+				p.Prev = target
+			}
+		}
+	}
+	return p, nil
+}
+
+// Find returns the first link of a Link HTTP header with a relation type.
+func (l LinkHeader) Find(rel string) (LinkValue, bool) {
+	for _, link := range l.Links {
+		if link.HasRel(rel) {
+			return link, true
+		}
+	}
+	return LinkValue{}, false
+}
+
+// HasRel reports whether a link has a relation type. Relation types are compared case-insensitively.
+func (l LinkValue) HasRel(rel string) bool {
+	for _, r := range l.Rel {
+		if strings.EqualFold(r, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns a string representation of a single link of a Link HTTP header.
+func (l LinkValue) String() string {
+	var substrings ([]string) = []string{("<" + l.Target.String() + ">")}
+	if len(l.Rel) != 0 {
+		(substrings) = (append(substrings, ("rel=" + tokenOrQuoted(strings.Join(l.Rel, " ")))))
+	}
+	if len(l.Anchor) != 0 {
+		(substrings) = (append(substrings, ("anchor=" + quoteString(l.Anchor))))
+	}
+	for _, lang := range l.HrefLang {
+		(substrings) = (append(substrings, ("hreflang=" + tokenOrQuoted(lang))))
+	}
+	if len(l.Type) != 0 {
+		(substrings) = (append(substrings, ("type=" + tokenOrQuoted(l.Type))))
+	}
+	if len(l.Title) != 0 {
+		if isPrintableASCII(l.Title) {
+			(substrings) = (append(substrings, ("title=" + quoteString(l.Title))))
+		} else {
+			(substrings) = (append(substrings, ("title*=" + encodeExtValue(l.Title))))
+		}
+	}
+	var names ([]string) = (make([]string, 0, len(l.Params)))
+	for name := range l.Params {
+		(names) = (append(names, name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		(substrings) = (append(substrings, (name + "=" + tokenOrQuoted(l.Params[name]))))
+	}
+	return (strings.Join(substrings, "; "))
+}
+
+// Close closes the body of the current page.
+func (it *PageIterator) Close() error {
+	if it.res == nil {
+		return nil
+	}
+	var err error = it.res.Body.Close()
+	it.res = nil
+	return err
+}
+
+// Err returns the error that stopped a PageIterator, or nil when it stopped after the last page.
+func (it *PageIterator) Err() error {
+	return it.err
+}
+
+// Next closes the body of the current page and fetches the next page. It returns false when there is no next page or
+// when fetching it fails. Responses with a status code other than 2xx stop the PageIterator with an error.
+func (it *PageIterator) Next() bool {
+	it.Close()
+	if it.err != nil || it.next == nil {
+		return false
+	}
+	var u *url.URL = it.next
+	it.next = nil
+	if it.visited[u.String()] {
+		it.err = ErrPageLoop
+		return false
+	}
+	it.visited[u.String()] = true
+	var r *http.Request = it.request.Clone(it.request.Context())
+	r.URL = u
+	r.Host = ""
+	if !OriginOf(u).SameOrigin(OriginOf(it.request.URL)) {
+		for _, name := range pageCredentialHeaders {
+			r.Header.Del(name)
+		}
+	}
+	var res, err = it.client.Do(r)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		res.Body.Close()
+		it.err = fmt.Errorf("w3g: fetching page %s: %s", u, res.Status)
+		return false
+	}
+	var p Pagination
+	if p, err = ParsePagination(res.Header, u); err != nil {
+		res.Body.Close()
+		it.err = err
+		return false
+	}
+	it.next = p.Next
+	it.res = res
+	return true
+}
+
+// Response returns the response of the current page. Its body is closed by the next call to Next or Close.
+func (it *PageIterator) Response() *http.Response {
+	return it.res
+}
+
+// isPrintableASCII reports whether a string only contains printable ASCII characters.
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLinkValue parses a single link of a Link HTTP header.
+func parseLinkValue(s string) (LinkValue, error) {
+	var l LinkValue
+	if !strings.HasPrefix(s, "<") || strings.IndexByte(s, '>') == -1 {
+		return l, errors.New("missing target")
+	}
+	var end int = strings.IndexByte(s, '>')
+	var target, err = url.Parse(strings.TrimSpace(s[1:end]))
+	if err != nil {
+		return l, err
+	}
+	l.Target = *target
+	var rest string = strings.TrimSpace(s[end+1:])
+	if len(rest) == 0 {
+		return l, nil
+	}
+	if rest[0] != ';' {
+		return l, errors.New("missing parameter separator")
+	}
+	var seen map[string]bool = map[string]bool{}
+	for _, param := range splitQuoted(rest[1:], ';') {
+		if len(param) == 0 {
+			continue
+		}
+		var name, value, _ = strings.Cut(param, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if !isToken(name) {
+			return l, errors.New("invalid parameter name")
+		}
+		if unquoted, ok := unquoteString(value); ok {
+			value = unquoted
+		} else if strings.HasPrefix(value, "\"") {
+			return l, errors.New("invalid parameter value")
+		}
+		if name == "hreflang" {
+			l.HrefLang = append(l.HrefLang, value)
+			continue
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		switch name {
+		case "anchor":
+			l.Anchor = value
+		case "rel":
+			l.Rel = strings.Fields(value)
+		case "title":
+			if !seen["title*"] {
+				l.Title = value
+			}
+		case "title*":
+			if title, ok := decodeExtValue(value); ok {
+				l.Title = title
+			}
+		case "type":
+			l.Type = value
+		default:
+			if l.Params == nil {
+				l.Params = map[string]string{}
+			}
+			l.Params[name] = value
+		}
+	}
+	return l, nil
+}
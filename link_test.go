@@ -0,0 +1,128 @@
+package w3g_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestParseLink(t *testing.T) {
+	var s string = `<https://example.com/a,b;c>; rel="next last"; title="a, b; c", </terms>; REL=copyright; anchor="#foo"; hreflang=en; hreflang=de, <http://example.org/>; rel=start; title*=UTF-8'de'letztes%20Kapitel; title="fallback"; type="text/html"; media=print`
+	var h, err = w3g.ParseLink(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Links) != 3 {
+		t.Fatalf("ParseLink(%q) returned %d links, want 3", s, len(h.Links))
+	}
+	var first, second, third w3g.LinkValue = h.Links[0], h.Links[1], h.Links[2]
+	if first.Target.String() != "https://example.com/a,b;c" || !reflect.DeepEqual(first.Rel, []string{"next", "last"}) || first.Title != "a, b; c" {
+		t.Errorf("first link = %+v", first)
+	}
+	if second.Anchor != "#foo" || !second.HasRel("Copyright") || !reflect.DeepEqual(second.HrefLang, []string{"en", "de"}) {
+		t.Errorf("second link = %+v", second)
+	}
+	if third.Title != "letztes Kapitel" || third.Type != "text/html" || third.Params["media"] != "print" {
+		t.Errorf("third link = %+v", third)
+	}
+	if l, ok := h.Find("START"); !ok || l.Target.Host != "example.org" {
+		t.Errorf("LinkHeader.Find(%q) = %+v, %t", "START", l, ok)
+	}
+	if got, want := third.String(), `<http://example.org/>; rel=start; type="text/html"; title="letztes Kapitel"; media=print`; got != want {
+		t.Errorf("LinkValue.String() = %q, want %q", got, want)
+	}
+	third.Title = "Übersicht"
+	if got, want := third.String(), `<http://example.org/>; rel=start; type="text/html"; title*=UTF-8''%C3%9Cbersicht; media=print`; got != want {
+		t.Errorf("LinkValue.String() = %q, want %q", got, want)
+	}
+	for _, invalid := range []string{"https://example.com/", `<https://example.com/> rel=next`, `<https://example.com/>; rel="next`} {
+		if _, err = w3g.ParseLink(invalid); err == nil {
+			t.Errorf("ParseLink(%q) succeeded, want error", invalid)
+		}
+	}
+}
+
+func TestLinkHeader(t *testing.T) {
+	var u, _ = url.Parse("https://example.com/legacy")
+	var h w3g.LinkHeader = w3g.LinkHeader{URL: *u, Links: []w3g.LinkValue{{Rel: []string{"preload"}, Target: url.URL{Path: "/style.css"}, Params: map[string]string{"as": "style"}}}}
+	if got, want := h.String(), "<https://example.com/legacy>, </style.css>; rel=preload; as=style"; got != want {
+		t.Errorf("LinkHeader.String() = %q, want %q", got, want)
+	}
+}
+
+func TestPageIterator(t *testing.T) {
+	var server *httptest.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var page string = r.URL.Query().Get("page")
+		switch page {
+		case "", "1":
+			w.Header().Add(w3g.Link, `</items?page=2>; rel="next", </items?page=3>; rel="last"`)
+		case "2":
+			w.Header().Add(w3g.Link, `</items?page=1>; rel="prev first"`)
+			w.Header().Add(w3g.Link, `</items?page=3>; rel=next`)
+		case "3":
+			w.Header().Add(w3g.Link, `</items>; rel=next`)
+		}
+		fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+	var r, _ = http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+	r.Header.Set("Authorization", "token")
+	var it *w3g.PageIterator = w3g.NewPageIterator(server.Client(), r)
+	var pages []string
+	for it.Next() {
+		var p, err = w3g.ParsePagination(it.Response().Header, it.Response().Request.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, it.Response().Request.URL.RawQuery)
+		if it.Response().Request.URL.RawQuery == "page=2" && (p.First.String() != server.URL+"/items?page=1" || p.Prev.String() != p.First.String()) {
+			t.Errorf("ParsePagination() = %+v", p)
+		}
+	}
+	if !reflect.DeepEqual(pages, []string{"", "page=2", "page=3"}) {
+		t.Errorf("PageIterator visited %q", pages)
+	}
+	if it.Err() != w3g.ErrPageLoop {
+		t.Errorf("PageIterator.Err() = %v, want %v", it.Err(), w3g.ErrPageLoop)
+	}
+}
+
+func TestPageIteratorCrossOrigin(t *testing.T) {
+	var received http.Header
+	var other *httptest.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer other.Close()
+	var server *httptest.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(w3g.Link, "<"+other.URL+"/collect>; rel=next")
+	}))
+	defer server.Close()
+	var r, _ = http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+	r.Header.Set(w3g.Authorization, "Bearer secret")
+	r.Header.Set(w3g.Cookie, "session=secret")
+	r.Header.Set(w3g.ProxyAuthorization, "Basic secret")
+	r.Header.Set(w3g.Accept, "application/json")
+	var it *w3g.PageIterator = w3g.NewPageIterator(nil, r)
+	for it.Next() {
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	if received == nil || received.Get(w3g.Accept) != "application/json" {
+		t.Fatalf("next page was not requested with the headers of the first request: %v", received)
+	}
+	for _, name := range []string{w3g.Authorization, w3g.Cookie, w3g.ProxyAuthorization} {
+		if v := received.Get(name); len(v) != 0 {
+			t.Errorf("cross-origin page received %s: %q", name, v)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package w3g
+
+import (
+	"sort"
+	"strings"
+)
+
+// MediaType is a struct to hold a media type as defined by RFC 9110, such as the value of a Content-Type HTTP header
+// or a member of an Accept HTTP header. Type, SubType and parameter names are lower case. A Type or SubType of "*"
+// is a wildcard, and a SubType of "*+json" matches every subtype with the +json structured syntax suffix.
+type MediaType struct {
+	Params  map[string]string `json:"params"`
+	SubType string            `json:"subtype"`
+	Type    string            `json:"type"`
+}
+
+// ParseMediaType parses a media type with optional parameters. Parameter values may be tokens or quoted-strings.
+func ParseMediaType(s string) (MediaType, error) {
+	var params []string = splitQuoted(s, ';')
+	var t, sub, ok = strings.Cut(strings.ToLower(params[0]), "/")
+	if !ok || !isToken(t) || !isToken(sub) {
+		return MediaType{}, &HeaderValueError{Header: ContentType, Value: s}
+	}
+	var m MediaType = MediaType{SubType: sub, Type: t}
+	for _, param := range params[1:] {
+		if len(param) == 0 {
+			continue
+		}
+		var name, value, ok = strings.Cut(param, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		var v, valid = unquoteString(value)
+		if !ok || !isToken(name) || (!valid && len(value) != 0) {
+			return MediaType{}, &HeaderValueError{Header: ContentType, Value: s}
+		}
+		if m.Params == nil {
+			m.Params = map[string]string{}
+		}
+		if _, ok := m.Params[name]; !ok {
+			m.Params[name] = v
+		}
+	}
+	return m, nil
+}
+
+// MediaType returns the MediaType of an Accept HTTP header value, without its quality value.
+func (a AcceptHeader) MediaType() MediaType {
+	return newMediaType(a.MIMEType, a.MIMESubType, nil)
+}
+
+// MediaType returns the MediaType of an Accept-Patch HTTP header value.
+func (a AcceptPatchHeader) MediaType() MediaType {
+	return newMediaType(a.MIMEType, a.MIMESubType, map[string]string{"charset": a.Charset})
+}
+
+// MediaType returns the MediaType of a Content-Type HTTP header value.
+func (c ContentTypeHeader) MediaType() MediaType {
+	return newMediaType(c.MIMEType, c.MIMESubType, map[string]string{"boundary": c.Boundary, "charset": c.Charset})
+}
+
+// Charset returns the charset parameter of a MediaType, or the default charset of the media type when it does not
+// have one.
+func (m MediaType) Charset() string {
+	if charset, ok := m.Params["charset"]; ok {
+		return charset
+	}
+	if info, ok := LookupMediaType(m.Essence()); ok {
+		return info.Charset
+	}
+	return ""
+}
+
+// Compressible reports whether a MediaType is worth compressing, according to the embedded MIME database or, for media
+// types that are not in it, its text type or structured syntax suffix.
+func (m MediaType) Compressible() bool {
+	if info, ok := LookupMediaType(m.Essence()); ok && info.CompressibleKnown {
+		return info.Compressible
+	}
+	switch m.Suffix() {
+	case "json", "text", "xml":
+		return true
+	}
+	return (m.Type == "text")
+}
+
+// Essence returns the type and subtype of a MediaType without its parameters.
+func (m MediaType) Essence() string {
+	return (m.Type + "/" + m.SubType)
+}
+
+// IsJSON reports whether a MediaType is JSON: application/json, text/json or a subtype with the +json suffix.
+func (m MediaType) IsJSON() bool {
+	return (m.Essence() == "application/json" || m.Essence() == "text/json" || m.Suffix() == "json")
+}
+
+// IsXML reports whether a MediaType is XML: application/xml, text/xml or a subtype with the +xml suffix.
+func (m MediaType) IsXML() bool {
+	return (m.Essence() == "application/xml" || m.Essence() == "text/xml" || m.Suffix() == "xml")
+}
+
+// Match reports whether a MediaType, which may contain wildcards, matches another media type. Every parameter of the
+// MediaType must be present in the other media type with the same value; charset values are compared
+// case-insensitively.
+func (m MediaType) Match(other MediaType) bool {
+	if m.Type != "*" && m.Type != other.Type {
+		return false
+	}
+	switch {
+	case m.SubType == "*":
+	case strings.HasPrefix(m.SubType, "*+"):
+		if other.Suffix() != m.SubType[2:] {
+			return false
+		}
+	case m.SubType != other.SubType:
+		return false
+	}
+	for name, value := range m.Params {
+		var v, ok = other.Params[name]
+		if !ok || (v != value && !(name == "charset" && strings.EqualFold(v, value))) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns a string representation of a MediaType with its parameters in alphabetical order.
+func (m MediaType) String() string {
+	var names ([]string) = (make([]string, 0, len(m.Params)))
+	for name := range m.Params {
+		(names) = (append(names, name))
+	}
+	sort.Strings(names)
+	var substrings ([]string) = []string{m.Essence()}
+	for _, name := range names {
+		(substrings) = (append(substrings, (name + "=" + tokenOrQuoted(m.Params[name]))))
+	}
+	return (strings.Join(substrings, "; "))
+}
+
+// Suffix returns the structured syntax suffix of a MediaType, such as "json" for application/ld+json, or an empty
+// string when its subtype does not have one.
+func (m MediaType) Suffix() string {
+	var i int = strings.LastIndexByte(m.SubType, '+')
+	if i == -1 {
+		return ""
+	}
+	return m.SubType[i+1:]
+}
+
+// newMediaType returns a MediaType with the parameters that are not empty, using a wildcard for an empty type or subtype.
+func newMediaType(t, sub string, params map[string]string) MediaType {
+	var m MediaType = MediaType{SubType: strings.ToLower(sub), Type: strings.ToLower(t)}
+	if len(m.Type) == 0 {
+		m.Type = "*"
+	}
+	if len(m.SubType) == 0 {
+		m.SubType = "*"
+	}
+	for name, value := range params {
+		if len(value) == 0 {
+			continue
+		}
+		if m.Params == nil {
+			m.Params = map[string]string{}
+		}
+		m.Params[name] = value
+	}
+	return m
+}
@@ -0,0 +1,101 @@
+package w3g_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestParseMediaType(t *testing.T) {
+	var m, err = w3g.ParseMediaType(`Application/LD+JSON; Profile="https://www.w3.org/ns/activitystreams"; charset=UTF-8`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want w3g.MediaType = w3g.MediaType{
+		Params:  map[string]string{"charset": "UTF-8", "profile": "https://www.w3.org/ns/activitystreams"},
+		SubType: "ld+json",
+		Type:    "application",
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("ParseMediaType() = %+v, want %+v", m, want)
+	}
+	if got, want := m.String(), `application/ld+json; charset=UTF-8; profile="https://www.w3.org/ns/activitystreams"`; got != want {
+		t.Errorf("MediaType.String() = %q, want %q", got, want)
+	}
+	if m.Suffix() != "json" || !m.IsJSON() || m.IsXML() || !m.Compressible() {
+		t.Errorf("suffix of %v not recognised", m)
+	}
+	for _, invalid := range []string{"text", "text/", "text/html; charset", `text/html; charset="utf-8`, "te xt/html"} {
+		if _, err = w3g.ParseMediaType(invalid); err == nil {
+			t.Errorf("ParseMediaType(%q) succeeded, want error", invalid)
+		}
+	}
+}
+
+func TestMediaTypeMatch(t *testing.T) {
+	var tests = []struct {
+		pattern string
+		t       string
+		want    bool
+	}{
+		{"*/*", "image/png", true},
+		{"image/*", "image/png", true},
+		{"image/*", "text/png", false},
+		{"text/html", "text/html; charset=utf-8", true},
+		{"text/html; charset=UTF-8", "text/html; charset=utf-8", true},
+		{"text/html; level=1", "text/html", false},
+		{"application/*+json", "application/problem+json", true},
+		{"application/*+json", "application/json", false},
+		{"*/*+xml", "image/svg+xml", true},
+	}
+	for _, test := range tests {
+		var pattern, _ = w3g.ParseMediaType(test.pattern)
+		var m, _ = w3g.ParseMediaType(test.t)
+		if got := pattern.Match(m); got != test.want {
+			t.Errorf("%q.Match(%q) = %t, want %t", test.pattern, test.t, got, test.want)
+		}
+	}
+	var c w3g.ContentTypeHeader = w3g.ContentTypeHeader{Charset: "utf-8", MIMESubType: "HTML", MIMEType: "text"}
+	if got := c.MediaType().String(); got != "text/html; charset=utf-8" {
+		t.Errorf("ContentTypeHeader.MediaType() = %q", got)
+	}
+	if got := (w3g.AcceptHeader{MIMEType: "image"}).MediaType().String(); got != "image/*" {
+		t.Errorf("AcceptHeader.MediaType() = %q", got)
+	}
+}
+
+func TestMIMEDatabase(t *testing.T) {
+	var tests = []struct {
+		ext  string
+		want string
+	}{
+		{"html", "text/html; charset=utf-8"},
+		{".JSON", "application/json; charset=utf-8"},
+		{"png", "image/png"},
+		{"mp4", "video/mp4"},
+		{"woff2", "font/woff2"},
+		{"wasm", "application/wasm"},
+		{"svg", "image/svg+xml"},
+		{"xml", "application/xml"},
+		{"mp3", "audio/mpeg"},
+		{"rtf", "application/rtf"},
+	}
+	for _, test := range tests {
+		if m, ok := w3g.TypeByExtension(test.ext); !ok || m.String() != test.want {
+			t.Errorf("TypeByExtension(%q) = %q, %t, want %q", test.ext, m.String(), ok, test.want)
+		}
+	}
+	if _, ok := w3g.TypeByExtension("not-an-extension"); ok {
+		t.Error("TypeByExtension() found an unknown extension")
+	}
+	if got := w3g.ExtensionsByType("text/html; charset=utf-8"); !reflect.DeepEqual(got, []string{"html", "htm", "shtml"}) {
+		t.Errorf("ExtensionsByType(%q) = %q", "text/html", got)
+	}
+	for essence, want := range map[string]bool{"text/html": true, "image/png": false, "application/x-unknown+json": true, "text/x-unknown": true, "video/x-unknown": false} {
+		var m, _ = w3g.ParseMediaType(essence)
+		if got := m.Compressible(); got != want {
+			t.Errorf("%q.Compressible() = %t, want %t", essence, got, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package w3g
+
+//go:generate sh -c "curl -fsSL https://cdn.jsdelivr.net/npm/mime-db@latest/db.json -o mime_db.json"
+//go:generate sh -c "curl -fsSL https://cdn.jsdelivr.net/npm/mime-db@latest/LICENSE -o LICENSE.mime-db"
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// mediaTypeSources are the sources of the embedded MIME database from the least to the most preferred, used to decide
+// which media type an extension that several media types claim belongs to.
+var mediaTypeSources = []string{"nginx", "apache", "", "iana"}
+
+// mimeDatabaseData is the embedded copy of version 1.35.0 of the mime-db MIME database
+// (https://github.com/jshttp/mime-db), whose MIT License is in LICENSE.mime-db. Run go generate to update both to the
+// latest release.
+//
+//go:embed mime_db.json
+var mimeDatabaseData []byte
+
+// mimeDatabase holds the parsed embedded MIME database.
+var mimeDatabase struct {
+	extensions map[string]string
+	once       sync.Once
+	types      map[string]MediaTypeInfo
+}
+
+// MediaTypeInfo is a struct to hold what the embedded MIME database knows about a media type. Charset is the default
+// charset of the media type. CompressibleKnown is false when the database does not say whether the media type is
+// compressible.
+type MediaTypeInfo struct {
+	Charset           string   `json:"charset"`
+	Compressible      bool     `json:"compressible"`
+	CompressibleKnown bool     `json:"compressible_known"`
+	Extensions        []string `json:"extensions"`
+	Source            string   `json:"source"`
+}
+
+// ExtensionsByType returns the file extensions, without a leading dot, of a media type. Parameters of the media type
+// are ignored.
+func ExtensionsByType(t string) []string {
+	var m, err = ParseMediaType(t)
+	if err != nil {
+		return nil
+	}
+	var info, _ = LookupMediaType(m.Essence())
+	return info.Extensions
+}
+
+// LookupMediaType returns what the embedded MIME database knows about the essence of a media type, such as
+// "text/html".
+func LookupMediaType(essence string) (MediaTypeInfo, bool) {
+	loadMIMEDatabase()
+	var info, ok = mimeDatabase.types[strings.ToLower(essence)]
+	return info, ok
+}
+
+// TypeByExtension returns the MediaType of a file extension, with or without a leading dot, and with a charset
+// parameter when the media type has a default charset. Extensions are matched case-insensitively and do not depend on
+// the MIME database of the host.
+func TypeByExtension(ext string) (MediaType, bool) {
+	loadMIMEDatabase()
+	var essence, ok = mimeDatabase.extensions[strings.ToLower(strings.TrimPrefix(ext, "."))]
+	if !ok {
+		return MediaType{}, false
+	}
+	var m, _ = ParseMediaType(essence)
+	if charset := m.Charset(); len(charset) != 0 {
+		m.Params = map[string]string{"charset": strings.ToLower(charset)}
+	}
+	return m, true
+}
+
+// loadMIMEDatabase parses the embedded MIME database on first use. Text media types without a charset default to UTF-8.
+// An extension claimed by several media types belongs to the one from the most preferred source, as in the
+// mime-types package.
+func loadMIMEDatabase() {
+	mimeDatabase.once.Do(func() {
+		var db map[string]struct {
+			Charset      string   `json:"charset"`
+			Compressible *bool    `json:"compressible"`
+			Extensions   []string `json:"extensions"`
+			Source       string   `json:"source"`
+		}
+		if err := json.Unmarshal(mimeDatabaseData, &db); err != nil {
+			panic(err)
+		}
+		var essences []string = make([]string, 0, len(db))
+		for essence := range db {
+			essences = append(essences, essence)
+		}
+		sort.Strings(essences)
+		mimeDatabase.extensions = map[string]string{}
+		mimeDatabase.types = make(map[string]MediaTypeInfo, len(db))
+		for _, essence := range essences {
+			var entry = db[essence]
+			var info MediaTypeInfo = MediaTypeInfo{Charset: entry.Charset, Extensions: entry.Extensions, Source: entry.Source}
+			if len(info.Charset) == 0 && strings.HasPrefix(essence, "text/") {
+				info.Charset = "UTF-8"
+			}
+			if entry.Compressible != nil {
+				info.Compressible, info.CompressibleKnown = *entry.Compressible, true
+			}
+			mimeDatabase.types[essence] = info
+			for _, ext := range entry.Extensions {
+				if existing, ok := mimeDatabase.extensions[ext]; ok && existing != "application/octet-stream" {
+					var from, to int = mediaTypeSourceRank(db[existing].Source), mediaTypeSourceRank(entry.Source)
+					if from > to || (from == to && strings.HasPrefix(existing, "application/")) {
+						continue
+					}
+				}
+				mimeDatabase.extensions[ext] = essence
+			}
+		}
+	})
+}
+
+// mediaTypeSourceRank returns the preference of a source of the embedded MIME database.
+func mediaTypeSourceRank(source string) int {
+	for i, s := range mediaTypeSources {
+		if s == source {
+			return i
+		}
+	}
+	return -1
+}
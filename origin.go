@@ -0,0 +1,118 @@
+package w3g
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// OriginValue is a struct to hold the origin of a URL: either a tuple of scheme, host and port, or an opaque origin that is
+// serialised as "null". Ports that are the default port of the scheme are not stored.
+type OriginValue struct {
+	Host   string `json:"host"`
+	Opaque bool   `json:"opaque"`
+	Port   string `json:"port"`
+	Scheme string `json:"scheme"`
+}
+
+// OriginOf returns the OriginValue of a URL. URLs whose scheme does not have a tuple origin, such as data: URLs,
+// have an opaque OriginValue.
+func OriginOf(u *url.URL) OriginValue {
+	var scheme string = strings.ToLower(u.Scheme)
+	switch scheme {
+	case "http", "https", "ws", "wss", "ftp":
+	case "blob":
+		var inner, err = url.Parse(u.Opaque)
+		if err == nil && (strings.EqualFold(inner.Scheme, "http") || strings.EqualFold(inner.Scheme, "https")) {
+			return OriginOf(inner)
+		}
+		return OriginValue{Opaque: true}
+	default:
+		return OriginValue{Opaque: true}
+	}
+	var host, ok = asciiHost(u.Hostname())
+	if !ok {
+		return OriginValue{Opaque: true}
+	}
+	var port string = u.Port()
+	if port == defaultPort(scheme) {
+		port = ""
+	}
+	return OriginValue{Host: host, Port: port, Scheme: scheme}
+}
+
+// ParseOrigin parses a serialised origin, such as the value of an Origin HTTP header: either "null" or a scheme,
+// host and optional port without any path, query, fragment or credentials.
+func ParseOrigin(s string) (OriginValue, error) {
+	if s == "null" {
+		return OriginValue{Opaque: true}, nil
+	}
+	var u, err = url.Parse(s)
+	if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 || u.User != nil || len(u.Path) != 0 || len(u.RawQuery) != 0 || u.ForceQuery || len(u.Fragment) != 0 || strings.HasSuffix(s, "#") {
+		return OriginValue{}, &HeaderValueError{Header: Origin, Value: s}
+	}
+	var o OriginValue = OriginOf(u)
+	if o.Opaque {
+		return OriginValue{}, &HeaderValueError{Header: Origin, Value: s}
+	}
+	return o, nil
+}
+
+// RegistrableDomain returns the registrable domain of the host of an OriginValue, or an empty string when the OriginValue
+// is opaque or its host does not have a registrable domain.
+func (o OriginValue) RegistrableDomain() string {
+	if o.Opaque {
+		return ""
+	}
+	return RegistrableDomain(o.Host)
+}
+
+// SameOrigin reports whether two OriginValues are the same origin. An opaque OriginValue is never the same origin as another OriginValue.
+func (o OriginValue) SameOrigin(other OriginValue) bool {
+	if o.Opaque || other.Opaque {
+		return false
+	}
+	return (o.Scheme == other.Scheme && o.Host == other.Host && o.Port == other.Port)
+}
+
+// SameSite reports whether two OriginValues are schemefully same site: they have the same scheme and either the same
+// registrable domain or, for hosts without a registrable domain such as IP addresses, the same host.
+// An opaque OriginValue is never same site with another OriginValue.
+func (o OriginValue) SameSite(other OriginValue) bool {
+	if o.Opaque || other.Opaque || o.Scheme != other.Scheme {
+		return false
+	}
+	var domain, otherDomain string = o.RegistrableDomain(), other.RegistrableDomain()
+	if len(domain) == 0 || len(otherDomain) == 0 {
+		return (o.Host == other.Host)
+	}
+	return (domain == otherDomain)
+}
+
+// String returns the serialisation of an OriginValue.
+func (o OriginValue) String() string {
+	if o.Opaque || len(o.Scheme) == 0 {
+		return "null"
+	}
+	var host string = o.Host
+	if strings.Contains(host, ":") && net.ParseIP(host) != nil {
+		host = "[" + host + "]"
+	}
+	if len(o.Port) != 0 {
+		host = net.JoinHostPort(o.Host, o.Port)
+	}
+	return (o.Scheme + "://" + host)
+}
+
+// defaultPort returns the default port of a URL scheme, or an empty string when the scheme does not have one.
+func defaultPort(scheme string) string {
+	switch strings.ToLower(scheme) {
+	case "http", "ws":
+		return "80"
+	case "https", "wss":
+		return "443"
+	case "ftp":
+		return "21"
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package w3g_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestParseOrigin(t *testing.T) {
+	var tests = map[string]string{
+		"null":                    "null",
+		"https://Example.COM":     "https://example.com",
+		"https://example.com:443": "https://example.com",
+		"http://example.com:8080": "http://example.com:8080",
+		"http://[::1]:8080":       "http://[::1]:8080",
+		"https://bücher.example":  "https://xn--bcher-kva.example",
+	}
+	for s, want := range tests {
+		var o, err = w3g.ParseOrigin(s)
+		if err != nil || o.String() != want {
+			t.Errorf("ParseOrigin(%q) = %q, %v, want %q", s, o, err, want)
+		}
+	}
+	for _, s := range []string{"https://example.com/", "https://example.com/path", "https://user@example.com", "example.com", "data:text/plain,x"} {
+		if _, err := w3g.ParseOrigin(s); err == nil {
+			t.Errorf("ParseOrigin(%q) should fail", s)
+		}
+	}
+}
+
+func TestOriginSameSite(t *testing.T) {
+	var tests = []struct {
+		a, b                 string
+		sameOrigin, sameSite bool
+	}{
+		{"https://example.com", "https://example.com", true, true},
+		{"https://a.example.com", "https://b.example.com", false, true},
+		{"http://example.com", "https://example.com", false, false},
+		{"https://a.github.io", "https://b.github.io", false, false},
+		{"https://a.example.co.uk", "https://example.co.uk", false, true},
+		{"https://127.0.0.1", "https://127.0.0.1:8443", false, true},
+		{"null", "null", false, false},
+	}
+	for _, test := range tests {
+		var a, _ = w3g.ParseOrigin(test.a)
+		var b, _ = w3g.ParseOrigin(test.b)
+		if a.SameOrigin(b) != test.sameOrigin || a.SameSite(b) != test.sameSite {
+			t.Errorf("%s and %s: same origin %t, same site %t", test.a, test.b, a.SameOrigin(b), a.SameSite(b))
+		}
+	}
+}
+
+func TestOriginHeader(t *testing.T) {
+	var u, _ = url.Parse("https://user@www.example.com:443/path?q#f")
+	if s := (w3g.OriginHeader{URL: *u}).String(); s != "https://www.example.com" {
+		t.Errorf("OriginHeader.String() = %q", s)
+	}
+}
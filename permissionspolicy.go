@@ -0,0 +1,250 @@
+package w3g
+
+import (
+	"strings"
+
+	"github.com/gellel/w3g/sfv"
+)
+
+// featurePolicyRenames maps Feature-Policy feature names to the names that replaced them in Permissions-Policy.
+var featurePolicyRenames = map[string]string{
+	"publickey-credentials": "publickey-credentials-get",
+	"vr":                    "xr-spatial-tracking",
+	"wake-lock":             "screen-wake-lock",
+}
+
+// PermissionsPolicyAllowlist is a struct to hold the origins that a Permissions-Policy directive allows to use a feature.
+// The zero value allows no origin.
+type PermissionsPolicyAllowlist struct {
+	All     bool     `json:"all"`
+	Origins []string `json:"origins"`
+	Self    bool     `json:"self"`
+	Src     bool     `json:"src"`
+}
+
+// PermissionsPolicyDirective is a struct to hold a feature of a Permissions-Policy HTTP header and its allowlist.
+type PermissionsPolicyDirective struct {
+	Allowlist PermissionsPolicyAllowlist `json:"allowlist"`
+	Feature   string                     `json:"feature"`
+	ReportTo  string                     `json:"report_to"`
+}
+
+// PermissionsPolicyHeader is a struct to prepare a Permissions-Policy HTTP header.
+type PermissionsPolicyHeader struct {
+	Directives []PermissionsPolicyDirective `json:"directives"`
+}
+
+// ParseAllowAttribute parses the allow attribute of an iframe, which has the grammar of a Feature-Policy HTTP header,
+// into a PermissionsPolicyHeader. Feature names are kept as they are written. A feature without an allowlist allows
+// the origin of the iframe's src, which is the default allowlist of the allow attribute.
+func ParseAllowAttribute(s string) (PermissionsPolicyHeader, error) {
+	return parseFeaturePolicy(s, PermissionsPolicyAllowlist{Src: true})
+}
+
+// ParseFeaturePolicy parses a Feature-Policy HTTP header value into a PermissionsPolicyHeader. Feature names are kept
+// as they are written. A feature without an allowlist allows the document's own origin, which is the default
+// allowlist of a Feature-Policy HTTP header; use ParseAllowAttribute for the allow attribute of an iframe.
+func ParseFeaturePolicy(s string) (PermissionsPolicyHeader, error) {
+	return parseFeaturePolicy(s, PermissionsPolicyAllowlist{Self: true})
+}
+
+// parseFeaturePolicy parses a policy with the grammar of a Feature-Policy HTTP header. Features without an allowlist
+// are given the default allowlist.
+func parseFeaturePolicy(s string, defaultAllowlist PermissionsPolicyAllowlist) (PermissionsPolicyHeader, error) {
+	var p PermissionsPolicyHeader
+	for _, directive := range strings.Split(s, ";") {
+		var fields []string = strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		if !validFeature(fields[0]) {
+			return PermissionsPolicyHeader{}, &HeaderValueError{Header: FeaturePolicy, Value: s}
+		}
+		var allowlist PermissionsPolicyAllowlist
+		if len(fields) == 1 {
+			allowlist = defaultAllowlist
+		}
+		for _, field := range fields[1:] {
+			switch strings.ToLower(field) {
+			case "*":
+				allowlist.All = true
+			case "'self'":
+				allowlist.Self = true
+			case "'src'":
+				allowlist.Src = true
+			case "'none'":
+			default:
+				var o, err = ParseOrigin(field)
+				if err != nil || o.Opaque {
+					return PermissionsPolicyHeader{}, &HeaderValueError{Header: FeaturePolicy, Value: s}
+				}
+				allowlist.Origins = append(allowlist.Origins, o.String())
+			}
+		}
+		p.Set(fields[0], allowlist)
+	}
+	return p, nil
+}
+
+// ParsePermissionsPolicy parses a Permissions-Policy HTTP header value. Allowlist members that are neither *, self
+// nor a tuple origin are ignored, as the Permissions Policy specification requires.
+func ParsePermissionsPolicy(s string) (PermissionsPolicyHeader, error) {
+	var d, err = sfv.ParseDictionary(s)
+	if err != nil {
+		return PermissionsPolicyHeader{}, &HeaderValueError{Header: PermissionsPolicy, Value: s}
+	}
+	var p PermissionsPolicyHeader
+	for _, m := range d {
+		var directive PermissionsPolicyDirective = PermissionsPolicyDirective{Feature: m.Key}
+		var items []sfv.Item
+		var params sfv.Params
+		switch x := m.Value.(type) {
+		case sfv.Item:
+			items, params = []sfv.Item{x}, x.Params
+		case sfv.InnerList:
+			items, params = x.Items, x.Params
+		}
+		for _, item := range items {
+			switch v := item.Value.(type) {
+			case sfv.Token:
+				switch v {
+				case "*":
+					directive.Allowlist.All = true
+				case "self":
+					directive.Allowlist.Self = true
+				}
+			case string:
+				if o, err := ParseOrigin(v); err == nil && !o.Opaque {
+					directive.Allowlist.Origins = append(directive.Allowlist.Origins, o.String())
+				}
+			}
+		}
+		if v, ok := params.Get("report-to"); ok {
+			if token, ok := v.(sfv.Token); ok {
+				directive.ReportTo = string(token)
+			}
+		}
+		p.Directives = append(p.Directives, directive)
+	}
+	return p, nil
+}
+
+// PermissionsPolicy converts a Feature-Policy HTTP header to the equivalent Permissions-Policy HTTP header,
+// renaming the features that were renamed by the Permissions Policy specification.
+func (f FeaturePolicyHeader) PermissionsPolicy() (PermissionsPolicyHeader, error) {
+	var legacy, err = ParseFeaturePolicy(f.String())
+	if err != nil {
+		return PermissionsPolicyHeader{}, err
+	}
+	var p PermissionsPolicyHeader
+	for _, directive := range legacy.Directives {
+		var feature string = directive.Feature
+		if renamed, ok := featurePolicyRenames[feature]; ok {
+			feature = renamed
+		}
+		p.Set(feature, directive.Allowlist)
+	}
+	return p, nil
+}
+
+// AllowAttribute returns the value of an iframe allow attribute that applies the same policy to the frame.
+func (p PermissionsPolicyHeader) AllowAttribute() string {
+	var substrings ([]string) = (make([]string, 0, len(p.Directives)))
+	for _, directive := range p.Directives {
+		(substrings) = (append(substrings, (directive.Feature + " " + directive.Allowlist.featurePolicy())))
+	}
+	return (strings.Join(substrings, "; "))
+}
+
+// Get returns the allowlist of a feature.
+func (p PermissionsPolicyHeader) Get(feature string) (PermissionsPolicyAllowlist, bool) {
+	for _, directive := range p.Directives {
+		if directive.Feature == feature {
+			return directive.Allowlist, true
+		}
+	}
+	return PermissionsPolicyAllowlist{}, false
+}
+
+// Set sets the allowlist of a feature, keeping the position of the feature when it is already present.
+func (p *PermissionsPolicyHeader) Set(feature string, allowlist PermissionsPolicyAllowlist) {
+	for i := range p.Directives {
+		if p.Directives[i].Feature == feature {
+			p.Directives[i].Allowlist = allowlist
+			return
+		}
+	}
+	p.Directives = append(p.Directives, PermissionsPolicyDirective{Allowlist: allowlist, Feature: feature})
+}
+
+// String returns a string representation of a Permissions-Policy HTTP header.
+func (p PermissionsPolicyHeader) String() string {
+	var d sfv.Dictionary
+	for _, directive := range p.Directives {
+		var params sfv.Params
+		if len(directive.ReportTo) != 0 {
+			params.Set("report-to", sfv.Token(directive.ReportTo))
+		}
+		if directive.Allowlist.All {
+			d.Set(directive.Feature, sfv.Item{Params: params, Value: sfv.Token("*")})
+			continue
+		}
+		var l sfv.InnerList = sfv.InnerList{Items: []sfv.Item{}, Params: params}
+		if directive.Allowlist.Self {
+			l.Items = append(l.Items, sfv.Item{Value: sfv.Token("self")})
+		}
+		for _, origin := range directive.Allowlist.Origins {
+			l.Items = append(l.Items, sfv.Item{Value: origin})
+		}
+		d.Set(directive.Feature, l)
+	}
+	return d.String()
+}
+
+// featurePolicy returns the Feature-Policy syntax of a PermissionsPolicyAllowlist.
+func (a PermissionsPolicyAllowlist) featurePolicy() string {
+	if a.All {
+		return "*"
+	}
+	var substrings ([]string) = (make([]string, 0, len(a.Origins)+2))
+	if a.Self {
+		(substrings) = (append(substrings, "'self'"))
+	}
+	if a.Src {
+		(substrings) = (append(substrings, "'src'"))
+	}
+	(substrings) = (append(substrings, a.Origins...))
+	if len(substrings) == 0 {
+		return "'none'"
+	}
+	return (strings.Join(substrings, " "))
+}
+
+// featurePolicyAllowlist returns the Feature-Policy syntax of the allowlist of a FeaturePolicyHeader field,
+// quoting the keywords that may be written without quotes.
+func featurePolicyAllowlist(s string) string {
+	var substrings ([]string) = (make([]string, 0))
+	for _, field := range strings.Fields(s) {
+		var keyword string = strings.ToLower(strings.Trim(field, "'"))
+		switch keyword {
+		case "none", "self", "src":
+			(substrings) = (append(substrings, ("'" + keyword + "'")))
+		default:
+			(substrings) = (append(substrings, field))
+		}
+	}
+	return (strings.Join(substrings, " "))
+}
+
+// validFeature reports whether a string is a valid policy-controlled feature name.
+func validFeature(s string) bool {
+	if len(s) == 0 || s[0] < 'a' || s[0] > 'z' {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if c := s[i]; (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
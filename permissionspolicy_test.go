@@ -0,0 +1,78 @@
+package w3g_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestParsePermissionsPolicy(t *testing.T) {
+	var s string = `camera=(), fullscreen=*, geolocation=(self "https://maps.example.com:443" "data:x" unknown);report-to=main`
+	var p, err = w3g.ParsePermissionsPolicy(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []w3g.PermissionsPolicyDirective = []w3g.PermissionsPolicyDirective{
+		{Feature: "camera"},
+		{Allowlist: w3g.PermissionsPolicyAllowlist{All: true}, Feature: "fullscreen"},
+		{Allowlist: w3g.PermissionsPolicyAllowlist{Origins: []string{"https://maps.example.com"}, Self: true}, Feature: "geolocation", ReportTo: "main"},
+	}
+	if !reflect.DeepEqual(p.Directives, want) {
+		t.Errorf("ParsePermissionsPolicy(%q) = %+v, want %+v", s, p.Directives, want)
+	}
+	if got, want := p.String(), `camera=(), fullscreen=*, geolocation=(self "https://maps.example.com");report-to=main`; got != want {
+		t.Errorf("PermissionsPolicyHeader.String() = %q, want %q", got, want)
+	}
+	if got, want := p.AllowAttribute(), "camera 'none'; fullscreen *; geolocation 'self' https://maps.example.com"; got != want {
+		t.Errorf("PermissionsPolicyHeader.AllowAttribute() = %q, want %q", got, want)
+	}
+	if _, err = w3g.ParsePermissionsPolicy("camera=(self"); err == nil {
+		t.Error("ParsePermissionsPolicy accepted an unterminated inner list")
+	}
+}
+
+func TestParseFeaturePolicy(t *testing.T) {
+	var s string = "geolocation 'self' https://example.com; camera 'none'; fullscreen *; autoplay"
+	var p, err = w3g.ParseFeaturePolicy(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.String(), `geolocation=(self "https://example.com"), camera=(), fullscreen=*, autoplay=(self)`; got != want {
+		t.Errorf("ParseFeaturePolicy(%q).String() = %q, want %q", s, got, want)
+	}
+	for _, invalid := range []string{"Camera 'self'", "camera example.com", "camera 'self' /path"} {
+		if _, err = w3g.ParseFeaturePolicy(invalid); err == nil {
+			t.Errorf("ParseFeaturePolicy(%q) succeeded, want error", invalid)
+		}
+	}
+}
+
+func TestParseAllowAttribute(t *testing.T) {
+	var p, err = w3g.ParseAllowAttribute("fullscreen; camera 'self'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Directives) != 2 || !p.Directives[0].Allowlist.Src || p.Directives[0].Allowlist.Self || !p.Directives[1].Allowlist.Self {
+		t.Errorf("ParseAllowAttribute() = %+v, want fullscreen to default to 'src'", p.Directives)
+	}
+}
+
+func TestFeaturePolicyHeader(t *testing.T) {
+	var f w3g.FeaturePolicyHeader = w3g.FeaturePolicyHeader{
+		Camera:               "none",
+		PublicKeyCredentials: "self https://id.example.com",
+		SyncXHR:              "*",
+		VR:                   "'self'",
+	}
+	if got, want := f.String(), "camera 'none'; publickey-credentials 'self' https://id.example.com; sync-xhr *; vr 'self'"; got != want {
+		t.Errorf("FeaturePolicyHeader.String() = %q, want %q", got, want)
+	}
+	var p, err = f.PermissionsPolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.String(), `camera=(), publickey-credentials-get=(self "https://id.example.com"), sync-xhr=*, xr-spatial-tracking=(self)`; got != want {
+		t.Errorf("FeaturePolicyHeader.PermissionsPolicy() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,166 @@
+package w3g
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// hopByHopHeaders are the HTTP headers that only apply to a single connection, and are never forwarded by proxies.
+var hopByHopHeaders = []string{
+	Connection,
+	KeepAlive,
+	ProxyAuthenticate,
+	ProxyAuthorization,
+	"Proxy-Connection",
+	TE,
+	Trailer,
+	TransferEncoding,
+	Upgrade}
+
+// traceExcludedHeaders are the HTTP headers that are left out of the responses to TRACE requests, because they are
+// likely to hold credentials.
+var traceExcludedHeaders = map[string]bool{
+	Authorization:      true,
+	Cookie:             true,
+	ProxyAuthorization: true}
+
+// ReverseProxy is a struct to configure a reverse proxy to Target. Hop-by-hop HTTP headers, including those named by
+// the Connection HTTP header, are removed from requests and responses, except for a TE HTTP header of trailers, and
+// the proxy adds itself to their Via HTTP header as Pseudonym, or as the host of the request when Pseudonym is empty.
+// The client is appended to the Forwarded HTTP header of requests, after the elements of the proxies before it, and
+// to the X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto HTTP headers when XForwarded is set. TRACE and OPTIONS requests have their Max-Forwards HTTP header decremented, and are answered by
+// the proxy when it is 0. Requests whose Via HTTP header already has Pseudonym are in a loop, and receive a 508 Loop
+// Detected response. Protocol upgrades, such as WebSocket, are passed through. Transport sends the requests, and
+// http.DefaultTransport is used when it is nil.
+type ReverseProxy struct {
+	Pseudonym  string            `json:"pseudonym"`
+	Target     *url.URL          `json:"target"`
+	Transport  http.RoundTripper `json:"-"`
+	XForwarded bool              `json:"x_forwarded"`
+}
+
+// NewReverseProxy returns a ReverseProxy to target.
+func NewReverseProxy(target *url.URL) *ReverseProxy {
+	return &ReverseProxy{Target: target}
+}
+
+// ParseConnectionHeader parses a Connection HTTP header value, a comma-separated list of connection options.
+func ParseConnectionHeader(s string) (ConnectionHeader, error) {
+	var c ConnectionHeader
+	for _, option := range strings.Split(s, ",") {
+		if option = strings.ToLower(strings.TrimSpace(option)); len(option) == 0 {
+			continue
+		}
+		if !isToken(option) {
+			return ConnectionHeader{}, &HeaderValueError{Header: Connection, Value: s}
+		}
+		if option == "close" {
+			c.Close = true
+			continue
+		}
+		(c.Options) = (append(c.Options, option))
+	}
+	return c, nil
+}
+
+// RemoveHopByHopHeaders removes the hop-by-hop HTTP headers of a message: Connection, the headers it names, Keep-Alive,
+// Proxy-Authenticate, Proxy-Authorization, Proxy-Connection, TE, Trailer, Transfer-Encoding and Upgrade.
+func RemoveHopByHopHeaders(h http.Header) {
+	for _, value := range h.Values(Connection) {
+		var c, err = ParseConnectionHeader(value)
+		if err != nil {
+			continue
+		}
+		for _, option := range c.Options {
+			h.Del(option)
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		h.Del(name)
+	}
+}
+
+// ServeHTTP proxies a HTTP request to the Target of a ReverseProxy.
+func (p *ReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(p.Pseudonym) != 0 {
+		if chain, err := ParseViaChain(r.Header); err == nil && chain.Contains(p.Pseudonym) {
+			http.Error(w, http.StatusText(http.StatusLoopDetected), http.StatusLoopDetected)
+			return
+		}
+	}
+	var maxForwards int64 = -1
+	if r.Method == http.MethodTrace || r.Method == http.MethodOptions {
+		if n, err := strconv.ParseInt(strings.TrimSpace(r.Header.Get(MaxForwards)), 10, 32); err == nil && n >= 0 {
+			if n == 0 {
+				p.answer(w, r)
+				return
+			}
+			maxForwards = n - 1
+		}
+	}
+	var via string = p.via(r.Proto, r.Host)
+	var proxy *httputil.ReverseProxy = &httputil.ReverseProxy{
+		ModifyResponse: func(res *http.Response) error {
+			if res.StatusCode != http.StatusSwitchingProtocols {
+				RemoveHopByHopHeaders(res.Header)
+			}
+			res.Header.Add(Via, p.via(res.Proto, r.Host))
+			return nil
+		},
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(p.Target)
+			if p.XForwarded {
+				pr.SetXForwarded()
+			}
+			if maxForwards >= 0 {
+				pr.Out.Header.Set(MaxForwards, strconv.FormatInt(maxForwards, 10))
+			}
+			var forwarded ForwardedHeader = ForwardedHeader{Host: pr.In.Host, Proto: "http"}
+			if pr.In.TLS != nil {
+				forwarded.Proto = "https"
+			}
+			if host, _, err := net.SplitHostPort(pr.In.RemoteAddr); err == nil {
+				forwarded.Identifier = net.ParseIP(host)
+			}
+			for _, value := range pr.In.Header.Values(Forwarded) {
+				pr.Out.Header.Add(Forwarded, value)
+			}
+			pr.Out.Header.Add(Forwarded, forwarded.String())
+			pr.Out.Header.Add(Via, via)
+		},
+		Transport: p.Transport}
+	proxy.ServeHTTP(w, r)
+}
+
+// answer answers a TRACE or OPTIONS request whose Max-Forwards HTTP header is 0. TRACE requests are reflected in a
+// message/http body, without the headers that are likely to hold credentials.
+func (p *ReverseProxy) answer(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set(ContentLength, "0")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	var b bytes.Buffer
+	b.WriteString(r.Method + " " + r.RequestURI + " " + r.Proto + "\r\n")
+	b.WriteString("Host: " + r.Host + "\r\n")
+	r.Header.WriteSubset(&b, traceExcludedHeaders)
+	b.WriteString("\r\n")
+	w.Header().Set(ContentType, "message/http")
+	w.Header().Set(ContentLength, strconv.Itoa(b.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(b.Bytes())
+}
+
+// via returns the entry of a ReverseProxy in the Via HTTP header of a message received with a protocol.
+func (p *ReverseProxy) via(protocol string, host string) string {
+	var v ViaHeader = ViaHeader{ProtocolVersion: strings.TrimPrefix(protocol, "HTTP/"), Pseudonym: p.Pseudonym}
+	if len(v.Pseudonym) == 0 {
+		v.Pseudonym = host
+	}
+	return v.String()
+}
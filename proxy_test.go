@@ -0,0 +1,132 @@
+package w3g_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestParseConnectionHeader(t *testing.T) {
+	var c, err = w3g.ParseConnectionHeader("Keep-Alive, X-Custom,close")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Close || !c.Has("x-custom") || !c.Has("CLOSE") || c.Has("upgrade") {
+		t.Errorf("ParseConnectionHeader() = %+v", c)
+	}
+	if got := c.String(); got != "close, keep-alive, x-custom" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (w3g.ConnectionHeader{}).String(); got != "keep-alive" {
+		t.Errorf("String() = %q, want keep-alive", got)
+	}
+	if _, err := w3g.ParseConnectionHeader("a b"); err == nil {
+		t.Error("ParseConnectionHeader(\"a b\") did not return an error")
+	}
+}
+
+func TestRemoveHopByHopHeaders(t *testing.T) {
+	var h http.Header = http.Header{}
+	h.Add(w3g.Connection, "keep-alive, X-Hop")
+	h.Add(w3g.Connection, "x-other")
+	for _, name := range []string{"X-Hop", "X-Other", w3g.KeepAlive, w3g.TE, w3g.Trailer, w3g.TransferEncoding, w3g.Upgrade, w3g.ProxyAuthorization, "Proxy-Connection", w3g.Accept} {
+		h.Set(name, "1")
+	}
+	w3g.RemoveHopByHopHeaders(h)
+	if len(h) != 1 || h.Get(w3g.Accept) != "1" {
+		t.Errorf("RemoveHopByHopHeaders() left %v", h)
+	}
+}
+
+func TestReverseProxy(t *testing.T) {
+	var backend *httptest.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(w3g.Upgrade) == "echo" {
+			w.Header().Set(w3g.Connection, "upgrade")
+			w.Header().Set(w3g.Upgrade, "echo")
+			w.WriteHeader(http.StatusSwitchingProtocols)
+			var conn, rw, _ = w.(http.Hijacker).Hijack()
+			defer conn.Close()
+			io.Copy(conn, rw)
+			return
+		}
+		w.Header().Set(w3g.Connection, "X-Backend")
+		w.Header().Set("X-Backend", "secret")
+		w.Header().Set("X-Hop", r.Header.Get("X-Hop"))
+		w.Header().Set(w3g.Forwarded, strings.Join(r.Header.Values(w3g.Forwarded), ", "))
+		w.Header().Set(w3g.MaxForwards, r.Header.Get(w3g.MaxForwards))
+		w.Header().Set("X-TE", r.Header.Get(w3g.TE))
+		w.Header().Set("X-Via", r.Header.Get(w3g.Via))
+		w.Header().Set("X-Forwarded", r.Header.Get(w3g.XForwardedFor))
+	}))
+	defer backend.Close()
+	var target, _ = url.Parse(backend.URL)
+	var proxy *w3g.ReverseProxy = w3g.NewReverseProxy(target)
+	proxy.Pseudonym = "gateway"
+	proxy.XForwarded = true
+	var frontend *httptest.Server = httptest.NewServer(proxy)
+	defer frontend.Close()
+
+	var r, _ = http.NewRequest(http.MethodOptions, frontend.URL+"/", nil)
+	r.Header.Set(w3g.Connection, "X-Hop")
+	r.Header.Set("X-Hop", "1")
+	r.Header.Set(w3g.MaxForwards, "5")
+	r.Header.Set(w3g.Forwarded, "for=192.0.2.60;proto=https")
+	r.Header.Set(w3g.TE, "trailers")
+	r.Host = "example.com"
+	var res, err = http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	for name, want := range map[string]string{
+		"X-Hop":           "",
+		"X-Backend":       "",
+		w3g.Forwarded:     "for=192.0.2.60;proto=https, for=127.0.0.1;host=example.com;proto=http",
+		"X-TE":            "trailers",
+		w3g.MaxForwards:   "4",
+		"X-Via":           "1.1 gateway",
+		"X-Forwarded":     "127.0.0.1",
+		w3g.Via:           "1.1 gateway",
+		w3g.ContentLength: "0",
+	} {
+		if got := res.Header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	r, _ = http.NewRequest(http.MethodTrace, frontend.URL+"/path", nil)
+	r.Header.Set(w3g.MaxForwards, "0")
+	r.Header.Set(w3g.Authorization, "Bearer secret")
+	r.Header.Set("X-Trace", "1")
+	if res, err = http.DefaultClient.Do(r); err != nil {
+		t.Fatal(err)
+	}
+	var body, _ = io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.Header.Get(w3g.ContentType) != "message/http" || !strings.HasPrefix(string(body), "TRACE /path HTTP/1.1\r\n") || !strings.Contains(string(body), "X-Trace: 1\r\n") || strings.Contains(string(body), "secret") {
+		t.Errorf("TRACE body = %q", body)
+	}
+
+	var conn, _ = net.Dial("tcp", frontend.Listener.Addr().String())
+	defer conn.Close()
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+	var br *bufio.Reader = bufio.NewReader(conn)
+	if res, err = http.ReadResponse(br, nil); err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols || res.Header.Get(w3g.Upgrade) != "echo" {
+		t.Fatalf("upgrade status = %d, Upgrade = %q", res.StatusCode, res.Header.Get(w3g.Upgrade))
+	}
+	io.WriteString(conn, "ping")
+	var echo []byte = make([]byte, 4)
+	if _, err := io.ReadFull(br, echo); err != nil || string(echo) != "ping" {
+		t.Errorf("echo = %q, %v", echo, err)
+	}
+}
@@ -0,0 +1,298 @@
+package w3g
+
+//go:generate sh -c "curl -fsSL https://publicsuffix.org/list/public_suffix_list.dat -o public_suffix_list.dat"
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+)
+
+// publicSuffixRuleException is the kind of a Public Suffix List rule starting with "!".
+const publicSuffixRuleException int = 3
+
+// publicSuffixRuleNormal is the kind of a Public Suffix List rule without a wildcard or exception marker.
+const publicSuffixRuleNormal int = 1
+
+// publicSuffixRuleWildcard is the kind of a Public Suffix List rule starting with "*.".
+const publicSuffixRuleWildcard int = 2
+
+// publicSuffixListData is the embedded copy of the Public Suffix List. Run go generate to update it.
+//
+//go:embed public_suffix_list.dat
+var publicSuffixListData string
+
+// publicSuffixList is the PublicSuffixList used by the package level functions.
+var publicSuffixList *PublicSuffixList
+
+// publicSuffixListMutex guards publicSuffixList.
+var publicSuffixListMutex sync.RWMutex
+
+// publicSuffixListOnce parses the embedded Public Suffix List on first use.
+var publicSuffixListOnce sync.Once
+
+// ErrPublicSuffixList is returned when a Public Suffix List does not contain any rules.
+var ErrPublicSuffixList = errors.New("w3g: public suffix list has no rules")
+
+// PublicSuffixList is a parsed copy of the Public Suffix List, which lists the domains under which Internet users
+// can directly register names, such as "com", "co.uk" and "github.io".
+type PublicSuffixList struct {
+	rules map[string]publicSuffixRule
+}
+
+// publicSuffixRule is a single rule of a PublicSuffixList.
+type publicSuffixRule struct {
+	icann bool
+	kind  int
+}
+
+// DefaultPublicSuffixList returns the PublicSuffixList used by PublicSuffix, RegistrableDomain and Origin.SameSite.
+// Unless replaced with SetPublicSuffixList, it is the copy of the list embedded in the package.
+func DefaultPublicSuffixList() *PublicSuffixList {
+	publicSuffixListOnce.Do(func() {
+		var l, err = ParsePublicSuffixList(strings.NewReader(publicSuffixListData))
+		if err != nil {
+			panic(err)
+		}
+		publicSuffixListMutex.Lock()
+		if publicSuffixList == nil {
+			publicSuffixList = l
+		}
+		publicSuffixListMutex.Unlock()
+	})
+	publicSuffixListMutex.RLock()
+	defer publicSuffixListMutex.RUnlock()
+	return publicSuffixList
+}
+
+// SetPublicSuffixList replaces the PublicSuffixList used by the package, for example with a newer copy
+// of https://publicsuffix.org/list/public_suffix_list.dat downloaded at runtime.
+func SetPublicSuffixList(l *PublicSuffixList) {
+	publicSuffixListOnce.Do(func() {})
+	publicSuffixListMutex.Lock()
+	publicSuffixList = l
+	publicSuffixListMutex.Unlock()
+}
+
+// ParsePublicSuffixList parses a Public Suffix List in the format of https://publicsuffix.org/list/public_suffix_list.dat.
+func ParsePublicSuffixList(r io.Reader) (*PublicSuffixList, error) {
+	var l *PublicSuffixList = &PublicSuffixList{rules: make(map[string]publicSuffixRule)}
+	var icann bool
+	var scanner *bufio.Scanner = bufio.NewScanner(r)
+	for scanner.Scan() {
+		var line string = strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.Contains(line, "===BEGIN ICANN DOMAINS==="):
+			icann = true
+			continue
+		case strings.Contains(line, "===END ICANN DOMAINS==="):
+			icann = false
+			continue
+		case len(line) == 0 || strings.HasPrefix(line, "//"):
+			continue
+		}
+		if i := strings.IndexAny(line, " \t"); i != -1 {
+			line = line[:i]
+		}
+		var rule publicSuffixRule = publicSuffixRule{icann: icann, kind: publicSuffixRuleNormal}
+		if strings.HasPrefix(line, "!") {
+			rule.kind = publicSuffixRuleException
+			line = line[1:]
+		} else if strings.HasPrefix(line, "*.") {
+			rule.kind = publicSuffixRuleWildcard
+			line = line[2:]
+		}
+		var domain, ok = asciiHost(line)
+		if !ok {
+			continue
+		}
+		l.rules[domain] = rule
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(l.rules) == 0 {
+		return nil, ErrPublicSuffixList
+	}
+	return l, nil
+}
+
+// PublicSuffix returns the public suffix of a host using the default PublicSuffixList.
+func PublicSuffix(host string) (string, bool) {
+	return DefaultPublicSuffixList().PublicSuffix(host)
+}
+
+// RegistrableDomain returns the registrable domain of a host using the default PublicSuffixList.
+func RegistrableDomain(host string) string {
+	return DefaultPublicSuffixList().RegistrableDomain(host)
+}
+
+// PublicSuffix returns the public suffix of a host in its ASCII form, and whether the suffix is managed by ICANN
+// rather than being a privately registered domain such as "github.io". Hosts that match no rule use the implicit
+// "*" rule. An empty string is returned for IP addresses and hosts that are not valid domains.
+func (l *PublicSuffixList) PublicSuffix(host string) (string, bool) {
+	var domain, ok = asciiHost(host)
+	if !ok || net.ParseIP(domain) != nil {
+		return "", false
+	}
+	var labels []string = strings.Split(domain, ".")
+	for i := range labels {
+		if rule, ok := l.rules[strings.Join(labels[i:], ".")]; ok && rule.kind == publicSuffixRuleException {
+			return strings.Join(labels[i+1:], "."), rule.icann
+		}
+	}
+	for i := range labels {
+		if rule, ok := l.rules[strings.Join(labels[i:], ".")]; ok && rule.kind == publicSuffixRuleNormal {
+			return strings.Join(labels[i:], "."), rule.icann
+		}
+		if i+1 < len(labels) {
+			if rule, ok := l.rules[strings.Join(labels[i+1:], ".")]; ok && rule.kind == publicSuffixRuleWildcard {
+				return strings.Join(labels[i:], "."), rule.icann
+			}
+		}
+	}
+	return labels[len(labels)-1], false
+}
+
+// RegistrableDomain returns the registrable domain of a host in its ASCII form: its public suffix and the label
+// preceding it. An empty string is returned when the host is itself a public suffix, an IP address or not a valid domain.
+func (l *PublicSuffixList) RegistrableDomain(host string) string {
+	var suffix, _ = l.PublicSuffix(host)
+	if len(suffix) == 0 {
+		return ""
+	}
+	var domain, _ = asciiHost(host)
+	if domain == suffix {
+		return ""
+	}
+	var prefix string = strings.TrimSuffix(domain, "."+suffix)
+	return prefix[strings.LastIndex(prefix, ".")+1:] + "." + suffix
+}
+
+// asciiHost lower cases a host, removes a trailing dot and converts any internationalised labels to their
+// punycode form. The returned bool is false when the host has an empty label.
+func asciiHost(host string) (string, bool) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if len(host) == 0 {
+		return "", false
+	}
+	var labels []string = strings.Split(host, ".")
+	for i, label := range labels {
+		if len(label) == 0 {
+			return "", false
+		}
+		if !isASCII(label) {
+			var encoded, err = punycodeEncode(label)
+			if err != nil {
+				return "", false
+			}
+			labels[i] = "xn--" + encoded
+		}
+	}
+	return strings.Join(labels, "."), true
+}
+
+// isASCII reports whether a string only contains ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// punycodeEncode encodes a label with the Punycode algorithm of RFC 3492.
+func punycodeEncode(s string) (string, error) {
+	const base, tmin, tmax int32 = 36, 1, 26
+	var output ([]byte) = (make([]byte, 0, 2*len(s)))
+	var delta, n, bias int32 = 0, 128, 72
+	var b, remaining int32
+	for _, r := range s {
+		if r < 0x80 {
+			b++
+			output = append(output, byte(r))
+		} else {
+			remaining++
+		}
+	}
+	var h int32 = b
+	if b > 0 {
+		output = append(output, '-')
+	}
+	for remaining != 0 {
+		var m int32 = 0x7fffffff
+		for _, r := range s {
+			if m > r && r >= n {
+				m = r
+			}
+		}
+		delta += (m - n) * (h + 1)
+		if delta < 0 {
+			return "", errors.New("w3g: punycode overflow")
+		}
+		n = m
+		for _, r := range s {
+			if r < n {
+				delta++
+				if delta < 0 {
+					return "", errors.New("w3g: punycode overflow")
+				}
+				continue
+			}
+			if r > n {
+				continue
+			}
+			var q int32 = delta
+			for k := base; ; k += base {
+				var t int32 = k - bias
+				if t < tmin {
+					t = tmin
+				} else if t > tmax {
+					t = tmax
+				}
+				if q < t {
+					break
+				}
+				output = append(output, punycodeDigit(t+(q-t)%(base-t)))
+				q = (q - t) / (base - t)
+			}
+			output = append(output, punycodeDigit(q))
+			bias = punycodeAdapt(delta, h+1, h == b)
+			delta = 0
+			h++
+			remaining--
+		}
+		delta++
+		n++
+	}
+	return string(output), nil
+}
+
+// punycodeAdapt is the bias adaptation function of RFC 3492.
+func punycodeAdapt(delta, points int32, first bool) int32 {
+	if first {
+		delta /= 700
+	} else {
+		delta /= 2
+	}
+	delta += delta / points
+	var k int32
+	for delta > ((36-1)*26)/2 {
+		delta /= 36 - 1
+		k += 36
+	}
+	return k + (36-1+1)*delta/(delta+38)
+}
+
+// punycodeDigit returns the basic code point of a Punycode digit.
+func punycodeDigit(digit int32) byte {
+	if digit < 26 {
+		return byte(digit + 'a')
+	}
+	return byte(digit - 26 + '0')
+}
@@ -0,0 +1,88 @@
+package w3g_test
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+// punycodeLabels are the ASCII forms of the internationalised labels of the expected domains of the Public Suffix
+// List test cases, as given by RFC 3492.
+var punycodeLabels = strings.NewReplacer(
+	"中国", "xn--fiqs8s",
+	"公司", "xn--55qx5d",
+	"食狮", "xn--85x722f")
+
+// toASCII returns an expected domain of the Public Suffix List test cases in ASCII, without using the function under
+// test.
+func toASCII(t *testing.T, domain string) string {
+	var ascii string = punycodeLabels.Replace(strings.ToLower(domain))
+	for i := 0; i < len(ascii); i++ {
+		if ascii[i] >= 0x80 {
+			t.Fatalf("no ASCII form for %q", domain)
+		}
+	}
+	return ascii
+}
+
+// TestRegistrableDomain runs the checkPublicSuffix test cases published alongside the Public Suffix List.
+func TestRegistrableDomain(t *testing.T) {
+	var f, err = os.Open("testdata/public_suffix_tests.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var scanner *bufio.Scanner = bufio.NewScanner(f)
+	for scanner.Scan() {
+		var fields []string = strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.HasPrefix(fields[0], "//") {
+			continue
+		}
+		var host, want string = fields[0], fields[1]
+		if host == "null" {
+			host = ""
+		}
+		if want == "null" {
+			want = ""
+		} else {
+			want = toASCII(t, want)
+		}
+		if got := w3g.RegistrableDomain(host); got != want {
+			t.Errorf("RegistrableDomain(%q) = %q, want %q", host, got, want)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPublicSuffix(t *testing.T) {
+	var tests = []struct {
+		host   string
+		suffix string
+		icann  bool
+	}{
+		{"www.example.co.uk", "co.uk", true},
+		{"user.github.io", "github.io", false},
+		{"example.unlisted", "unlisted", false},
+		{"127.0.0.1", "", false},
+	}
+	for _, test := range tests {
+		if suffix, icann := w3g.PublicSuffix(test.host); suffix != test.suffix || icann != test.icann {
+			t.Errorf("PublicSuffix(%q) = %q, %t, want %q, %t", test.host, suffix, icann, test.suffix, test.icann)
+		}
+	}
+	var l, err = w3g.ParsePublicSuffixList(strings.NewReader("// ===BEGIN ICANN DOMAINS===\ncom\n*.example\n!www.example\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.RegistrableDomain("a.b.example"); got != "a.b.example" {
+		t.Errorf("RegistrableDomain(a.b.example) = %q", got)
+	}
+	if got := l.RegistrableDomain("a.www.example"); got != "www.example" {
+		t.Errorf("RegistrableDomain(a.www.example) = %q", got)
+	}
+}
@@ -0,0 +1,129 @@
+package w3g
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// maxReferrerLength is the length after which a full referrer URL is reduced to its origin.
+const maxReferrerLength int = 4096
+
+// ParseReferrerPolicyList parses a Referrer-Policy HTTP header value, which may list several comma-separated policies
+// so that user agents that do not recognise a newer policy fall back to an older one. The last recognised policy
+// is returned, or an empty ReferrerPolicyValue when no policy is recognised.
+func ParseReferrerPolicyList(s string) ReferrerPolicyValue {
+	var policy ReferrerPolicyValue
+	for _, token := range strings.Split(s, ",") {
+		if r, err := ParseReferrerPolicy(token); err == nil {
+			policy = r
+		}
+	}
+	return policy
+}
+
+// Referrer returns the Referer HTTP header value that a user agent sends when a document at source requests
+// destination under policy, following the W3C Referrer Policy specification. An empty ReferrerPolicyValue is
+// treated as the default strict-origin-when-cross-origin policy. An empty string is returned when no Referer
+// HTTP header is sent.
+func Referrer(policy ReferrerPolicyValue, source, destination *url.URL) string {
+	if source == nil || destination == nil {
+		return ""
+	}
+	var referrerURL, ok = stripReferrerURL(source, false)
+	if !ok {
+		return ""
+	}
+	var referrerOrigin, _ = stripReferrerURL(source, true)
+	if len(referrerURL) > maxReferrerLength {
+		referrerURL = referrerOrigin
+	}
+	var downgrade bool = (potentiallyTrustworthy(source) && !potentiallyTrustworthy(destination))
+	var sameOrigin bool = (OriginOf(source).SameOrigin(OriginOf(destination)))
+	switch policy {
+	case ReferrerPolicyNoReferrer:
+		return ""
+	case ReferrerPolicyOrigin:
+		return referrerOrigin
+	case ReferrerPolicyUnsafeURL:
+		return referrerURL
+	case ReferrerPolicyStrictOrigin:
+		if downgrade {
+			return ""
+		}
+		return referrerOrigin
+	case ReferrerPolicySameOrigin:
+		if sameOrigin {
+			return referrerURL
+		}
+		return ""
+	case ReferrerPolicyOriginWhenCrossOrigin:
+		if sameOrigin {
+			return referrerURL
+		}
+		return referrerOrigin
+	case ReferrerPolicyNoReferrerWhenDowngrade:
+		if downgrade {
+			return ""
+		}
+		return referrerURL
+	}
+	if sameOrigin {
+		return referrerURL
+	}
+	if downgrade {
+		return ""
+	}
+	return referrerOrigin
+}
+
+// potentiallyTrustworthy reports whether a URL is a potentially trustworthy URL as defined by the W3C Secure Contexts
+// specification: a URL with a secure scheme, or a URL addressing the loopback interface.
+func potentiallyTrustworthy(u *url.URL) bool {
+	switch strings.ToLower(u.Scheme) {
+	case "https", "wss", "file":
+		return true
+	case "http", "ws":
+	default:
+		return false
+	}
+	var host string = strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	var ip net.IP = net.ParseIP(host)
+	return (ip != nil && ip.IsLoopback())
+}
+
+// stripReferrerURL strips a URL for use as a referrer, removing its credentials and fragment and, when originOnly is
+// true, its path and query. The returned bool is false when a URL with the scheme cannot be used as a referrer.
+func stripReferrerURL(u *url.URL, originOnly bool) (string, bool) {
+	var scheme string = strings.ToLower(u.Scheme)
+	switch scheme {
+	case "", "about", "blob", "data":
+		return "", false
+	}
+	var stripped url.URL = *u
+	var host string = strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); len(port) != 0 && port != defaultPort(scheme) {
+		host = host + ":" + port
+	}
+	stripped.Scheme = scheme
+	stripped.Host = host
+	stripped.User = nil
+	stripped.Fragment = ""
+	stripped.RawFragment = ""
+	if originOnly {
+		stripped.Path = ""
+		stripped.RawPath = ""
+		stripped.RawQuery = ""
+		stripped.ForceQuery = false
+	}
+	if len(stripped.Path) == 0 && len(stripped.Opaque) == 0 {
+		stripped.Path = "/"
+	}
+	return stripped.String(), true
+}
@@ -0,0 +1,124 @@
+package w3g
+
+import "fmt"
+
+// ResourceLoadAllowed is the ResourceLoadReason of a resource load that a browser would allow.
+const ResourceLoadAllowed ResourceLoadReason = ""
+
+// ResourceLoadBlockedByCOEP is the ResourceLoadReason of a cross-origin resource load that a browser would block
+// because the embedding document's Cross-Origin-Embedder-Policy requires an explicit Cross-Origin-Resource-Policy.
+const ResourceLoadBlockedByCOEP ResourceLoadReason = "coep"
+
+// ResourceLoadBlockedByCORP is the ResourceLoadReason of a resource load that a browser would block
+// because the response's Cross-Origin-Resource-Policy does not permit the requesting site.
+const ResourceLoadBlockedByCORP ResourceLoadReason = "corp"
+
+// ResourceLoadBlockedByCORS is the ResourceLoadReason of a cors mode resource load that a browser would block
+// because the response's Access-Control-Allow-* headers do not grant the requesting origin access.
+const ResourceLoadBlockedByCORS ResourceLoadReason = "cors"
+
+// ResourceLoadBlockedByMode is the ResourceLoadReason of a same-origin mode resource load that a browser would block
+// because the resource is cross-origin.
+const ResourceLoadBlockedByMode ResourceLoadReason = "mode"
+
+// ResourceLoadInvalid is the ResourceLoadReason of a resource load whose request context cannot be interpreted.
+const ResourceLoadInvalid ResourceLoadReason = "invalid"
+
+// ResourceLoadReason is the reason a browser would give for blocking a resource load.
+type ResourceLoadReason string
+
+// ResourceLoad is a struct to describe a resource load as seen by a browser: the Fetch Metadata of the request,
+// the Cross-Origin-Embedder-Policy of the document that initiated it and the isolation headers of the response.
+type ResourceLoad struct {
+	AllowCredentials AccessControlAllowCredentialsHeader `json:"allow_credentials"`
+	AllowOrigin      *AcceptControlAllowOriginHeader     `json:"allow_origin"`
+	Credentials      bool                                `json:"credentials"`
+	Dest             SecFetchDestValue                   `json:"dest"`
+	EmbedderPolicy   CrossOriginEmbedderPolicyValue      `json:"embedder_policy"`
+	Mode             SecFetchModeValue                   `json:"mode"`
+	Origin           string                              `json:"origin"`
+	ResourcePolicy   CrossOriginResourcePolicyValue      `json:"resource_policy"`
+	Site             SecFetchSiteValue                   `json:"site"`
+}
+
+// ResourceLoadResult is a struct to describe whether a browser would block a resource load and why.
+type ResourceLoadResult struct {
+	Blocked bool               `json:"blocked"`
+	Detail  string             `json:"detail"`
+	Reason  ResourceLoadReason `json:"reason"`
+}
+
+// String returns a string representation of a ResourceLoadResult.
+func (r ResourceLoadResult) String() string {
+	if !r.Blocked {
+		return "allowed"
+	}
+	return (fmt.Sprintf("blocked (%s): %s", r.Reason, r.Detail))
+}
+
+// Simulate decides whether a browser would block the ResourceLoad, following the CORS, Cross-Origin-Resource-Policy
+// and Cross-Origin-Embedder-Policy checks of the Fetch standard.
+func (r ResourceLoad) Simulate() ResourceLoadResult {
+	if !r.Site.Valid() || !r.Mode.Valid() || !r.Dest.Valid() {
+		return (ResourceLoadResult{Blocked: true, Reason: ResourceLoadInvalid, Detail: fmt.Sprintf("request context (%q, %q, %q) is not valid Fetch Metadata", r.Site, r.Mode, r.Dest)})
+	}
+	if len(r.EmbedderPolicy) != 0 && !r.EmbedderPolicy.Valid() {
+		return (ResourceLoadResult{Blocked: true, Reason: ResourceLoadInvalid, Detail: fmt.Sprintf("%s %q is not valid", CrossOriginEmbedderPolicy, r.EmbedderPolicy)})
+	}
+	var crossOrigin bool = (r.Site != SecFetchSiteSameOrigin && r.Site != SecFetchSiteNone)
+	switch r.Mode {
+	case SecFetchModeSameOrigin:
+		if crossOrigin {
+			return (ResourceLoadResult{Blocked: true, Reason: ResourceLoadBlockedByMode, Detail: fmt.Sprintf("same-origin request to a %s resource", r.Site)})
+		}
+	case SecFetchModeCORS:
+		if crossOrigin {
+			return (r.simulateCORS())
+		}
+	case SecFetchModeNoCORS:
+		return (r.simulateCORP(crossOrigin, r.Credentials))
+	case SecFetchModeNavigate:
+		// Nested navigations are only subject to Cross-Origin-Resource-Policy when the embedder policy is not
+		// unsafe-none.
+		if r.Dest != SecFetchDestDocument && len(r.EmbedderPolicy) != 0 && r.EmbedderPolicy != CrossOriginEmbedderPolicyUnsafeNone {
+			return (r.simulateCORP(crossOrigin, true))
+		}
+	}
+	return ResourceLoadResult{}
+}
+
+// simulateCORS performs the CORS check of a cross-origin cors mode ResourceLoad.
+func (r ResourceLoad) simulateCORS() ResourceLoadResult {
+	if r.AllowOrigin == nil {
+		return (ResourceLoadResult{Blocked: true, Reason: ResourceLoadBlockedByCORS, Detail: fmt.Sprintf("response has no %s header", AccessControlAllowOrigin)})
+	}
+	var allowOrigin string = (r.AllowOrigin.String())
+	if r.Credentials && allowOrigin == "*" {
+		return (ResourceLoadResult{Blocked: true, Reason: ResourceLoadBlockedByCORS, Detail: fmt.Sprintf("%s is \"*\" for a credentialed request", AccessControlAllowOrigin)})
+	}
+	if allowOrigin != "*" && allowOrigin != r.Origin {
+		return (ResourceLoadResult{Blocked: true, Reason: ResourceLoadBlockedByCORS, Detail: fmt.Sprintf("%s %q does not match origin %q", AccessControlAllowOrigin, allowOrigin, r.Origin)})
+	}
+	if r.Credentials && !r.AllowCredentials.Allow {
+		return (ResourceLoadResult{Blocked: true, Reason: ResourceLoadBlockedByCORS, Detail: fmt.Sprintf("%s is not \"true\" for a credentialed request", AccessControlAllowCredentials)})
+	}
+	return ResourceLoadResult{}
+}
+
+// simulateCORP performs the Cross-Origin-Resource-Policy check of a no-cors ResourceLoad, or of a nested navigation
+// from a document with an embedder policy.
+// Credentials reports whether a credentialless embedder policy should be enforced as require-corp.
+// A Cross-Origin-Resource-Policy that is not valid is ignored, as browsers do.
+func (r ResourceLoad) simulateCORP(crossOrigin bool, credentials bool) ResourceLoadResult {
+	if !r.ResourcePolicy.Valid() {
+		var requireCorp bool = (r.EmbedderPolicy == CrossOriginEmbedderPolicyRequireCorp || (r.EmbedderPolicy == CrossOriginEmbedderPolicyCredentialless && credentials))
+		if requireCorp && crossOrigin {
+			return (ResourceLoadResult{Blocked: true, Reason: ResourceLoadBlockedByCOEP, Detail: fmt.Sprintf("%s %s requires a %s header on %s responses", CrossOriginEmbedderPolicy, r.EmbedderPolicy, CrossOriginResourcePolicy, r.Site)})
+		}
+		return ResourceLoadResult{}
+	}
+	if (r.ResourcePolicy == CrossOriginResourcePolicySameOrigin && crossOrigin) || (r.ResourcePolicy == CrossOriginResourcePolicySameSite && r.Site == SecFetchSiteCrossSite) {
+		return (ResourceLoadResult{Blocked: true, Reason: ResourceLoadBlockedByCORP, Detail: fmt.Sprintf("%s %s does not permit a %s request", CrossOriginResourcePolicy, r.ResourcePolicy, r.Site)})
+	}
+	return ResourceLoadResult{}
+}
@@ -0,0 +1,41 @@
+package w3g_test
+
+import (
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestResourceLoadSimulate(t *testing.T) {
+	var origin string = "https://app.example"
+	var tests = []struct {
+		name   string
+		load   w3g.ResourceLoad
+		reason w3g.ResourceLoadReason
+	}{
+		{"same-origin image", w3g.ResourceLoad{Site: w3g.SecFetchSiteSameOrigin, Mode: w3g.SecFetchModeNoCORS, Dest: w3g.SecFetchDestImage}, w3g.ResourceLoadAllowed},
+		{"cross-site image without corp", w3g.ResourceLoad{Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeNoCORS, Dest: w3g.SecFetchDestImage}, w3g.ResourceLoadAllowed},
+		{"cross-site image under require-corp", w3g.ResourceLoad{Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeNoCORS, Dest: w3g.SecFetchDestImage, EmbedderPolicy: w3g.CrossOriginEmbedderPolicyRequireCorp}, w3g.ResourceLoadBlockedByCOEP},
+		{"cross-site image under credentialless", w3g.ResourceLoad{Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeNoCORS, Dest: w3g.SecFetchDestImage, EmbedderPolicy: w3g.CrossOriginEmbedderPolicyCredentialless}, w3g.ResourceLoadAllowed},
+		{"cross-site script with corp same-site", w3g.ResourceLoad{Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeNoCORS, Dest: w3g.SecFetchDestScript, ResourcePolicy: w3g.CrossOriginResourcePolicySameSite}, w3g.ResourceLoadBlockedByCORP},
+		{"same-site script with corp same-origin", w3g.ResourceLoad{Site: w3g.SecFetchSiteSameSite, Mode: w3g.SecFetchModeNoCORS, Dest: w3g.SecFetchDestScript, ResourcePolicy: w3g.CrossOriginResourcePolicySameOrigin}, w3g.ResourceLoadBlockedByCORP},
+		{"cross-site script with corp cross-origin", w3g.ResourceLoad{Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeNoCORS, Dest: w3g.SecFetchDestScript, ResourcePolicy: w3g.CrossOriginResourcePolicyCrossOrigin, EmbedderPolicy: w3g.CrossOriginEmbedderPolicyRequireCorp}, w3g.ResourceLoadAllowed},
+		{"cors fetch without allow-origin", w3g.ResourceLoad{Origin: origin, Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeCORS, Dest: w3g.SecFetchDestEmpty}, w3g.ResourceLoadBlockedByCORS},
+		{"cors fetch with wildcard", w3g.ResourceLoad{Origin: origin, Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeCORS, Dest: w3g.SecFetchDestEmpty, AllowOrigin: &w3g.AcceptControlAllowOriginHeader{}}, w3g.ResourceLoadAllowed},
+		{"credentialed cors fetch with wildcard", w3g.ResourceLoad{Origin: origin, Credentials: true, Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeCORS, Dest: w3g.SecFetchDestEmpty, AllowOrigin: &w3g.AcceptControlAllowOriginHeader{}, AllowCredentials: w3g.AccessControlAllowCredentialsHeader{Allow: true}}, w3g.ResourceLoadBlockedByCORS},
+		{"credentialed cors fetch", w3g.ResourceLoad{Origin: origin, Credentials: true, Site: w3g.SecFetchSiteSameSite, Mode: w3g.SecFetchModeCORS, Dest: w3g.SecFetchDestEmpty, AllowOrigin: &w3g.AcceptControlAllowOriginHeader{Origin: origin}, AllowCredentials: w3g.AccessControlAllowCredentialsHeader{Allow: true}}, w3g.ResourceLoadAllowed},
+		{"same-origin mode cross-site", w3g.ResourceLoad{Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeSameOrigin, Dest: w3g.SecFetchDestEmpty}, w3g.ResourceLoadBlockedByMode},
+		{"top-level navigation", w3g.ResourceLoad{Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeNavigate, Dest: w3g.SecFetchDestDocument, EmbedderPolicy: w3g.CrossOriginEmbedderPolicyRequireCorp}, w3g.ResourceLoadAllowed},
+		{"nested navigation under require-corp", w3g.ResourceLoad{Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeNavigate, Dest: w3g.SecFetchDestIFrame, EmbedderPolicy: w3g.CrossOriginEmbedderPolicyRequireCorp}, w3g.ResourceLoadBlockedByCOEP},
+		{"nested navigation to corp same-origin without coep", w3g.ResourceLoad{Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeNavigate, Dest: w3g.SecFetchDestIFrame, ResourcePolicy: w3g.CrossOriginResourcePolicySameOrigin}, w3g.ResourceLoadAllowed},
+		{"nested navigation to corp same-origin under credentialless", w3g.ResourceLoad{Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeNavigate, Dest: w3g.SecFetchDestIFrame, ResourcePolicy: w3g.CrossOriginResourcePolicySameOrigin, EmbedderPolicy: w3g.CrossOriginEmbedderPolicyCredentialless}, w3g.ResourceLoadBlockedByCORP},
+		{"websocket", w3g.ResourceLoad{Site: w3g.SecFetchSiteCrossSite, Mode: w3g.SecFetchModeWebSocket, Dest: w3g.SecFetchDestEmpty}, w3g.ResourceLoadAllowed},
+		{"ambiguous mode", w3g.ResourceLoad{Site: w3g.SecFetchSiteSameOrigin, Mode: "cors, navigate", Dest: w3g.SecFetchDestEmpty}, w3g.ResourceLoadInvalid},
+	}
+	for _, test := range tests {
+		var result w3g.ResourceLoadResult = test.load.Simulate()
+		if result.Reason != test.reason || result.Blocked != (test.reason != w3g.ResourceLoadAllowed) {
+			t.Errorf("%s: got %s, want reason %q", test.name, result, test.reason)
+		}
+	}
+}
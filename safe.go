@@ -0,0 +1,417 @@
+package w3g
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// ErrUnknownHeader is returned by SetHeader for a type that is not a header type of w3g.
+var ErrUnknownHeader = errors.New("w3g: unknown header type")
+
+// headerNames are the field names of the header types, by type.
+var headerNames = map[reflect.Type]string{
+	reflect.TypeOf(AcceptCHHeader{}):                      AcceptCH,
+	reflect.TypeOf(AcceptCHLifetimeHeader{}):              AcceptCHLifetime,
+	reflect.TypeOf(AcceptCharsetHeader{}):                 AcceptCharset,
+	reflect.TypeOf(AcceptControlAllowOriginHeader{}):      AccessControlAllowOrigin,
+	reflect.TypeOf(AcceptControlExposeHeadersHeader{}):    AccessControlExposeHeaders,
+	reflect.TypeOf(AcceptControlRequestHeadersHeader{}):   AccessControlRequestHeaders,
+	reflect.TypeOf(AcceptControlRequestMethodHeader{}):    AccessControlRequestMethod,
+	reflect.TypeOf(AcceptEncodingHeader{}):                AcceptEncoding,
+	reflect.TypeOf(AcceptHeader{}):                        Accept,
+	reflect.TypeOf(AcceptLanguageHeader{}):                AcceptLanguage,
+	reflect.TypeOf(AcceptPatchHeader{}):                   AcceptPatch,
+	reflect.TypeOf(AcceptRangesHeader{}):                  AcceptRanges,
+	reflect.TypeOf(AccessControlAllowCredentialsHeader{}): AccessControlAllowCredentials,
+	reflect.TypeOf(AccessControlAllowHeadersHeader{}):     AccessControlAllowHeaders,
+	reflect.TypeOf(AccessControlMaxAgeHeader{}):           AccessControlMaxAge,
+	reflect.TypeOf(AgeHeader{}):                           Age,
+	reflect.TypeOf(AllowHeader{}):                         Allow,
+	reflect.TypeOf(AltSvcHeader{}):                        AltSvc,
+	reflect.TypeOf(AuthorizationHeader{}):                 Authorization,
+	reflect.TypeOf(CacheControlHeader{}):                  CacheControl,
+	reflect.TypeOf(ClearSiteDataHeader{}):                 ClearSiteData,
+	reflect.TypeOf(ConnectionHeader{}):                    Connection,
+	reflect.TypeOf(ContentDispositionHeader{}):            ContentDisposition,
+	reflect.TypeOf(ContentEncodingHeader{}):               ContentEncoding,
+	reflect.TypeOf(ContentLanguageHeader{}):               ContentLanguage,
+	reflect.TypeOf(ContentLengthHeader{}):                 ContentLength,
+	reflect.TypeOf(ContentLocationHeader{}):               ContentLocation,
+	reflect.TypeOf(ContentMD5Header{}):                    ContentMD5,
+	reflect.TypeOf(ContentRangeHeader{}):                  ContentRange,
+	reflect.TypeOf(ContentTypeHeader{}):                   ContentType,
+	reflect.TypeOf(CookieHeader{}):                        Cookie,
+	reflect.TypeOf(CrossOriginEmbedderPolicyHeader{}):     CrossOriginEmbedderPolicy,
+	reflect.TypeOf(CrossOriginResourcePolicyHeader{}):     CrossOriginResourcePolicy,
+	reflect.TypeOf(DNTHeader{}):                           DNT,
+	reflect.TypeOf(DPRHeader{}):                           DPR,
+	reflect.TypeOf(DateHeader{}):                          Date,
+	reflect.TypeOf(DeviceMemoryHeader{}):                  DeviceMemory,
+	reflect.TypeOf(DigestHeader{}):                        Digest,
+	reflect.TypeOf(ETagHeader{}):                          ETag,
+	reflect.TypeOf(EarlyDataHeader{}):                     EarlyData,
+	reflect.TypeOf(ExpectCTHeader{}):                      ExpectCT,
+	reflect.TypeOf(ExpectHeader{}):                        Expect,
+	reflect.TypeOf(ExpiresHeader{}):                       Expires,
+	reflect.TypeOf(FeaturePolicyHeader{}):                 FeaturePolicy,
+	reflect.TypeOf(ForwardedChain{}):                      Forwarded,
+	reflect.TypeOf(ForwardedHeader{}):                     Forwarded,
+	reflect.TypeOf(FromHeader{}):                          From,
+	reflect.TypeOf(HostHeader{}):                          Host,
+	reflect.TypeOf(IfMatchHeader{}):                       IfMatch,
+	reflect.TypeOf(IfModifiedSinceHeader{}):               IfModifiedSince,
+	reflect.TypeOf(IfNoneMatchHeader{}):                   IfNoneMatch,
+	reflect.TypeOf(IfRangeHeader{}):                       IfRange,
+	reflect.TypeOf(IfUnmodifiedSinceHeader{}):             IfUnmodifiedSince,
+	reflect.TypeOf(KeepAliveHeader{}):                     KeepAlive,
+	reflect.TypeOf(LargeAllocationHeader{}):               LargeAllocation,
+	reflect.TypeOf(LastModifiedHeader{}):                  LastModified,
+	reflect.TypeOf(LinkHeader{}):                          Link,
+	reflect.TypeOf(LocationHeader{}):                      Location,
+	reflect.TypeOf(OriginHeader{}):                        Origin,
+	reflect.TypeOf(PragmaHeader{}):                        Pragma,
+	reflect.TypeOf(ProxyAuthenticateHeader{}):             ProxyAuthenticate,
+	reflect.TypeOf(ProxyAuthorizationHeader{}):            ProxyAuthorization,
+	reflect.TypeOf(PublicKeyPinsHeader{}):                 PublicKeyPins,
+	reflect.TypeOf(PublicKeyPinsReporyOnlyHeader{}):       PublicKeyPinsReportOnly,
+	reflect.TypeOf(RangeHeader{}):                         Range,
+	reflect.TypeOf(RefererHeader{}):                       Referer,
+	reflect.TypeOf(ReferrerPolicyHeader{}):                ReferrerPolicy,
+	reflect.TypeOf(ResponseCookie{}):                      SetCookie,
+	reflect.TypeOf(RetryAfterHeader{}):                    RetryAfter,
+	reflect.TypeOf(SaveDataHeader{}):                      SaveData,
+	reflect.TypeOf(SecFetchDestHeader{}):                  SecFetchDest,
+	reflect.TypeOf(SecFetchModeHeader{}):                  SecFetchMode,
+	reflect.TypeOf(SecFetchSiteHeader{}):                  SecFetchSite,
+	reflect.TypeOf(SecFetchUserHeader{}):                  SecFetchUser,
+	reflect.TypeOf(SecWebSocketAcceptHeader{}):            SecWebSocketAccept,
+	reflect.TypeOf(ServerHeader{}):                        Server,
+	reflect.TypeOf(ServerTimingHeader{}):                  ServerTiming,
+	reflect.TypeOf(SetCookie2Header{}):                    SetCookie2,
+	reflect.TypeOf(SetCookieHeader{}):                     SetCookie,
+	reflect.TypeOf(SourceMapHeader{}):                     SourceMap,
+	reflect.TypeOf(StrictTransportSecurityHeader{}):       StrictTransportSecurity,
+	reflect.TypeOf(TEHeader{}):                            TE,
+	reflect.TypeOf(TimingAllowOriginHeader{}):             TimingAllowOrigin,
+	reflect.TypeOf(TkHeader{}):                            Tk,
+	reflect.TypeOf(TrailerHeader{}):                       Trailer,
+	reflect.TypeOf(TransferEncodingHeader{}):              TransferEncoding,
+	reflect.TypeOf(UpgradeInsecureRequestsHeader{}):       UpgradeInsecureRequests,
+	reflect.TypeOf(UserAgentHeader{}):                     UserAgent,
+	reflect.TypeOf(VaryHeader{}):                          Vary,
+	reflect.TypeOf(ViaChain{}):                            Via,
+	reflect.TypeOf(ViaHeader{}):                           Via,
+	reflect.TypeOf(WWWAuthenticateHeader{}):               WWWAuthenticate,
+	reflect.TypeOf(XRealIPHeader{}):                       XRealIP,
+}
+
+// fieldValidator is implemented by the header types whose fields have a grammar that SafeString checks, beyond the
+// grammar of a field value.
+type fieldValidator interface {
+	validate() error
+}
+
+// HeaderName returns the field name of a header type, such as "Content-Type" for a ContentTypeHeader.
+func HeaderName(h fmt.Stringer) (string, bool) {
+	var t reflect.Type = reflect.TypeOf(h)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var name, ok = headerNames[t]
+	return name, ok
+}
+
+// SafeString returns the string representation of a header type when it is safe to send: a field value as defined
+// by RFC 9110, without CR, LF, NUL or other control characters that could end the field line and inject another,
+// and, for the header types whose fields are sent as they are, such as the product list of a ServerHeader or
+// UserAgentHeader, a value that matches the grammar of its header. Quoted-strings are escaped as the grammar allows,
+// and a *HeaderValueError is returned instead of a value that cannot be made safe.
+func SafeString(h fmt.Stringer) (string, error) {
+	var name, ok = HeaderName(h)
+	if !ok {
+		name = reflect.TypeOf(h).String()
+	}
+	if v, ok := h.(fieldValidator); ok {
+		if err := v.validate(); err != nil {
+			return "", err
+		}
+	}
+	var s string = h.String()
+	if !isFieldValue(s) {
+		return "", &HeaderValueError{Header: name, Value: s}
+	}
+	return s, nil
+}
+
+// SetHeader sets the header of a header type on a response, after checking it with SafeString. Set-Cookie and
+// Set-Cookie2 HTTP headers are added instead, as they cannot be combined into one field. Nothing is written when an
+// error is returned.
+func SetHeader(w http.ResponseWriter, h fmt.Stringer) error {
+	var name, ok = HeaderName(h)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrUnknownHeader, h)
+	}
+	var value, err = SafeString(h)
+	if err != nil {
+		return err
+	}
+	if name == SetCookie || name == SetCookie2 {
+		w.Header().Add(name, value)
+	} else {
+		w.Header().Set(name, value)
+	}
+	return nil
+}
+
+// validate reports whether the charset of a Accept-Charset HTTP header is a token.
+func (a AcceptCharsetHeader) validate() error {
+	if len(a.Charset) != 0 && !isToken(a.Charset) {
+		return &HeaderValueError{Header: AcceptCharset, Value: a.Charset}
+	}
+	return nil
+}
+
+// validate reports whether the content coding of a Accept-Encoding HTTP header is a token.
+func (a AcceptEncodingHeader) validate() error {
+	if len(a.Encoding) != 0 && !isToken(a.Encoding) {
+		return &HeaderValueError{Header: AcceptEncoding, Value: a.Encoding}
+	}
+	return nil
+}
+
+// validate reports whether the media type and charset of a Accept-Patch HTTP header are tokens.
+func (a AcceptPatchHeader) validate() error {
+	for _, value := range []string{a.MIMEType, a.MIMESubType, a.Charset} {
+		if len(value) != 0 && !isToken(value) {
+			return &HeaderValueError{Header: AcceptPatch, Value: value}
+		}
+	}
+	return nil
+}
+
+// validate reports whether the protocol-id of a Alt-Svc HTTP header is a token.
+func (a AltSvcHeader) validate() error {
+	if !a.Clear && !isToken(a.ProtocolID) {
+		return &HeaderValueError{Header: AltSvc, Value: a.ProtocolID}
+	}
+	return nil
+}
+
+// validate reports whether the scheme of a Authorization HTTP header is a token, and its credentials a token68 or a
+// list of auth-params.
+func (a AuthorizationHeader) validate() error {
+	if !isToken(a.Type) {
+		return &HeaderValueError{Header: Authorization, Value: a.Type}
+	}
+	if len(a.Credentials) != 0 && !isToken68(a.Credentials) && !isAuthParams(a.Credentials) {
+		return &HeaderValueError{Header: Authorization, Value: a.Credentials}
+	}
+	return nil
+}
+
+// validate reports whether the media type, boundary and charset of a Content-Type HTTP header are tokens, and the
+// boundary at most 70 characters long as RFC 2046 requires.
+func (c ContentTypeHeader) validate() error {
+	for _, value := range []string{c.MIMEType, c.MIMESubType, c.Boundary, c.Charset} {
+		if len(value) != 0 && !isToken(value) {
+			return &HeaderValueError{Header: ContentType, Value: value}
+		}
+	}
+	if len(c.Boundary) > 70 {
+		return &HeaderValueError{Header: ContentType, Value: c.Boundary}
+	}
+	return nil
+}
+
+// validate reports whether the algorithm of a Digest HTTP header is a token, and its value a token68, such as the
+// base64 or hex encoding of a digest.
+func (d DigestHeader) validate() error {
+	if !isToken(d.Algorithm) {
+		return &HeaderValueError{Header: Digest, Value: d.Algorithm}
+	}
+	if !isToken68(d.Value) {
+		return &HeaderValueError{Header: Digest, Value: d.Value}
+	}
+	return nil
+}
+
+// validate reports whether the report-uri of a Expect-CT HTTP header is a URI reference.
+func (e ExpectCTHeader) validate() error {
+	if _, err := url.Parse(e.ReportURI); err != nil {
+		return &HeaderValueError{Header: ExpectCT, Value: e.ReportURI}
+	}
+	return nil
+}
+
+// validate reports whether every allowlist of a Feature-Policy HTTP header is a list of keywords, "*" and origins.
+func (f FeaturePolicyHeader) validate() error {
+	var r reflect.Value = reflect.ValueOf(f)
+	for i, n := 0, r.NumField(); i < n; i++ {
+		if v := r.Field(i); v.Kind() == reflect.String && !isFeaturePolicyAllowlist(v.String()) {
+			return &HeaderValueError{Header: FeaturePolicy, Value: v.String()}
+		}
+	}
+	return nil
+}
+
+// validate reports whether every element of a Forwarded HTTP header is valid.
+func (c ForwardedChain) validate() error {
+	for _, f := range c.Elements {
+		if err := f.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate reports whether the proto of an element of a Forwarded HTTP header is a URI scheme.
+func (f ForwardedHeader) validate() error {
+	if len(f.Proto) != 0 && !isURIScheme(f.Proto) {
+		return &HeaderValueError{Header: Forwarded, Value: f.Proto}
+	}
+	return nil
+}
+
+// validate reports whether the scheme of a Proxy-Authenticate HTTP header is a token.
+func (p ProxyAuthenticateHeader) validate() error {
+	if len(p.Type) != 0 && !isToken(p.Type) {
+		return &HeaderValueError{Header: ProxyAuthenticate, Value: p.Type}
+	}
+	return nil
+}
+
+// validate reports whether the scheme of a Proxy-Authorization HTTP header is a token.
+func (p ProxyAuthorizationHeader) validate() error {
+	if len(p.Type) != 0 && !isToken(p.Type) {
+		return &HeaderValueError{Header: ProxyAuthorization, Value: p.Type}
+	}
+	return nil
+}
+
+// validate reports whether a Server HTTP header is a list of products and comments.
+func (s ServerHeader) validate() error {
+	if !isProductList(s.Server) {
+		return &HeaderValueError{Header: Server, Value: s.Server}
+	}
+	return nil
+}
+
+// validate reports whether a User-Agent HTTP header is a list of products and comments.
+func (u UserAgentHeader) validate() error {
+	if !isProductList(u.UserAgent) {
+		return &HeaderValueError{Header: UserAgent, Value: u.UserAgent}
+	}
+	return nil
+}
+
+// commentLength returns the length of the comment at the start of a string, or -1 when it is not closed.
+func commentLength(s string) int {
+	var depth int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// isAuthParams reports whether a string is a comma-separated list of auth-params as defined by RFC 9110, such as
+// `username="Mufasa", realm="example"`.
+func isAuthParams(s string) bool {
+	for _, param := range splitQuoted(s, ',') {
+		var name, value, ok = strings.Cut(param, "=")
+		if !ok || !isToken(strings.TrimRight(name, " \t")) {
+			return false
+		}
+		if value = strings.TrimLeft(value, " \t"); !isToken(value) {
+			if _, ok := unquoteString(value); !ok || !strings.HasPrefix(value, `"`) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isFeaturePolicyAllowlist reports whether a string is an allowlist of a Feature-Policy HTTP header: the keywords
+// none, self and src, with or without quotes, "*" and serialized origins, separated by whitespace.
+func isFeaturePolicyAllowlist(s string) bool {
+	for _, field := range strings.Fields(s) {
+		switch strings.ToLower(strings.Trim(field, "'")) {
+		case "*", "none", "self", "src":
+			continue
+		}
+		var u, err = url.Parse(field)
+		if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 || strings.ContainsAny(field, ";,'\"") {
+			return false
+		}
+	}
+	return true
+}
+
+// isFieldValue reports whether a string is a field value as defined by RFC 9110: visible characters, obs-text and
+// whitespace, without whitespace at either end.
+func isFieldValue(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < 0x20 && c != '\t') || c == 0x7f {
+			return false
+		}
+	}
+	return len(s) == len(strings.Trim(s, " \t"))
+}
+
+// isProductList reports whether a string is a list of products and comments as defined by RFC 9110, such as
+// "Mozilla/5.0 (X11; Linux x86_64) Gecko/20100101", which must start with a product.
+func isProductList(s string) bool {
+	var first bool = true
+	for len(s) != 0 {
+		var n int
+		if s[0] == '(' {
+			if n = commentLength(s); first || n == -1 {
+				return false
+			}
+			if _, ok := parseComment(s[:n]); !ok {
+				return false
+			}
+		} else {
+			if n = strings.IndexAny(s, " \t"); n == -1 {
+				n = len(s)
+			}
+			var product, version, ok = strings.Cut(s[:n], "/")
+			if !isToken(product) || (ok && !isToken(version)) {
+				return false
+			}
+		}
+		var rest string = strings.TrimLeft(s[n:], " \t")
+		if len(rest) != 0 && len(rest) == len(s[n:]) {
+			return false
+		}
+		s = rest
+		first = false
+	}
+	return !first
+}
+
+// isToken68 reports whether a string is a token68 as defined by RFC 9110, the syntax of base64 encoded credentials.
+func isToken68(s string) bool {
+	var value string = strings.TrimRight(s, "=")
+	if len(value) == 0 {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; (c|0x20 < 'a' || c|0x20 > 'z') && (c < '0' || c > '9') && strings.IndexByte("-._~+/", c) == -1 {
+			return false
+		}
+	}
+	return true
+}
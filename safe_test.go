@@ -0,0 +1,86 @@
+package w3g_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestSafeString(t *testing.T) {
+	for _, test := range []struct {
+		h    interface{ String() string }
+		want string
+		ok   bool
+	}{
+		{w3g.ServerHeader{Server: "Apache/2.4.1 (Unix) mod_ssl/2.4.1"}, "Apache/2.4.1 (Unix) mod_ssl/2.4.1", true},
+		{w3g.UserAgentHeader{UserAgent: "Mozilla/5.0 (X11; Linux x86_64; (nested \\) paren)) Gecko/20100101"}, "Mozilla/5.0 (X11; Linux x86_64; (nested \\) paren)) Gecko/20100101", true},
+		{w3g.ExpectCTHeader{Enforce: true, ReportURI: `https://example.com/report?a="b"`}, `enforce, report-uri="https://example.com/report?a=\"b\""`, true},
+		{w3g.ContentDispositionHeader{Attachment: true, FileName: "a\r\nSet-Cookie: x=y"}, `attachment; filename="a__Set-Cookie: x=y"; filename*=UTF-8''a%0D%0ASet-Cookie%3A%20x%3Dy`, true},
+		{w3g.ServerHeader{Server: "nginx\r\nSet-Cookie: session=attacker"}, "", false},
+		{w3g.ServerHeader{Server: "(comment first) nginx"}, "", false},
+		{w3g.ServerHeader{Server: "nginx/1.2(unclosed"}, "", false},
+		{w3g.UserAgentHeader{UserAgent: "curl/8.0 (a\nb)"}, "", false},
+		{w3g.UserAgentHeader{UserAgent: "curl/8.0\x00"}, "", false},
+		{w3g.ExpectCTHeader{ReportURI: "https://example.com/\r\nX-Injected: 1"}, "", false},
+		{w3g.AuthorizationHeader{Type: "Bearer\r\nX-Injected:", Credentials: "token"}, "", false},
+		{w3g.HostHeader{Host: "example.com\r\nX-Injected: 1"}, "", false},
+		{w3g.AcceptCharsetHeader{Charset: "utf-8"}, "utf-8", true},
+		{w3g.AcceptCharsetHeader{Charset: "utf-8, *;q=0"}, "", false},
+		{w3g.AcceptEncodingHeader{Encoding: "gzip, br"}, "", false},
+		{w3g.AcceptPatchHeader{MIMEType: "text", MIMESubType: "plain", Charset: "utf-8;x=y"}, "", false},
+		{w3g.AltSvcHeader{ProtocolID: "h3", AltAuthority: ":443", MaxAge: 0}, `h3=":443"`, true},
+		{w3g.AltSvcHeader{ProtocolID: `h3=":443", h2`, AltAuthority: ":443"}, "", false},
+		{w3g.AuthorizationHeader{Type: "Basic", Credentials: "QWxhZGRpbjpvcGVuIHNlc2FtZQ=="}, "Basic QWxhZGRpbjpvcGVuIHNlc2FtZQ==", true},
+		{w3g.AuthorizationHeader{Type: "Digest", Credentials: `username="Mufasa", realm="a, b"`}, `Digest username="Mufasa", realm="a, b"`, true},
+		{w3g.AuthorizationHeader{Type: "Bearer", Credentials: "token, Basic other"}, "", false},
+		{w3g.ContentTypeHeader{MIMEType: "multipart", MIMESubType: "form-data", Boundary: "abc123"}, "multipart/form-data; boundary=abc123", true},
+		{w3g.ContentTypeHeader{MIMEType: "multipart", MIMESubType: "form-data", Boundary: "a; charset=evil"}, "", false},
+		{w3g.ContentTypeHeader{MIMEType: "text", MIMESubType: "html", Charset: "utf-8, text/plain"}, "", false},
+		{w3g.DigestHeader{Algorithm: "sha-256", Value: "X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE="}, "sha-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=", true},
+		{w3g.DigestHeader{Algorithm: "sha-256=x, md5", Value: "AAAA"}, "", false},
+		{w3g.DigestHeader{Algorithm: "sha-256", Value: "AAAA, md5=BBBB"}, "", false},
+		{w3g.FeaturePolicyHeader{Camera: "self https://example.com"}, "camera 'self' https://example.com", true},
+		{w3g.FeaturePolicyHeader{Camera: "'none'; microphone *"}, "", false},
+		{w3g.ForwardedHeader{For: "unknown", Proto: "https;by=x"}, "", false},
+		{w3g.ForwardedChain{Elements: []w3g.ForwardedHeader{{For: "unknown", Proto: "1https"}}}, "", false},
+	} {
+		var got, err = w3g.SafeString(test.h)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("SafeString(%#v) = %q, %v", test.h, got, err)
+		}
+		var invalid *w3g.HeaderValueError
+		if err != nil && !errors.As(err, &invalid) {
+			t.Errorf("SafeString(%#v) error = %v, want a *HeaderValueError", test.h, err)
+		}
+	}
+}
+
+func TestSetHeader(t *testing.T) {
+	var w *httptest.ResponseRecorder = httptest.NewRecorder()
+	if err := w3g.SetHeader(w, w3g.ServerHeader{Server: "w3g/1.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w3g.SetHeader(w, &w3g.ViaChain{Hops: []w3g.ViaHeader{{ProtocolVersion: "1.1", Pseudonym: "gateway"}}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b"} {
+		if err := w3g.SetHeader(w, w3g.SetCookieHeader{Cookie: http.Cookie{Name: name, Value: "1"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w3g.SetHeader(w, w3g.UserAgentHeader{UserAgent: "x\r\nInjected: 1"}); err == nil {
+		t.Error("SetHeader() with CRLF did not return an error")
+	}
+	if err := w3g.SetHeader(w, w3g.ConnectionClose); !errors.Is(err, w3g.ErrUnknownHeader) {
+		t.Errorf("SetHeader() of a ConnectionValue = %v, want ErrUnknownHeader", err)
+	}
+	if w.Header().Get(w3g.Server) != "w3g/1.0" || w.Header().Get(w3g.Via) != "1.1 gateway" || len(w.Header().Values(w3g.SetCookie)) != 2 || len(w.Header().Values(w3g.UserAgent)) != 0 {
+		t.Errorf("headers = %v", w.Header())
+	}
+	if name, ok := w3g.HeaderName(w3g.ContentTypeHeader{}); name != w3g.ContentType || !ok {
+		t.Errorf("HeaderName() = %q, %v", name, ok)
+	}
+}
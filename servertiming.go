@@ -0,0 +1,225 @@
+package w3g
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serverTimingKey is the context key of a ServerTimingCollector.
+type serverTimingKey struct{}
+
+// ServerTimingMetric is a struct to hold a single metric of a Server-Timing HTTP header. A zero Duration is not sent.
+type ServerTimingMetric struct {
+	Description string        `json:"description"`
+	Duration    time.Duration `json:"duration"`
+	Name        string        `json:"name"`
+}
+
+// ServerTimingCollector is a struct to collect the ServerTimingMetrics of a HTTP request. Its methods may be called
+// concurrently and on a nil ServerTimingCollector, which discards every metric.
+type ServerTimingCollector struct {
+	metrics []ServerTimingMetric
+	mu      sync.Mutex
+}
+
+// ServerTimingPolicy is a struct to configure a middleware that collects ServerTimingMetrics recorded by handlers and
+// sends them as a Server-Timing HTTP header. Metrics are only sent to same-origin requests and to cross-origin requests
+// whose origin the TimingAllowOrigin HTTP header allows, so that timing information is not leaked to other origins.
+type ServerTimingPolicy struct {
+	TimingAllowOrigin TimingAllowOriginHeader `json:"timing_allow_origin"`
+}
+
+// serverTimingWriter is a http.ResponseWriter that adds a Server-Timing HTTP header before the response headers are sent.
+type serverTimingWriter struct {
+	http.ResponseWriter
+	collector   *ServerTimingCollector
+	sent        int
+	wroteHeader bool
+}
+
+// ParseServerTiming parses a Server-Timing HTTP header value. Only the first dur and desc parameters of a metric are
+// used and unknown parameters are ignored.
+func ParseServerTiming(s string) (ServerTimingHeader, error) {
+	var h ServerTimingHeader
+	for _, metric := range splitQuoted(s, ',') {
+		if len(metric) == 0 {
+			continue
+		}
+		var params []string = splitQuoted(metric, ';')
+		if !isToken(params[0]) {
+			return ServerTimingHeader{}, &HeaderValueError{Header: ServerTiming, Value: s}
+		}
+		var m ServerTimingMetric = ServerTimingMetric{Name: params[0]}
+		var dur, desc bool
+		for _, param := range params[1:] {
+			var name, value, _ = strings.Cut(param, "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+			var v, ok = unquoteString(strings.TrimSpace(value))
+			if !isToken(name) || (len(value) != 0 && !ok) {
+				return ServerTimingHeader{}, &HeaderValueError{Header: ServerTiming, Value: s}
+			}
+			switch {
+			case name == "dur" && !dur:
+				dur = true
+				if ms, err := strconv.ParseFloat(v, 64); err == nil {
+					m.Duration = time.Duration(ms * float64(time.Millisecond))
+				}
+			case name == "desc" && !desc:
+				desc = true
+				m.Description = v
+			}
+		}
+		h.Metrics = append(h.Metrics, m)
+	}
+	return h, nil
+}
+
+// ServerTimingFromContext returns the ServerTimingCollector of a request context, or nil when the request is not
+// handled by a ServerTimingPolicy.
+func ServerTimingFromContext(ctx context.Context) *ServerTimingCollector {
+	var c, _ = ctx.Value(serverTimingKey{}).(*ServerTimingCollector)
+	return c
+}
+
+// String returns a string representation of a ServerTimingMetric.
+func (m ServerTimingMetric) String() string {
+	var s string = m.Name
+	if m.Duration != 0 {
+		var ms float64 = float64(m.Duration.Round(time.Microsecond)) / float64(time.Millisecond)
+		s += ";dur=" + strconv.FormatFloat(ms, 'f', -1, 64)
+	}
+	if len(m.Description) != 0 {
+		s += ";desc=" + tokenOrQuoted(m.Description)
+	}
+	return s
+}
+
+// Add records a ServerTimingMetric.
+func (c *ServerTimingCollector) Add(m ServerTimingMetric) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.metrics = append(c.metrics, m)
+	c.mu.Unlock()
+}
+
+// Metrics returns the ServerTimingMetrics recorded so far.
+func (c *ServerTimingCollector) Metrics() []ServerTimingMetric {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ServerTimingMetric(nil), c.metrics...)
+}
+
+// Start starts timing a named span and returns a function that records it as a ServerTimingMetric, for example
+// defer ServerTimingFromContext(ctx).Start("db", "Database")().
+func (c *ServerTimingCollector) Start(name, description string) func() {
+	var start time.Time = time.Now()
+	return func() {
+		c.Add(ServerTimingMetric{Description: description, Duration: time.Since(start), Name: name})
+	}
+}
+
+// Allow reports whether a ServerTimingPolicy permits sending timing information in the response to a HTTP request.
+func (p ServerTimingPolicy) Allow(r *http.Request) bool {
+	var origin string = r.Header.Get(Origin)
+	if len(origin) == 0 {
+		return true
+	}
+	var o, err = ParseOrigin(origin)
+	if err == nil && o.SameOrigin(OriginOf(requestURL(r))) {
+		return true
+	}
+	return p.TimingAllowOrigin.Allows(origin)
+}
+
+// Handler returns a http.Handler that adds a ServerTimingCollector to the request context before calling next.
+// Metrics recorded before the response headers are written are sent as a Server-Timing HTTP header, and metrics
+// recorded later, such as while a response is streamed, are sent as a Server-Timing trailer.
+func (p ServerTimingPolicy) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var c *ServerTimingCollector = &ServerTimingCollector{}
+		r = r.WithContext(context.WithValue(r.Context(), serverTimingKey{}, c))
+		if !p.Allow(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(p.TimingAllowOrigin.Origins) != 0 {
+			w.Header().Set(TimingAllowOrigin, p.TimingAllowOrigin.String())
+		}
+		var tw *serverTimingWriter = &serverTimingWriter{ResponseWriter: w, collector: c}
+		next.ServeHTTP(tw, r)
+		if !tw.wroteHeader {
+			tw.WriteHeader(http.StatusOK)
+			return
+		}
+		if metrics := c.Metrics(); len(metrics) > tw.sent {
+			w.Header().Set(http.TrailerPrefix+ServerTiming, ServerTimingHeader{Metrics: metrics[tw.sent:]}.String())
+		}
+	})
+}
+
+// Flush sends any buffered data to the client.
+func (w *serverTimingWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use by http.ResponseController.
+func (w *serverTimingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Write writes the response headers, when they have not been written yet, and then data.
+func (w *serverTimingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteHeader adds the metrics recorded so far as a Server-Timing HTTP header and writes the response headers.
+func (w *serverTimingWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.wroteHeader = true
+	var metrics []ServerTimingMetric = w.collector.Metrics()
+	if len(metrics) != 0 {
+		w.Header().Set(ServerTiming, ServerTimingHeader{Metrics: metrics}.String())
+	}
+	w.sent = len(metrics)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// requestURL returns the URL of a HTTP request including the scheme and host that a server receives separately.
+func requestURL(r *http.Request) *url.URL {
+	var u url.URL = *r.URL
+	if len(u.Host) == 0 {
+		u.Host = r.Host
+	}
+	if len(u.Scheme) == 0 {
+		u.Scheme = "http"
+		if r.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+	return &u
+}
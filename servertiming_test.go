@@ -0,0 +1,78 @@
+package w3g_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gellel/w3g"
+)
+
+func TestParseServerTiming(t *testing.T) {
+	var s string = `cache;desc="Cache Read";dur=23.2, db;dur=53;dur=1, app;DESC=render;foo=bar, missedCache`
+	var h, err = w3g.ParseServerTiming(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []w3g.ServerTimingMetric = []w3g.ServerTimingMetric{
+		{Description: "Cache Read", Duration: 23200 * time.Microsecond, Name: "cache"},
+		{Duration: 53 * time.Millisecond, Name: "db"},
+		{Description: "render", Name: "app"},
+		{Name: "missedCache"},
+	}
+	if !reflect.DeepEqual(h.Metrics, want) {
+		t.Errorf("ParseServerTiming(%q) = %+v, want %+v", s, h.Metrics, want)
+	}
+	if got, want := h.String(), `cache;dur=23.2;desc="Cache Read", db;dur=53, app;desc=render, missedCache`; got != want {
+		t.Errorf("ServerTimingHeader.String() = %q, want %q", got, want)
+	}
+	for _, invalid := range []string{`"quoted"`, `db;desc="unterminated`, `db;dur=1 2`} {
+		if _, err = w3g.ParseServerTiming(invalid); err == nil {
+			t.Errorf("ParseServerTiming(%q) succeeded, want error", invalid)
+		}
+	}
+}
+
+func TestServerTimingPolicy(t *testing.T) {
+	var u, _ = url.Parse("https://partner.example")
+	var policy w3g.ServerTimingPolicy = w3g.ServerTimingPolicy{TimingAllowOrigin: w3g.TimingAllowOriginHeader{Origins: []url.URL{*u}}}
+	var handler http.Handler = policy.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var timing *w3g.ServerTimingCollector = w3g.ServerTimingFromContext(r.Context())
+		timing.Add(w3g.ServerTimingMetric{Duration: 5 * time.Millisecond, Name: "db"})
+		w.Write([]byte("streamed"))
+		timing.Add(w3g.ServerTimingMetric{Description: "Render", Name: "render"})
+	}))
+	var tests = []struct {
+		origin  string
+		header  string
+		trailer string
+	}{
+		{"", "db;dur=5", "render;desc=Render"},
+		{"https://partner.example", "db;dur=5", "render;desc=Render"},
+		{"http://example.com", "db;dur=5", "render;desc=Render"},
+		{"https://attacker.example", "", ""},
+	}
+	for _, test := range tests {
+		var r *http.Request = httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+		if len(test.origin) != 0 {
+			r.Header.Set(w3g.Origin, test.origin)
+		}
+		var w *httptest.ResponseRecorder = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		var res *http.Response = w.Result()
+		if got := res.Header.Get(w3g.ServerTiming); got != test.header {
+			t.Errorf("origin %q: Server-Timing header = %q, want %q", test.origin, got, test.header)
+		}
+		if got := res.Trailer.Get(w3g.ServerTiming); got != test.trailer {
+			t.Errorf("origin %q: Server-Timing trailer = %q, want %q", test.origin, got, test.trailer)
+		}
+	}
+	if timing := w3g.ServerTimingFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()); timing != nil {
+		t.Errorf("ServerTimingFromContext() = %v, want nil", timing)
+	} else {
+		timing.Start("ignored", "")()
+	}
+}
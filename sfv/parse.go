@@ -0,0 +1,439 @@
+package sfv
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// parser holds the state of parsing a single Structured Field Value.
+type parser struct {
+	i int
+	s string
+}
+
+// ParseDictionary parses a Structured Field Dictionary. Multiple field lines should be joined with ", " first.
+func ParseDictionary(s string) (Dictionary, error) {
+	var p *parser = &parser{s: s}
+	p.discardSP()
+	var d Dictionary = Dictionary{}
+	for !p.eof() {
+		var key, err = p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		var member Member
+		if p.peek() == '=' {
+			p.i++
+			if member, err = p.parseMember(); err != nil {
+				return nil, err
+			}
+		} else {
+			var params, err = p.parseParams()
+			if err != nil {
+				return nil, err
+			}
+			member = Item{Value: true, Params: params}
+		}
+		d.Set(key, member)
+		if err = p.parseListSeparator(); err != nil {
+			return nil, err
+		}
+	}
+	return d, p.end()
+}
+
+// ParseItem parses a Structured Field Item.
+func ParseItem(s string) (Item, error) {
+	var p *parser = &parser{s: s}
+	p.discardSP()
+	var item, err = p.parseItem()
+	if err != nil {
+		return Item{}, err
+	}
+	return item, p.end()
+}
+
+// ParseList parses a Structured Field List. Multiple field lines should be joined with ", " first.
+func ParseList(s string) (List, error) {
+	var p *parser = &parser{s: s}
+	p.discardSP()
+	var l List = List{}
+	for !p.eof() {
+		var member, err = p.parseMember()
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, member)
+		if err = p.parseListSeparator(); err != nil {
+			return nil, err
+		}
+	}
+	return l, p.end()
+}
+
+// discardOWS discards optional whitespace.
+func (p *parser) discardOWS() {
+	for !p.eof() && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+// discardSP discards spaces.
+func (p *parser) discardSP() {
+	for !p.eof() && p.s[p.i] == ' ' {
+		p.i++
+	}
+}
+
+// end discards trailing spaces and fails when any input remains.
+func (p *parser) end() error {
+	p.discardSP()
+	if !p.eof() {
+		return p.fail("unexpected trailing characters")
+	}
+	return nil
+}
+
+// eof reports whether the whole input has been consumed.
+func (p *parser) eof() bool {
+	return p.i >= len(p.s)
+}
+
+// fail returns a ParseError at the current offset.
+func (p *parser) fail(reason string) error {
+	return &ParseError{Offset: p.i, Reason: reason}
+}
+
+// peek returns the next character, or 0 at the end of the input.
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+// parseBareItem parses a bare item.
+func (p *parser) parseBareItem() (interface{}, error) {
+	var c byte = p.peek()
+	switch {
+	case c == '-' || isDigit(c):
+		return p.parseNumber()
+	case c == '"':
+		return p.parseString()
+	case c == '*' || isAlpha(c):
+		return p.parseToken()
+	case c == ':':
+		return p.parseByteSequence()
+	case c == '?':
+		return p.parseBoolean()
+	case c == '@':
+		return p.parseDate()
+	case c == '%':
+		return p.parseDisplayString()
+	}
+	return nil, p.fail("unexpected start of bare item")
+}
+
+// parseBoolean parses a Boolean.
+func (p *parser) parseBoolean() (bool, error) {
+	p.i++
+	switch p.peek() {
+	case '1':
+		p.i++
+		return true, nil
+	case '0':
+		p.i++
+		return false, nil
+	}
+	return false, p.fail("invalid boolean")
+}
+
+// parseByteSequence parses a Byte Sequence.
+func (p *parser) parseByteSequence() ([]byte, error) {
+	p.i++
+	var end int = strings.IndexByte(p.s[p.i:], ':')
+	if end == -1 {
+		return nil, p.fail("unterminated byte sequence")
+	}
+	var encoded string = p.s[p.i : p.i+end]
+	for i := 0; i < len(encoded); i++ {
+		if c := encoded[i]; !isAlpha(c) && !isDigit(c) && c != '+' && c != '/' && c != '=' {
+			return nil, p.fail("invalid byte sequence character")
+		}
+	}
+	var decoded, err = base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		if decoded, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "=")); err != nil {
+			return nil, p.fail("invalid base64")
+		}
+	}
+	p.i += end + 1
+	return decoded, nil
+}
+
+// parseDate parses a Date.
+func (p *parser) parseDate() (time.Time, error) {
+	p.i++
+	var v, err = p.parseNumber()
+	if err != nil {
+		return time.Time{}, err
+	}
+	var seconds, ok = v.(int64)
+	if !ok {
+		return time.Time{}, p.fail("date is not an integer")
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// parseDisplayString parses a Display String.
+func (p *parser) parseDisplayString() (DisplayString, error) {
+	p.i++
+	if p.peek() != '"' {
+		return "", p.fail("invalid display string")
+	}
+	p.i++
+	var b []byte
+	for !p.eof() {
+		var c byte = p.s[p.i]
+		p.i++
+		switch {
+		case c == '%':
+			if p.i+2 > len(p.s) || !isLowerHex(p.s[p.i]) || !isLowerHex(p.s[p.i+1]) {
+				return "", p.fail("invalid percent encoding in display string")
+			}
+			var n, _ = strconv.ParseUint(p.s[p.i:p.i+2], 16, 8)
+			b = append(b, byte(n))
+			p.i += 2
+		case c == '"':
+			if !utf8.Valid(b) {
+				return "", p.fail("display string is not valid UTF-8")
+			}
+			return DisplayString(b), nil
+		case c < 0x20 || c > 0x7e:
+			return "", p.fail("invalid display string character")
+		default:
+			b = append(b, c)
+		}
+	}
+	return "", p.fail("unterminated display string")
+}
+
+// parseInnerList parses an Inner List.
+func (p *parser) parseInnerList() (InnerList, error) {
+	p.i++
+	var l InnerList = InnerList{Items: []Item{}}
+	for !p.eof() {
+		p.discardSP()
+		if p.peek() == ')' {
+			p.i++
+			var params, err = p.parseParams()
+			if err != nil {
+				return InnerList{}, err
+			}
+			l.Params = params
+			return l, nil
+		}
+		var item, err = p.parseItem()
+		if err != nil {
+			return InnerList{}, err
+		}
+		l.Items = append(l.Items, item)
+		if c := p.peek(); c != ' ' && c != ')' {
+			return InnerList{}, p.fail("invalid inner list separator")
+		}
+	}
+	return InnerList{}, p.fail("unterminated inner list")
+}
+
+// parseItem parses an Item.
+func (p *parser) parseItem() (Item, error) {
+	var v, err = p.parseBareItem()
+	if err != nil {
+		return Item{}, err
+	}
+	var params Params
+	if params, err = p.parseParams(); err != nil {
+		return Item{}, err
+	}
+	return Item{Value: v, Params: params}, nil
+}
+
+// parseKey parses a key of a Dictionary or Params.
+func (p *parser) parseKey() (string, error) {
+	if c := p.peek(); c != '*' && !isLowerAlpha(c) {
+		return "", p.fail("invalid key")
+	}
+	var start int = p.i
+	for !p.eof() && isKeyChar(p.s[p.i]) {
+		p.i++
+	}
+	return p.s[start:p.i], nil
+}
+
+// parseListSeparator parses the separator following a List or Dictionary member.
+func (p *parser) parseListSeparator() error {
+	p.discardOWS()
+	if p.eof() {
+		return nil
+	}
+	if p.s[p.i] != ',' {
+		return p.fail("expected comma")
+	}
+	p.i++
+	p.discardOWS()
+	if p.eof() {
+		return p.fail("trailing comma")
+	}
+	return nil
+}
+
+// parseMember parses an Item or an Inner List.
+func (p *parser) parseMember() (Member, error) {
+	if p.peek() == '(' {
+		return p.parseInnerList()
+	}
+	return p.parseItem()
+}
+
+// parseNumber parses an Integer or a Decimal.
+func (p *parser) parseNumber() (interface{}, error) {
+	var start int = p.i
+	if p.peek() == '-' {
+		p.i++
+	}
+	if !isDigit(p.peek()) {
+		return nil, p.fail("invalid number")
+	}
+	var decimal bool
+	var digits, point int = 0, -1
+	for !p.eof() {
+		var c byte = p.s[p.i]
+		if isDigit(c) {
+			digits++
+		} else if c == '.' && !decimal {
+			if digits > 12 {
+				return nil, p.fail("decimal integer component too long")
+			}
+			decimal = true
+			point = digits
+		} else {
+			break
+		}
+		p.i++
+		if !decimal && digits > 15 {
+			return nil, p.fail("integer too long")
+		}
+		if decimal && digits > 15 {
+			return nil, p.fail("decimal too long")
+		}
+	}
+	var number string = p.s[start:p.i]
+	if !decimal {
+		var n, err = strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			return nil, p.fail("invalid integer")
+		}
+		return n, nil
+	}
+	if fraction := digits - point; fraction == 0 || fraction > 3 {
+		return nil, p.fail("invalid decimal fraction")
+	}
+	var f, err = strconv.ParseFloat(number, 64)
+	if err != nil {
+		return nil, p.fail("invalid decimal")
+	}
+	return f, nil
+}
+
+// parseParams parses the Params following an Item or Inner List.
+func (p *parser) parseParams() (Params, error) {
+	var params Params = Params{}
+	for p.peek() == ';' {
+		p.i++
+		p.discardSP()
+		var key, err = p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		var value interface{} = true
+		if p.peek() == '=' {
+			p.i++
+			if value, err = p.parseBareItem(); err != nil {
+				return nil, err
+			}
+		}
+		params.Set(key, value)
+	}
+	return params, nil
+}
+
+// parseString parses a String.
+func (p *parser) parseString() (string, error) {
+	p.i++
+	var b strings.Builder
+	for !p.eof() {
+		var c byte = p.s[p.i]
+		p.i++
+		switch {
+		case c == '\\':
+			if p.eof() || (p.s[p.i] != '"' && p.s[p.i] != '\\') {
+				return "", p.fail("invalid string escape")
+			}
+			b.WriteByte(p.s[p.i])
+			p.i++
+		case c == '"':
+			return b.String(), nil
+		case c < 0x20 || c > 0x7e:
+			return "", p.fail("invalid string character")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.fail("unterminated string")
+}
+
+// parseToken parses a Token.
+func (p *parser) parseToken() (Token, error) {
+	var start int = p.i
+	p.i++
+	for !p.eof() && isTokenChar(p.s[p.i]) {
+		p.i++
+	}
+	return Token(p.s[start:p.i]), nil
+}
+
+// isAlpha reports whether a character is an ASCII letter.
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isDigit reports whether a character is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isKeyChar reports whether a character may appear in a key.
+func isKeyChar(c byte) bool {
+	return isLowerAlpha(c) || isDigit(c) || c == '_' || c == '-' || c == '.' || c == '*'
+}
+
+// isLowerAlpha reports whether a character is a lower case ASCII letter.
+func isLowerAlpha(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+// isLowerHex reports whether a character is a lower case hexadecimal digit.
+func isLowerHex(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f')
+}
+
+// isTokenChar reports whether a character may appear in a Token after its first character.
+func isTokenChar(c byte) bool {
+	if isAlpha(c) || isDigit(c) {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~:/", c) != -1
+}
@@ -0,0 +1,273 @@
+package sfv
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxInteger is the largest magnitude of an Integer.
+const maxInteger int64 = 999999999999999
+
+// Serialize returns the serialisation of a Dictionary.
+func (d Dictionary) Serialize() (string, error) {
+	var b strings.Builder
+	for i, m := range d {
+		if i != 0 {
+			b.WriteString(", ")
+		}
+		if err := serializeKey(&b, m.Key); err != nil {
+			return "", err
+		}
+		if item, ok := m.Value.(Item); ok && item.Value == true {
+			if err := serializeParams(&b, item.Params); err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte('=')
+		if err := serializeMember(&b, m.Value); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// String returns the serialisation of a Dictionary, or an empty string when it cannot be serialised.
+func (d Dictionary) String() string {
+	var s, _ = d.Serialize()
+	return s
+}
+
+// Serialize returns the serialisation of an InnerList.
+func (l InnerList) Serialize() (string, error) {
+	var b strings.Builder
+	if err := serializeInnerList(&b, l); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// String returns the serialisation of an InnerList, or an empty string when it cannot be serialised.
+func (l InnerList) String() string {
+	var s, _ = l.Serialize()
+	return s
+}
+
+// Serialize returns the serialisation of an Item.
+func (i Item) Serialize() (string, error) {
+	var b strings.Builder
+	if err := serializeItem(&b, i); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// String returns the serialisation of an Item, or an empty string when it cannot be serialised.
+func (i Item) String() string {
+	var s, _ = i.Serialize()
+	return s
+}
+
+// Serialize returns the serialisation of a List.
+func (l List) Serialize() (string, error) {
+	var b strings.Builder
+	for i, m := range l {
+		if i != 0 {
+			b.WriteString(", ")
+		}
+		if err := serializeMember(&b, m); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// String returns the serialisation of a List, or an empty string when it cannot be serialised.
+func (l List) String() string {
+	var s, _ = l.Serialize()
+	return s
+}
+
+// invalid returns an error wrapping ErrInvalidValue.
+func invalid(format string, v ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidValue, fmt.Sprintf(format, v...))
+}
+
+// serializeBareItem serialises a bare item value.
+func serializeBareItem(b *strings.Builder, v interface{}) error {
+	var value, ok = normalizeBareItem(v)
+	if !ok {
+		return invalid("unsupported bare item type %T", v)
+	}
+	switch x := value.(type) {
+	case int64:
+		return serializeInteger(b, x)
+	case float64:
+		return serializeDecimal(b, x)
+	case string:
+		return serializeString(b, x)
+	case Token:
+		return serializeToken(b, x)
+	case []byte:
+		b.WriteByte(':')
+		b.WriteString(base64.StdEncoding.EncodeToString(x))
+		b.WriteByte(':')
+	case bool:
+		if x {
+			b.WriteString("?1")
+		} else {
+			b.WriteString("?0")
+		}
+	case time.Time:
+		b.WriteByte('@')
+		return serializeInteger(b, x.Unix())
+	case DisplayString:
+		serializeDisplayString(b, x)
+	}
+	return nil
+}
+
+// serializeDecimal serialises a Decimal, rounding it to three decimal places.
+func serializeDecimal(b *strings.Builder, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return invalid("decimal %v", f)
+	}
+	var rounded float64 = math.RoundToEven(f*1000) / 1000
+	if math.Abs(rounded) >= 1e12 {
+		return invalid("decimal %v out of range", f)
+	}
+	var s string = strconv.FormatFloat(rounded, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	if s == "-0.0" {
+		s = "0.0"
+	}
+	b.WriteString(s)
+	return nil
+}
+
+// serializeDisplayString serialises a Display String, percent-encoding every byte that is not printable ASCII.
+func serializeDisplayString(b *strings.Builder, s DisplayString) {
+	b.WriteString("%\"")
+	for i := 0; i < len(s); i++ {
+		var c byte = s[i]
+		if c == '%' || c == '"' || c < 0x20 || c > 0x7e {
+			fmt.Fprintf(b, "%%%02x", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+}
+
+// serializeInnerList serialises an Inner List.
+func serializeInnerList(b *strings.Builder, l InnerList) error {
+	b.WriteByte('(')
+	for i, item := range l.Items {
+		if i != 0 {
+			b.WriteByte(' ')
+		}
+		if err := serializeItem(b, item); err != nil {
+			return err
+		}
+	}
+	b.WriteByte(')')
+	return serializeParams(b, l.Params)
+}
+
+// serializeInteger serialises an Integer.
+func serializeInteger(b *strings.Builder, n int64) error {
+	if n > maxInteger || n < -maxInteger {
+		return invalid("integer %d out of range", n)
+	}
+	b.WriteString(strconv.FormatInt(n, 10))
+	return nil
+}
+
+// serializeItem serialises an Item.
+func serializeItem(b *strings.Builder, i Item) error {
+	if err := serializeBareItem(b, i.Value); err != nil {
+		return err
+	}
+	return serializeParams(b, i.Params)
+}
+
+// serializeKey serialises a key of a Dictionary or Params.
+func serializeKey(b *strings.Builder, key string) error {
+	if len(key) == 0 || (key[0] != '*' && !isLowerAlpha(key[0])) {
+		return invalid("key %q", key)
+	}
+	for i := 1; i < len(key); i++ {
+		if !isKeyChar(key[i]) {
+			return invalid("key %q", key)
+		}
+	}
+	b.WriteString(key)
+	return nil
+}
+
+// serializeMember serialises an Item or an Inner List.
+func serializeMember(b *strings.Builder, m Member) error {
+	switch x := m.(type) {
+	case Item:
+		return serializeItem(b, x)
+	case InnerList:
+		return serializeInnerList(b, x)
+	}
+	return invalid("unsupported member type %T", m)
+}
+
+// serializeParams serialises Params, omitting the value of parameters that are true.
+func serializeParams(b *strings.Builder, params Params) error {
+	for _, param := range params {
+		b.WriteByte(';')
+		if err := serializeKey(b, param.Key); err != nil {
+			return err
+		}
+		if param.Value == true {
+			continue
+		}
+		b.WriteByte('=')
+		if err := serializeBareItem(b, param.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serializeString serialises a String, which may only contain printable ASCII characters.
+func serializeString(b *strings.Builder, s string) error {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return invalid("string %q", s)
+		}
+	}
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+	return nil
+}
+
+// serializeToken serialises a Token.
+func serializeToken(b *strings.Builder, t Token) error {
+	if len(t) == 0 || (t[0] != '*' && !isAlpha(t[0])) {
+		return invalid("token %q", t)
+	}
+	for i := 1; i < len(t); i++ {
+		if !isTokenChar(t[i]) {
+			return invalid("token %q", t)
+		}
+	}
+	b.WriteString(string(t))
+	return nil
+}
@@ -0,0 +1,141 @@
+// Package sfv implements Structured Field Values for HTTP as defined by RFC 9651, which obsoletes RFC 8941.
+//
+// Bare item values are represented by Go types: int64 for Integers, float64 for Decimals, string for Strings,
+// Token for Tokens, []byte for Byte Sequences, bool for Booleans, time.Time for Dates and DisplayString for
+// Display Strings.
+package sfv
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidValue is returned when a value cannot be serialised as a Structured Field Value.
+var ErrInvalidValue = errors.New("sfv: invalid value")
+
+// DisplayString is a Display String bare item, a string of Unicode text.
+type DisplayString string
+
+// Token is a Token bare item, such as the values of the Sec-Fetch-Site HTTP header.
+type Token string
+
+// Item is a Structured Field Item: a bare item value and its Params.
+type Item struct {
+	Params Params      `json:"params"`
+	Value  interface{} `json:"value"`
+}
+
+// InnerList is a Structured Field Inner List: a list of Items and the Params of the list.
+type InnerList struct {
+	Items  []Item `json:"items"`
+	Params Params `json:"params"`
+}
+
+// Member is a member of a List or Dictionary, either an Item or an InnerList.
+type Member interface {
+	member()
+}
+
+// List is a Structured Field List.
+type List []Member
+
+// DictMember is a named member of a Dictionary.
+type DictMember struct {
+	Key   string `json:"key"`
+	Value Member `json:"value"`
+}
+
+// Dictionary is a Structured Field Dictionary, an ordered map of keys to Members.
+type Dictionary []DictMember
+
+// Param is a single parameter of an Item or InnerList.
+type Param struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// Params is an ordered map of parameter keys to bare item values.
+type Params []Param
+
+// ParseError is returned when a string is not a valid Structured Field Value.
+type ParseError struct {
+	Offset int    `json:"offset"`
+	Reason string `json:"reason"`
+}
+
+// Error returns a string representation of a ParseError.
+func (p *ParseError) Error() string {
+	return (fmt.Sprintf("sfv: %s at offset %d", p.Reason, p.Offset))
+}
+
+func (Item) member() {}
+
+func (InnerList) member() {}
+
+// Get returns the value of a Dictionary member.
+func (d Dictionary) Get(key string) (Member, bool) {
+	for _, m := range d {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set sets the value of a Dictionary member, keeping its position when the key is already present.
+func (d *Dictionary) Set(key string, value Member) {
+	for i := range *d {
+		if (*d)[i].Key == key {
+			(*d)[i].Value = value
+			return
+		}
+	}
+	*d = append(*d, DictMember{Key: key, Value: value})
+}
+
+// Get returns the value of a parameter.
+func (p Params) Get(key string) (interface{}, bool) {
+	for _, param := range p {
+		if param.Key == key {
+			return param.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set sets the value of a parameter, keeping its position when the key is already present.
+func (p *Params) Set(key string, value interface{}) {
+	for i := range *p {
+		if (*p)[i].Key == key {
+			(*p)[i].Value = value
+			return
+		}
+	}
+	*p = append(*p, Param{Key: key, Value: value})
+}
+
+// normalizeBareItem converts the Go types accepted as bare item values to the types used by this package.
+func normalizeBareItem(v interface{}) (interface{}, bool) {
+	switch x := v.(type) {
+	case int:
+		return int64(x), true
+	case int8:
+		return int64(x), true
+	case int16:
+		return int64(x), true
+	case int32:
+		return int64(x), true
+	case int64, float64, string, Token, []byte, bool, time.Time, DisplayString:
+		return x, true
+	case uint8:
+		return int64(x), true
+	case uint16:
+		return int64(x), true
+	case uint32:
+		return int64(x), true
+	case float32:
+		return float64(x), true
+	}
+	return nil, false
+}
@@ -0,0 +1,398 @@
+package sfv_test
+
+import (
+	"encoding/base32"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gellel/w3g/sfv"
+)
+
+// vector is a test case in the format of the structured-field-tests repository.
+type vector struct {
+	CanFail    bool        `json:"can_fail"`
+	Canonical  []string    `json:"canonical"`
+	Expected   interface{} `json:"expected"`
+	HeaderType string      `json:"header_type"`
+	MustFail   bool        `json:"must_fail"`
+	Name       string      `json:"name"`
+	Raw        []string    `json:"raw"`
+}
+
+// TestVectors runs every test case in testdata, and every serialisation test case in testdata/serialisation-tests.
+// Files from the structured-field-tests repository can be added to testdata unchanged; see testdata/README.md.
+func TestVectors(t *testing.T) {
+	var files, err = filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no test vectors in testdata")
+	}
+	for _, file := range files {
+		for _, v := range readVectors(t, file) {
+			runVector(t, filepath.Base(file)+": "+v.Name, v)
+		}
+	}
+	if files, err = filepath.Glob(filepath.Join("testdata", "serialisation-tests", "*.json")); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		for _, v := range readVectors(t, file) {
+			runSerialisationVector(t, filepath.Base(file)+": "+v.Name, v)
+		}
+	}
+}
+
+// TestSerialisationVector checks the runner of the serialisation test cases with test cases in their format.
+func TestSerialisationVector(t *testing.T) {
+	var vectors []vector
+	var decoder *json.Decoder = json.NewDecoder(strings.NewReader(`[
+		{"name": "params", "header_type": "item", "expected": [{"__type": "token", "value": "a"}, [["q", 0.5], ["b", true]]], "canonical": ["a;q=0.5;b"]},
+		{"name": "inner list", "header_type": "list", "expected": [[[[1, []], ["x", []]], [["n", {"__type": "binary", "value": "AA======"}]]]], "canonical": ["(1 \"x\");n=:AA==:"]},
+		{"name": "dictionary", "header_type": "dictionary", "expected": [["a", [{"__type": "date", "value": 1659578233}, []]], ["b", [true, []]]], "canonical": ["a=@1659578233, b"]},
+		{"name": "uppercase key", "header_type": "dictionary", "expected": [["A", [1, []]]], "must_fail": true},
+		{"name": "too big integer", "header_type": "item", "expected": [1000000000000000, []], "must_fail": true}
+	]`))
+	decoder.UseNumber()
+	if err := decoder.Decode(&vectors); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		runSerialisationVector(t, v.Name, v)
+	}
+}
+
+// readVectors decodes a file of test cases in the format of the structured-field-tests repository.
+func readVectors(t *testing.T, file string) []vector {
+	var vectors []vector
+	var f, err = os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var decoder *json.Decoder = json.NewDecoder(f)
+	decoder.UseNumber()
+	if err = decoder.Decode(&vectors); err != nil {
+		t.Fatalf("%s: %v", file, err)
+	}
+	return vectors
+}
+
+func runVector(t *testing.T, name string, v vector) {
+	var raw string = strings.Join(v.Raw, ", ")
+	var got interface{}
+	var serialize func() (string, error)
+	var err error
+	switch v.HeaderType {
+	case "dictionary":
+		var d sfv.Dictionary
+		d, err = sfv.ParseDictionary(raw)
+		got, serialize = dictionaryJSON(d), d.Serialize
+	case "item":
+		var item sfv.Item
+		item, err = sfv.ParseItem(raw)
+		got, serialize = itemJSON(item), item.Serialize
+	case "list":
+		var l sfv.List
+		l, err = sfv.ParseList(raw)
+		got, serialize = listJSON(l), l.Serialize
+	default:
+		t.Errorf("%s: unknown header type %q", name, v.HeaderType)
+		return
+	}
+	if v.MustFail {
+		if err == nil {
+			t.Errorf("%s: parsing %q succeeded, want error", name, raw)
+		}
+		return
+	}
+	if err != nil {
+		if !v.CanFail {
+			t.Errorf("%s: parsing %q: %v", name, raw, err)
+		}
+		return
+	}
+	if !equalJSON(got, v.Expected) {
+		t.Errorf("%s: parsing %q = %v, want %v", name, raw, got, v.Expected)
+	}
+	var want string = raw
+	if v.Canonical != nil {
+		want = strings.Join(v.Canonical, ", ")
+	}
+	if s, err := serialize(); err != nil || s != want {
+		t.Errorf("%s: serialisation = %q, %v, want %q", name, s, err, want)
+	}
+}
+
+// runSerialisationVector serialises the expected value of a serialisation test case.
+func runSerialisationVector(t *testing.T, name string, v vector) {
+	var s string
+	var err error
+	switch v.HeaderType {
+	case "dictionary":
+		var d sfv.Dictionary
+		for _, m := range asArray(v.Expected) {
+			var pair []interface{} = asArray(m)
+			if len(pair) != 2 {
+				t.Errorf("%s: invalid dictionary member %v", name, m)
+				return
+			}
+			var key, _ = pair[0].(string)
+			d = append(d, sfv.DictMember{Key: key, Value: memberFromJSON(pair[1])})
+		}
+		s, err = d.Serialize()
+	case "item":
+		s, err = itemFromJSON(v.Expected).Serialize()
+	case "list":
+		var l sfv.List = sfv.List{}
+		for _, m := range asArray(v.Expected) {
+			l = append(l, memberFromJSON(m))
+		}
+		s, err = l.Serialize()
+	default:
+		t.Errorf("%s: unknown header type %q", name, v.HeaderType)
+		return
+	}
+	if v.MustFail {
+		if err == nil {
+			t.Errorf("%s: serialisation = %q, want error", name, s)
+		}
+		return
+	}
+	if want := strings.Join(v.Canonical, ", "); err != nil || s != want {
+		t.Errorf("%s: serialisation = %q, %v, want %q", name, s, err, want)
+	}
+}
+
+func TestSerializeInvalid(t *testing.T) {
+	var tests = []interface{}{
+		sfv.Item{Value: int64(1000000000000000)},
+		sfv.Item{Value: 1e12},
+		sfv.Item{Value: "\n"},
+		sfv.Item{Value: sfv.Token("1a")},
+		sfv.Item{Value: struct{}{}},
+		sfv.Item{Value: 1, Params: sfv.Params{{Key: "A", Value: 1}}},
+		sfv.Dictionary{{Key: "a b", Value: sfv.Item{Value: 1}}},
+	}
+	for _, test := range tests {
+		var err error
+		switch x := test.(type) {
+		case sfv.Item:
+			_, err = x.Serialize()
+		case sfv.Dictionary:
+			_, err = x.Serialize()
+		}
+		if err == nil {
+			t.Errorf("serialising %#v succeeded, want error", test)
+		}
+	}
+}
+
+func TestSerializeDecimal(t *testing.T) {
+	var tests = []struct {
+		value float64
+		want  string
+	}{
+		{1, "1.0"},
+		{1.0005, "1.0"},
+		{1.0015, "1.002"},
+		{-0.0001, "0.0"},
+		{123.456789, "123.457"},
+	}
+	for _, test := range tests {
+		if got := (sfv.Item{Value: test.value}).String(); got != test.want {
+			t.Errorf("Item{%v}.String() = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestDictionarySet(t *testing.T) {
+	var d sfv.Dictionary
+	d.Set("a", sfv.Item{Value: 1})
+	d.Set("b", sfv.InnerList{Items: []sfv.Item{{Value: sfv.Token("x")}}})
+	d.Set("a", sfv.Item{Value: false})
+	if got, want := d.String(), "a=?0, b=(x)"; got != want {
+		t.Errorf("Dictionary.String() = %q, want %q", got, want)
+	}
+	if m, ok := d.Get("b"); !ok || m.(sfv.InnerList).Items[0].Value != sfv.Token("x") {
+		t.Errorf("Dictionary.Get(%q) = %v, %t", "b", m, ok)
+	}
+}
+
+// asArray returns a JSON array, or nil when a value is not one.
+func asArray(v interface{}) []interface{} {
+	var a, _ = v.([]interface{})
+	return a
+}
+
+// bareFromJSON converts a bare item of a test case to its Go type.
+func bareFromJSON(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if !strings.ContainsAny(string(x), ".eE") {
+			if n, err := strconv.ParseInt(string(x), 10, 64); err == nil {
+				return n
+			}
+		}
+		var f, _ = strconv.ParseFloat(string(x), 64)
+		return f
+	case map[string]interface{}:
+		switch x["__type"] {
+		case "binary":
+			var s, _ = x["value"].(string)
+			var b, _ = base32.StdEncoding.DecodeString(s)
+			return b
+		case "date":
+			var n, _ = x["value"].(json.Number)
+			var seconds, _ = strconv.ParseInt(string(n), 10, 64)
+			return time.Unix(seconds, 0).UTC()
+		case "displaystring":
+			var s, _ = x["value"].(string)
+			return sfv.DisplayString(s)
+		case "token":
+			var s, _ = x["value"].(string)
+			return sfv.Token(s)
+		}
+	}
+	return v
+}
+
+// itemFromJSON converts an item of a test case, a bare item and its parameters, to an Item.
+func itemFromJSON(v interface{}) sfv.Item {
+	var pair []interface{} = asArray(v)
+	if len(pair) != 2 {
+		return sfv.Item{Value: v}
+	}
+	return sfv.Item{Value: bareFromJSON(pair[0]), Params: paramsFromJSON(pair[1])}
+}
+
+// memberFromJSON converts a member of a test case to an Item, or to an InnerList when its first element is an array.
+func memberFromJSON(v interface{}) sfv.Member {
+	var pair []interface{} = asArray(v)
+	if len(pair) != 2 {
+		return itemFromJSON(v)
+	}
+	if items, ok := pair[0].([]interface{}); ok {
+		var l sfv.InnerList = sfv.InnerList{Items: []sfv.Item{}, Params: paramsFromJSON(pair[1])}
+		for _, item := range items {
+			l.Items = append(l.Items, itemFromJSON(item))
+		}
+		return l
+	}
+	return itemFromJSON(v)
+}
+
+// paramsFromJSON converts the parameters of a test case to Params.
+func paramsFromJSON(v interface{}) sfv.Params {
+	var params sfv.Params
+	for _, p := range asArray(v) {
+		var pair []interface{} = asArray(p)
+		if len(pair) == 2 {
+			var key, _ = pair[0].(string)
+			params = append(params, sfv.Param{Key: key, Value: bareFromJSON(pair[1])})
+		}
+	}
+	return params
+}
+
+func bareJSON(v interface{}) interface{} {
+	switch x := v.(type) {
+	case sfv.Token:
+		return map[string]interface{}{"__type": "token", "value": string(x)}
+	case []byte:
+		return map[string]interface{}{"__type": "binary", "value": base32.StdEncoding.EncodeToString(x)}
+	case time.Time:
+		return map[string]interface{}{"__type": "date", "value": x.Unix()}
+	case sfv.DisplayString:
+		return map[string]interface{}{"__type": "displaystring", "value": string(x)}
+	}
+	return v
+}
+
+func dictionaryJSON(d sfv.Dictionary) interface{} {
+	var out []interface{} = []interface{}{}
+	for _, m := range d {
+		out = append(out, []interface{}{m.Key, memberJSON(m.Value)})
+	}
+	return out
+}
+
+func itemJSON(item sfv.Item) interface{} {
+	return []interface{}{bareJSON(item.Value), paramsJSON(item.Params)}
+}
+
+func listJSON(l sfv.List) interface{} {
+	var out []interface{} = []interface{}{}
+	for _, m := range l {
+		out = append(out, memberJSON(m))
+	}
+	return out
+}
+
+func memberJSON(m sfv.Member) interface{} {
+	if l, ok := m.(sfv.InnerList); ok {
+		var items []interface{} = []interface{}{}
+		for _, item := range l.Items {
+			items = append(items, itemJSON(item))
+		}
+		return []interface{}{items, paramsJSON(l.Params)}
+	}
+	return itemJSON(m.(sfv.Item))
+}
+
+func paramsJSON(params sfv.Params) interface{} {
+	var out []interface{} = []interface{}{}
+	for _, p := range params {
+		out = append(out, []interface{}{p.Key, bareJSON(p.Value)})
+	}
+	return out
+}
+
+// equalJSON compares a parsed value converted by the JSON helpers with an expected value decoded from JSON.
+func equalJSON(got, want interface{}) bool {
+	switch w := want.(type) {
+	case json.Number:
+		var f, err = strconv.ParseFloat(string(w), 64)
+		if err != nil {
+			return false
+		}
+		switch g := got.(type) {
+		case int64:
+			return (!strings.ContainsAny(string(w), ".eE") && float64(g) == f)
+		case float64:
+			return (strings.Contains(string(w), ".") && g == f)
+		}
+		return false
+	case []interface{}:
+		var g, ok = got.([]interface{})
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !equalJSON(g[i], w[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		var g, ok = got.(map[string]interface{})
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for k := range w {
+			if !equalJSON(g[k], w[k]) {
+				return false
+			}
+		}
+		return true
+	case nil:
+		return false
+	}
+	return (got == want)
+}
@@ -0,0 +1,803 @@
+package w3g
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gellel/w3g/sfv"
+)
+
+// SignatureAlgorithmECDSAP256SHA256 is the SignatureAlgorithm for ECDSA with the P-256 curve and SHA-256.
+const SignatureAlgorithmECDSAP256SHA256 SignatureAlgorithm = "ecdsa-p256-sha256"
+
+// SignatureAlgorithmEd25519 is the SignatureAlgorithm for Ed25519.
+const SignatureAlgorithmEd25519 SignatureAlgorithm = "ed25519"
+
+// SignatureAlgorithmHMACSHA256 is the SignatureAlgorithm for HMAC with SHA-256 and a shared secret.
+const SignatureAlgorithmHMACSHA256 SignatureAlgorithm = "hmac-sha256"
+
+// SignatureAlgorithmRSAPSSSHA512 is the SignatureAlgorithm for RSASSA-PSS with SHA-512 and a 64 byte salt.
+const SignatureAlgorithmRSAPSSSHA512 SignatureAlgorithm = "rsa-pss-sha512"
+
+// ErrSignatureCoverage is returned when a signature does not cover the components a MessageVerifier requires.
+var ErrSignatureCoverage = errors.New("w3g: signature does not cover the required components")
+
+// ErrSignatureExpired is returned when a signature has expired, is too old or was created in the future.
+var ErrSignatureExpired = errors.New("w3g: signature expired")
+
+// ErrSignatureInvalid is returned when a signature does not verify or cannot be computed.
+var ErrSignatureInvalid = errors.New("w3g: invalid signature")
+
+// ErrSignatureNotFound is returned when a message does not have a signature to verify.
+var ErrSignatureNotFound = errors.New("w3g: no signature")
+
+// ErrSignatureReplayed is returned when the nonce of a signature has already been seen.
+var ErrSignatureReplayed = errors.New("w3g: signature nonce replayed")
+
+// signatureParamsKey is the context key of the SignatureParams of a verified request.
+type signatureParamsKey struct{}
+
+// SignatureAlgorithm is an algorithm of the HTTP Signature Algorithms registry of RFC 9421.
+type SignatureAlgorithm string
+
+// MessageSigner is a struct to configure the signing of HTTP messages with HTTP Message Signatures. Each signature
+// covers Components, is named Label, or "sig" when Label is empty, and is created now. It expires after Expires when
+// Expires is not 0, and has a random nonce when Nonce is set. Transport sends the requests of RoundTrip, and
+// http.DefaultTransport is used when it is nil.
+type MessageSigner struct {
+	Components []SignatureComponent `json:"components"`
+	Expires    time.Duration        `json:"expires"`
+	Key        SignatureKey         `json:"-"`
+	Label      string               `json:"label"`
+	Nonce      bool                 `json:"nonce"`
+	Tag        string               `json:"tag"`
+	Transport  http.RoundTripper    `json:"-"`
+}
+
+// MessageVerifier is a struct to configure the verification of HTTP Message Signatures. A message is valid when one
+// of its signatures, or the signature named Label when Label is not empty, verifies with the key that Keys resolves
+// from its keyid, covers the Required components and has the Tag, when Tag is not empty. Signatures created more than
+// MaxAge ago, or later than now plus ClockSkew, are rejected, as are signatures whose expires parameter has passed.
+// When Nonces is not nil it is called with the nonce of each valid signature and must return false for a nonce that
+// was already seen; signatures without a nonce are then rejected.
+type MessageVerifier struct {
+	ClockSkew time.Duration           `json:"clock_skew"`
+	Keys      SignatureKeyResolver    `json:"-"`
+	Label     string                  `json:"label"`
+	MaxAge    time.Duration           `json:"max_age"`
+	Nonces    func(nonce string) bool `json:"-"`
+	Required  []SignatureComponent    `json:"required"`
+	Tag       string                  `json:"tag"`
+}
+
+// SignatureComponent is a struct to hold a component identifier of a HTTP Message Signature. Name is a derived
+// component, such as @method, or a lower case field name. Key selects a member of a Dictionary field, Param selects a
+// parameter of the @query-param component, SF reserializes a field as a Structured Field, BS encodes each field line
+// as a Byte Sequence, and Req takes the component from the request of a response.
+type SignatureComponent struct {
+	BS    bool   `json:"bs"`
+	Key   string `json:"key"`
+	Name  string `json:"name"`
+	Param string `json:"param"`
+	Req   bool   `json:"req"`
+	SF    bool   `json:"sf"`
+}
+
+// SignatureKey is a struct to hold a key of a HTTP Message Signature. Key is a []byte shared secret for
+// hmac-sha256, a ed25519.PrivateKey or ed25519.PublicKey for ed25519, a *ecdsa.PrivateKey or *ecdsa.PublicKey for
+// ecdsa-p256-sha256, and a *rsa.PrivateKey or *rsa.PublicKey for rsa-pss-sha512. Private keys can sign and verify,
+// public keys can only verify.
+type SignatureKey struct {
+	Algorithm SignatureAlgorithm `json:"algorithm"`
+	ID        string             `json:"id"`
+	Key       interface{}        `json:"-"`
+}
+
+// SignatureKeyResolver resolves the key of a HTTP Message Signature from its keyid parameter.
+type SignatureKeyResolver interface {
+	ResolveSignatureKey(ctx context.Context, keyID string) (SignatureKey, error)
+}
+
+// SignatureKeys is a SignatureKeyResolver of a fixed set of keys, by keyid.
+type SignatureKeys map[string]SignatureKey
+
+// SignatureParams is a struct to hold the covered components and the parameters of a HTTP Message Signature, the
+// value of a member of a Signature-Input HTTP header. Zero times and empty strings are left out.
+type SignatureParams struct {
+	Algorithm  SignatureAlgorithm   `json:"algorithm"`
+	Components []SignatureComponent `json:"components"`
+	Created    time.Time            `json:"created"`
+	Expires    time.Time            `json:"expires"`
+	KeyID      string               `json:"key_id"`
+	Nonce      string               `json:"nonce"`
+	Tag        string               `json:"tag"`
+}
+
+// signatureMessage is a HTTP request or response whose components are signed.
+type signatureMessage struct {
+	header  http.Header
+	request *http.Request
+	status  int
+}
+
+// signatureWriter is a http.ResponseWriter that signs a response before its headers are sent.
+type signatureWriter struct {
+	http.ResponseWriter
+	request     *http.Request
+	signer      *MessageSigner
+	wroteHeader bool
+}
+
+// ParseSignatureComponent parses a component identifier, such as "@method" or "example-dict";key="a".
+func ParseSignatureComponent(s string) (SignatureComponent, error) {
+	var item, err = sfv.ParseItem(s)
+	if err != nil {
+		return SignatureComponent{}, &HeaderValueError{Header: SignatureInput, Value: s}
+	}
+	return signatureComponent(item)
+}
+
+// SignatureParamsFromContext returns the SignatureParams of the signature that a MessageVerifier verified for a
+// request, and false when the request was not verified.
+func SignatureParamsFromContext(ctx context.Context) (SignatureParams, bool) {
+	var p, ok = ctx.Value(signatureParamsKey{}).(SignatureParams)
+	return p, ok
+}
+
+// SignRequest adds a signature with params, computed with key, to the Signature-Input and Signature HTTP headers of a
+// request under label. The keyid parameter defaults to the ID of the key.
+func SignRequest(r *http.Request, label string, params SignatureParams, key SignatureKey) error {
+	return signMessage(signatureMessage{header: r.Header, request: r}, label, params, key)
+}
+
+// SignResponse adds a signature with params, computed with key, to the Signature-Input and Signature HTTP headers of
+// a response with header h and status code to request r. The request is only used by components with Req set.
+func SignResponse(h http.Header, code int, r *http.Request, label string, params SignatureParams, key SignatureKey) error {
+	return signMessage(signatureMessage{header: h, request: r, status: code}, label, params, key)
+}
+
+// Handler returns a http.Handler that signs the responses of next before their headers are sent. Responses are sent
+// without a signature when a covered component is missing.
+func (s *MessageSigner) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&signatureWriter{ResponseWriter: w, request: r, signer: s}, r)
+	})
+}
+
+// RoundTrip signs a HTTP request and sends it.
+func (s *MessageSigner) RoundTrip(r *http.Request) (*http.Response, error) {
+	var transport http.RoundTripper = s.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	r = r.Clone(r.Context())
+	if err := SignRequest(r, s.label(), s.params(), s.Key); err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(r)
+}
+
+// label returns the Label of a MessageSigner, or "sig" when it is empty.
+func (s *MessageSigner) label() string {
+	if len(s.Label) == 0 {
+		return "sig"
+	}
+	return s.Label
+}
+
+// params returns the SignatureParams of a new signature.
+func (s *MessageSigner) params() SignatureParams {
+	var p SignatureParams = SignatureParams{Components: s.Components, Created: time.Now(), Tag: s.Tag}
+	if s.Expires != 0 {
+		p.Expires = p.Created.Add(s.Expires)
+	}
+	if s.Nonce {
+		var b [16]byte
+		rand.Read(b[:])
+		p.Nonce = hex.EncodeToString(b[:])
+	}
+	return p
+}
+
+// Handler returns a http.Handler that verifies the signatures of requests before calling next, which can read the
+// SignatureParams of the verified signature with SignatureParamsFromContext. Requests without a valid signature
+// receive a 401 Unauthorized response.
+func (v *MessageVerifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p, err = v.VerifyRequest(r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), signatureParamsKey{}, p)))
+	})
+}
+
+// VerifyRequest verifies the signatures of a HTTP request and returns the SignatureParams of the first valid
+// signature.
+func (v *MessageVerifier) VerifyRequest(r *http.Request) (SignatureParams, error) {
+	return v.verify(r.Context(), signatureMessage{header: r.Header, request: r})
+}
+
+// VerifyResponse verifies the signatures of a HTTP response and returns the SignatureParams of the first valid
+// signature. Components with Req set are taken from the Request of the response.
+func (v *MessageVerifier) VerifyResponse(res *http.Response) (SignatureParams, error) {
+	var ctx context.Context = context.Background()
+	if res.Request != nil {
+		ctx = res.Request.Context()
+	}
+	return v.verify(ctx, signatureMessage{header: res.Header, request: res.Request, status: res.StatusCode})
+}
+
+// verify verifies the signatures of a message.
+func (v *MessageVerifier) verify(ctx context.Context, m signatureMessage) (SignatureParams, error) {
+	var inputs, err = sfv.ParseDictionary(strings.Join(m.header.Values(SignatureInput), ", "))
+	if err != nil {
+		return SignatureParams{}, ErrSignatureInvalid
+	}
+	var signatures sfv.Dictionary
+	if signatures, err = sfv.ParseDictionary(strings.Join(m.header.Values(Signature), ", ")); err != nil {
+		return SignatureParams{}, ErrSignatureInvalid
+	}
+	err = ErrSignatureNotFound
+	for _, input := range inputs {
+		if len(v.Label) != 0 && input.Key != v.Label {
+			continue
+		}
+		var p, verifyErr = v.verifySignature(ctx, m, input, signatures)
+		if verifyErr == nil {
+			return p, nil
+		}
+		if err == ErrSignatureNotFound {
+			err = verifyErr
+		}
+	}
+	return SignatureParams{}, err
+}
+
+// verifySignature verifies the signature of a member of a Signature-Input HTTP header.
+func (v *MessageVerifier) verifySignature(ctx context.Context, m signatureMessage, input sfv.DictMember, signatures sfv.Dictionary) (SignatureParams, error) {
+	var list, ok = input.Value.(sfv.InnerList)
+	if !ok {
+		return SignatureParams{}, ErrSignatureInvalid
+	}
+	var member, found = signatures.Get(input.Key)
+	if !found {
+		return SignatureParams{}, ErrSignatureNotFound
+	}
+	var item, isItem = member.(sfv.Item)
+	var signature, isBytes = item.Value.([]byte)
+	if !isItem || !isBytes {
+		return SignatureParams{}, ErrSignatureInvalid
+	}
+	var p, err = signatureParams(list)
+	if err != nil {
+		return SignatureParams{}, err
+	}
+	for _, required := range v.Required {
+		if !p.Covers(required) {
+			return SignatureParams{}, ErrSignatureCoverage
+		}
+	}
+	if len(v.Tag) != 0 && p.Tag != v.Tag {
+		return SignatureParams{}, ErrSignatureInvalid
+	}
+	var now time.Time = time.Now()
+	switch {
+	case !p.Expires.IsZero() && now.After(p.Expires):
+		return SignatureParams{}, ErrSignatureExpired
+	case !p.Created.IsZero() && p.Created.After(now.Add(v.ClockSkew)):
+		return SignatureParams{}, ErrSignatureExpired
+	case v.MaxAge > 0 && (p.Created.IsZero() || now.Sub(p.Created) > v.MaxAge):
+		return SignatureParams{}, ErrSignatureExpired
+	}
+	if v.Keys == nil {
+		return SignatureParams{}, ErrSignatureInvalid
+	}
+	var key SignatureKey
+	if key, err = v.Keys.ResolveSignatureKey(ctx, p.KeyID); err != nil {
+		return SignatureParams{}, err
+	}
+	if len(p.Algorithm) != 0 && p.Algorithm != key.Algorithm {
+		return SignatureParams{}, ErrSignatureInvalid
+	}
+	var base []byte
+	if base, err = signatureBase(m, list); err != nil {
+		return SignatureParams{}, err
+	}
+	if err = key.Verify(base, signature); err != nil {
+		return SignatureParams{}, err
+	}
+	if v.Nonces != nil && (len(p.Nonce) == 0 || !v.Nonces(p.Nonce)) {
+		return SignatureParams{}, ErrSignatureReplayed
+	}
+	return p, nil
+}
+
+// String returns a string representation of a SignatureAlgorithm.
+func (s SignatureAlgorithm) String() string {
+	return string(s)
+}
+
+// Valid reports whether a SignatureAlgorithm is supported.
+func (s SignatureAlgorithm) Valid() bool {
+	switch s {
+	case SignatureAlgorithmECDSAP256SHA256, SignatureAlgorithmEd25519, SignatureAlgorithmHMACSHA256, SignatureAlgorithmRSAPSSSHA512:
+		return true
+	}
+	return false
+}
+
+// String returns the serialized component identifier of a SignatureComponent.
+func (s SignatureComponent) String() string {
+	return (s.item().String())
+}
+
+// item returns the component identifier of a SignatureComponent as a Structured Field Item, with its parameters in
+// the order of RFC 9421.
+func (s SignatureComponent) item() sfv.Item {
+	var item sfv.Item = sfv.Item{Value: s.Name}
+	if s.SF {
+		item.Params.Set("sf", true)
+	}
+	if len(s.Key) != 0 {
+		item.Params.Set("key", s.Key)
+	}
+	if s.BS {
+		item.Params.Set("bs", true)
+	}
+	if s.Req {
+		item.Params.Set("req", true)
+	}
+	if len(s.Param) != 0 {
+		item.Params.Set("name", s.Param)
+	}
+	return item
+}
+
+// ResolveSignatureKey returns the key with a keyid.
+func (s SignatureKeys) ResolveSignatureKey(ctx context.Context, keyID string) (SignatureKey, error) {
+	var key, ok = s[keyID]
+	if !ok {
+		return SignatureKey{}, ErrSignatureInvalid
+	}
+	return key, nil
+}
+
+// Sign returns the signature of a signature base.
+func (s SignatureKey) Sign(base []byte) ([]byte, error) {
+	switch s.Algorithm {
+	case SignatureAlgorithmECDSAP256SHA256:
+		var key, ok = s.Key.(*ecdsa.PrivateKey)
+		if !ok || key.Curve != elliptic.P256() {
+			return nil, ErrSignatureInvalid
+		}
+		var digest [sha256.Size]byte = sha256.Sum256(base)
+		var r, sig, err = ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		var signature []byte = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		sig.FillBytes(signature[32:])
+		return signature, nil
+	case SignatureAlgorithmEd25519:
+		var key, ok = s.Key.(ed25519.PrivateKey)
+		if !ok || len(key) != ed25519.PrivateKeySize {
+			return nil, ErrSignatureInvalid
+		}
+		return ed25519.Sign(key, base), nil
+	case SignatureAlgorithmHMACSHA256:
+		var key, ok = s.Key.([]byte)
+		if !ok {
+			return nil, ErrSignatureInvalid
+		}
+		var mac = hmac.New(sha256.New, key)
+		mac.Write(base)
+		return mac.Sum(nil), nil
+	case SignatureAlgorithmRSAPSSSHA512:
+		var key, ok = s.Key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrSignatureInvalid
+		}
+		var digest [sha512.Size]byte = sha512.Sum512(base)
+		return rsa.SignPSS(rand.Reader, key, crypto.SHA512, digest[:], &rsa.PSSOptions{SaltLength: 64})
+	}
+	return nil, ErrSignatureInvalid
+}
+
+// Verify checks the signature of a signature base, returning ErrSignatureInvalid when it does not verify.
+func (s SignatureKey) Verify(base, signature []byte) error {
+	var valid bool
+	switch s.Algorithm {
+	case SignatureAlgorithmECDSAP256SHA256:
+		var key *ecdsa.PublicKey
+		switch k := s.Key.(type) {
+		case *ecdsa.PrivateKey:
+			key = &k.PublicKey
+		case *ecdsa.PublicKey:
+			key = k
+		}
+		if key != nil && key.Curve == elliptic.P256() && len(signature) == 64 {
+			var digest [sha256.Size]byte = sha256.Sum256(base)
+			valid = ecdsa.Verify(key, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:]))
+		}
+	case SignatureAlgorithmEd25519:
+		var key ed25519.PublicKey
+		switch k := s.Key.(type) {
+		case ed25519.PrivateKey:
+			key, _ = k.Public().(ed25519.PublicKey)
+		case ed25519.PublicKey:
+			key = k
+		}
+		valid = (len(key) == ed25519.PublicKeySize && ed25519.Verify(key, base, signature))
+	case SignatureAlgorithmHMACSHA256:
+		var expected, err = s.Sign(base)
+		valid = (err == nil && hmac.Equal(expected, signature))
+	case SignatureAlgorithmRSAPSSSHA512:
+		var key *rsa.PublicKey
+		switch k := s.Key.(type) {
+		case *rsa.PrivateKey:
+			key = &k.PublicKey
+		case *rsa.PublicKey:
+			key = k
+		}
+		if key != nil {
+			var digest [sha512.Size]byte = sha512.Sum512(base)
+			valid = (rsa.VerifyPSS(key, crypto.SHA512, digest[:], signature, &rsa.PSSOptions{SaltLength: 64}) == nil)
+		}
+	}
+	if !valid {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// Covers reports whether a signature covers a component.
+func (s SignatureParams) Covers(component SignatureComponent) bool {
+	for _, c := range s.Components {
+		if c.String() == component.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns a string representation of a SignatureParams, the value of a member of a Signature-Input HTTP header.
+func (s SignatureParams) String() string {
+	return (s.innerList().String())
+}
+
+// innerList returns a SignatureParams as a Structured Field Inner List, with its parameters in the order of RFC 9421.
+func (s SignatureParams) innerList() sfv.InnerList {
+	var list sfv.InnerList = sfv.InnerList{Items: []sfv.Item{}}
+	for _, c := range s.Components {
+		list.Items = append(list.Items, c.item())
+	}
+	if !s.Created.IsZero() {
+		list.Params.Set("created", s.Created.Unix())
+	}
+	if !s.Expires.IsZero() {
+		list.Params.Set("expires", s.Expires.Unix())
+	}
+	if len(s.Nonce) != 0 {
+		list.Params.Set("nonce", s.Nonce)
+	}
+	if len(s.Algorithm) != 0 {
+		list.Params.Set("alg", string(s.Algorithm))
+	}
+	if len(s.KeyID) != 0 {
+		list.Params.Set("keyid", s.KeyID)
+	}
+	if len(s.Tag) != 0 {
+		list.Params.Set("tag", s.Tag)
+	}
+	return list
+}
+
+// Flush signs the response, when its headers have not been sent yet, and sends any buffered data to the client.
+func (w *signatureWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use by http.ResponseController.
+func (w *signatureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Write signs the response, when its headers have not been sent yet, and writes data.
+func (w *signatureWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteHeader signs the response and sends its headers. Informational responses are not signed.
+func (w *signatureWriter) WriteHeader(code int) {
+	if !w.wroteHeader && code >= 200 {
+		w.wroteHeader = true
+		var h http.Header = w.Header()
+		var signed http.Header = h.Clone()
+		if SignResponse(signed, code, w.request, w.signer.label(), w.signer.params(), w.signer.Key) == nil {
+			h[SignatureInput] = signed[SignatureInput]
+			h[Signature] = signed[Signature]
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// component returns the values of a component of a message. Most components have a single value, but the
+// @query-param component has one value for each occurrence of its parameter.
+func (m signatureMessage) component(c SignatureComponent) ([]string, error) {
+	if c.Req {
+		if m.request == nil || m.status == 0 {
+			return nil, ErrSignatureInvalid
+		}
+		c.Req = false
+		return signatureMessage{header: m.request.Header, request: m.request}.component(c)
+	}
+	if !strings.HasPrefix(c.Name, "@") {
+		return m.field(c)
+	}
+	if c.Name == "@status" {
+		if m.status == 0 {
+			return nil, ErrSignatureInvalid
+		}
+		return []string{strconv.Itoa(m.status)}, nil
+	}
+	var r *http.Request = m.request
+	if r == nil || m.status != 0 {
+		return nil, ErrSignatureInvalid
+	}
+	switch c.Name {
+	case "@authority":
+		return []string{signatureAuthority(r)}, nil
+	case "@method":
+		return []string{r.Method}, nil
+	case "@path":
+		var path string = r.URL.EscapedPath()
+		if len(path) == 0 {
+			path = "/"
+		}
+		return []string{path}, nil
+	case "@query":
+		return []string{"?" + r.URL.RawQuery}, nil
+	case "@query-param":
+		var values []string
+		for _, pair := range strings.Split(r.URL.RawQuery, "&") {
+			var name, value, _ = strings.Cut(pair, "=")
+			if queryParamEncode(name) == c.Param {
+				values = append(values, queryParamEncode(value))
+			}
+		}
+		if len(values) == 0 {
+			return nil, ErrSignatureInvalid
+		}
+		return values, nil
+	case "@request-target":
+		return []string{r.URL.RequestURI()}, nil
+	case "@scheme":
+		return []string{requestURL(r).Scheme}, nil
+	case "@target-uri":
+		return []string{requestURL(r).Scheme + "://" + signatureAuthority(r) + r.URL.RequestURI()}, nil
+	}
+	return nil, ErrSignatureInvalid
+}
+
+// field returns the value of a header field component of a message.
+func (m signatureMessage) field(c SignatureComponent) ([]string, error) {
+	var lines []string
+	for _, line := range m.header.Values(c.Name) {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if len(lines) == 0 {
+		return nil, ErrSignatureInvalid
+	}
+	var value string = strings.Join(lines, ", ")
+	switch {
+	case c.BS:
+		var encoded []string
+		for _, line := range lines {
+			encoded = append(encoded, ":"+base64.StdEncoding.EncodeToString([]byte(line))+":")
+		}
+		return []string{strings.Join(encoded, ", ")}, nil
+	case len(c.Key) != 0:
+		var d, err = sfv.ParseDictionary(value)
+		if err != nil {
+			return nil, ErrSignatureInvalid
+		}
+		switch member, _ := d.Get(c.Key); member := member.(type) {
+		case sfv.InnerList:
+			return []string{member.String()}, nil
+		case sfv.Item:
+			return []string{member.String()}, nil
+		}
+		return nil, ErrSignatureInvalid
+	case c.SF:
+		if d, err := sfv.ParseDictionary(value); err == nil {
+			return []string{d.String()}, nil
+		}
+		if l, err := sfv.ParseList(value); err == nil {
+			return []string{l.String()}, nil
+		}
+		return nil, ErrSignatureInvalid
+	}
+	return []string{value}, nil
+}
+
+// signMessage adds a signature to the Signature-Input and Signature HTTP headers of a message.
+func signMessage(m signatureMessage, label string, params SignatureParams, key SignatureKey) error {
+	if len(params.KeyID) == 0 {
+		params.KeyID = key.ID
+	}
+	var list sfv.InnerList = params.innerList()
+	var base, err = signatureBase(m, list)
+	if err != nil {
+		return err
+	}
+	var signature []byte
+	if signature, err = key.Sign(base); err != nil {
+		return err
+	}
+	var inputs, _ = sfv.ParseDictionary(strings.Join(m.header.Values(SignatureInput), ", "))
+	var signatures, _ = sfv.ParseDictionary(strings.Join(m.header.Values(Signature), ", "))
+	inputs.Set(label, list)
+	signatures.Set(label, sfv.Item{Value: signature})
+	m.header.Set(SignatureInput, inputs.String())
+	m.header.Set(Signature, signatures.String())
+	return nil
+}
+
+// signatureAuthority returns the @authority component of a HTTP request: its lower case host, without the default
+// port of its scheme.
+func signatureAuthority(r *http.Request) string {
+	var host string = r.Host
+	if len(host) == 0 {
+		host = r.URL.Host
+	}
+	host = strings.ToLower(host)
+	switch scheme := requestURL(r).Scheme; {
+	case scheme == "http" && strings.HasSuffix(host, ":80"):
+		host = strings.TrimSuffix(host, ":80")
+	case scheme == "https" && strings.HasSuffix(host, ":443"):
+		host = strings.TrimSuffix(host, ":443")
+	}
+	return host
+}
+
+// queryParamEncode decodes a name or value of a query and encodes it again as the @query-param component requires,
+// with spaces encoded as %20.
+func queryParamEncode(s string) string {
+	var decoded, err = url.QueryUnescape(s)
+	if err != nil {
+		decoded = s
+	}
+	return (strings.ReplaceAll(url.QueryEscape(decoded), "+", "%20"))
+}
+
+// signatureBase returns the signature base of a message for the covered components and parameters of a signature.
+func signatureBase(m signatureMessage, list sfv.InnerList) ([]byte, error) {
+	var b strings.Builder
+	var seen map[string]bool = map[string]bool{}
+	for _, item := range list.Items {
+		var c, err = signatureComponent(item)
+		if err != nil {
+			return nil, err
+		}
+		var id string = c.String()
+		if seen[id] {
+			return nil, ErrSignatureInvalid
+		}
+		seen[id] = true
+		var values []string
+		if values, err = m.component(c); err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			if strings.ContainsAny(value, "\r\n") {
+				return nil, ErrSignatureInvalid
+			}
+			b.WriteString(id + ": " + value + "\n")
+		}
+	}
+	var params, err = list.Serialize()
+	if err != nil {
+		return nil, ErrSignatureInvalid
+	}
+	b.WriteString(`"@signature-params": ` + params)
+	return []byte(b.String()), nil
+}
+
+// signatureComponent returns the SignatureComponent of a component identifier.
+func signatureComponent(item sfv.Item) (SignatureComponent, error) {
+	var name, ok = item.Value.(string)
+	if !ok || len(name) == 0 || name != strings.ToLower(name) {
+		return SignatureComponent{}, ErrSignatureInvalid
+	}
+	var c SignatureComponent = SignatureComponent{Name: name}
+	for _, param := range item.Params {
+		switch param.Key {
+		case "bs":
+			c.BS = param.Value == true
+		case "key":
+			c.Key, ok = param.Value.(string)
+		case "name":
+			c.Param, ok = param.Value.(string)
+		case "req":
+			c.Req = param.Value == true
+		case "sf":
+			c.SF = param.Value == true
+		default:
+			ok = false
+		}
+		if !ok {
+			return SignatureComponent{}, ErrSignatureInvalid
+		}
+	}
+	if (c.Name == "@query-param") != (len(c.Param) != 0) || (c.BS && (c.SF || len(c.Key) != 0)) {
+		return SignatureComponent{}, ErrSignatureInvalid
+	}
+	return c, nil
+}
+
+// signatureParams returns the SignatureParams of the covered components and parameters of a signature.
+func signatureParams(list sfv.InnerList) (SignatureParams, error) {
+	var p SignatureParams
+	for _, item := range list.Items {
+		var c, err = signatureComponent(item)
+		if err != nil {
+			return SignatureParams{}, err
+		}
+		p.Components = append(p.Components, c)
+	}
+	for _, param := range list.Params {
+		var ok bool = true
+		switch param.Key {
+		case "alg":
+			var alg string
+			alg, ok = param.Value.(string)
+			p.Algorithm = SignatureAlgorithm(alg)
+		case "created":
+			var created int64
+			created, ok = param.Value.(int64)
+			p.Created = time.Unix(created, 0)
+		case "expires":
+			var expires int64
+			expires, ok = param.Value.(int64)
+			p.Expires = time.Unix(expires, 0)
+		case "keyid":
+			p.KeyID, ok = param.Value.(string)
+		case "nonce":
+			p.Nonce, ok = param.Value.(string)
+		case "tag":
+			p.Tag, ok = param.Value.(string)
+		}
+		if !ok {
+			return SignatureParams{}, ErrSignatureInvalid
+		}
+	}
+	return p, nil
+}
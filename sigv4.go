@@ -0,0 +1,724 @@
+package w3g
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SigV4Algorithm is the algorithm of AWS Signature Version 4, the Type of its AuthorizationHeader values.
+const SigV4Algorithm string = "AWS4-HMAC-SHA256"
+
+// SigV4PayloadSigned is the SigV4Payload that signs the SHA-256 digest of the whole body.
+const SigV4PayloadSigned SigV4Payload = ""
+
+// SigV4PayloadStreaming is the SigV4Payload that sends the body with the aws-chunked content coding and signs each
+// chunk, chained to the signature of the headers.
+const SigV4PayloadStreaming SigV4Payload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+
+// SigV4PayloadUnsigned is the SigV4Payload that does not sign the body.
+const SigV4PayloadUnsigned SigV4Payload = "UNSIGNED-PAYLOAD"
+
+// sigV4MaxExpires is the longest validity of a presigned URL in seconds, 7 days.
+const sigV4MaxExpires int64 = 604800
+
+// sigV4TimeFormat is the layout of the X-Amz-Date HTTP header.
+const sigV4TimeFormat string = "20060102T150405Z"
+
+// sigV4ChunkSignatureLength is the length of the chunk-signature extension of a chunk of a streaming payload.
+const sigV4ChunkSignatureLength int = len(";chunk-signature=") + sha256.Size*2
+
+// The HTTP headers of AWS Signature Version 4.
+const (
+	xAmzContentSHA256        string = "X-Amz-Content-Sha256"
+	xAmzDate                 string = "X-Amz-Date"
+	xAmzDecodedContentLength string = "X-Amz-Decoded-Content-Length"
+	xAmzSecurityToken        string = "X-Amz-Security-Token"
+)
+
+// errSigV4ContentLength is returned when a streaming payload is signed for a request without a known length.
+var errSigV4ContentLength = errors.New("w3g: streaming payloads require a known content length")
+
+// sigV4EmptyHash is the hex encoded SHA-256 digest of an empty payload.
+var sigV4EmptyHash string = hex.EncodeToString(sha256.New().Sum(nil))
+
+// sigV4IgnoredHeaders are the headers that are not signed, because proxies and clients change them.
+var sigV4IgnoredHeaders = map[string]bool{
+	"authorization":     true,
+	"connection":        true,
+	"expect":            true,
+	"transfer-encoding": true,
+	"user-agent":        true,
+	"x-amzn-trace-id":   true,
+}
+
+// sigV4AccessKeyIDKey is the context key of the access key ID of a verified request.
+type sigV4AccessKeyIDKey struct{}
+
+// SigV4Credentials is a struct to hold the credentials of AWS Signature Version 4. SessionToken is only set for
+// temporary credentials.
+type SigV4Credentials struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"-"`
+	SessionToken    string `json:"-"`
+}
+
+// SigV4CredentialsResolver resolves the credentials of AWS Signature Version 4 from an access key ID.
+type SigV4CredentialsResolver interface {
+	ResolveSigV4Credentials(ctx context.Context, accessKeyID string) (SigV4Credentials, error)
+}
+
+// SigV4Keys is a SigV4CredentialsResolver of a fixed set of credentials, by access key ID.
+type SigV4Keys map[string]SigV4Credentials
+
+// SigV4Payload is a payload mode of AWS Signature Version 4, the value of the X-Amz-Content-Sha256 HTTP header when
+// the body is not signed as a whole.
+type SigV4Payload string
+
+// SigV4Signer is a struct to configure the signing of HTTP requests with AWS Signature Version 4 for a Region and
+// Service. Payload selects how the body is signed, and ChunkSize is the size of the chunks of a streaming payload,
+// 64 KiB when it is 0. Requests to the s3 Service follow the rules of Amazon S3: the path is escaped once and not
+// normalized, and the X-Amz-Content-Sha256 HTTP header is always sent. Transport sends the requests of RoundTrip, and
+// http.DefaultTransport is used when it is nil.
+type SigV4Signer struct {
+	ChunkSize   int               `json:"chunk_size"`
+	Credentials SigV4Credentials  `json:"-"`
+	Payload     SigV4Payload      `json:"payload"`
+	Region      string            `json:"region"`
+	Service     string            `json:"service"`
+	Transport   http.RoundTripper `json:"-"`
+}
+
+// SigV4Verifier is a struct to configure the verification of HTTP requests signed with AWS Signature Version 4, in
+// the Authorization HTTP header or in the query of a presigned URL. Keys resolves the credentials of the access key
+// ID of a signature, whose scope must match Region and Service when they are not empty. Signatures dated more than
+// ClockSkew away from now, 15 minutes when it is 0, presigned URLs that have expired, and presigned URLs valid for
+// more than the 7 days allowed by AWS are rejected. Streaming payloads are not supported, and requests without a
+// X-Amz-Content-Sha256 HTTP header have their body read into memory to compute its digest. Such bodies larger than
+// MaxBodySize bytes are rejected with a 413 Content Too Large response. A MaxBodySize of 0 does not limit their size.
+type SigV4Verifier struct {
+	ClockSkew   time.Duration            `json:"clock_skew"`
+	Keys        SigV4CredentialsResolver `json:"-"`
+	MaxBodySize int64                    `json:"max_body_size"`
+	Region      string                   `json:"region"`
+	Service     string                   `json:"service"`
+}
+
+// sigV4Authorization is a struct to hold the parameters of a signature of AWS Signature Version 4.
+type sigV4Authorization struct {
+	accessKeyID   string
+	date          time.Time
+	region        string
+	service       string
+	signature     string
+	signedHeaders []string
+}
+
+// sigV4ChunkReader is a io.ReadCloser that encodes a body with the aws-chunked content coding, signing each chunk.
+type sigV4ChunkReader struct {
+	body     io.ReadCloser
+	buf      bytes.Buffer
+	chunk    []byte
+	done     bool
+	key      []byte
+	previous string
+	prefix   string
+}
+
+// sigV4PayloadReader is a io.ReadCloser that returns ErrDigestMismatch at the end of a body whose SHA-256 digest does
+// not match the X-Amz-Content-Sha256 HTTP header.
+type sigV4PayloadReader struct {
+	body     io.ReadCloser
+	err      error
+	expected string
+	hash     hash.Hash
+}
+
+// SigV4AccessKeyIDFromContext returns the access key ID of the signature that a SigV4Verifier verified for a request,
+// and false when the request was not verified.
+func SigV4AccessKeyIDFromContext(ctx context.Context) (string, bool) {
+	var accessKeyID, ok = ctx.Value(sigV4AccessKeyIDKey{}).(string)
+	return accessKeyID, ok
+}
+
+// ResolveSigV4Credentials returns the credentials of an access key ID.
+func (k SigV4Keys) ResolveSigV4Credentials(ctx context.Context, accessKeyID string) (SigV4Credentials, error) {
+	var credentials, ok = k[accessKeyID]
+	if !ok {
+		return SigV4Credentials{}, ErrSignatureInvalid
+	}
+	return credentials, nil
+}
+
+// CanonicalRequest returns the canonical request of a HTTP request, covering signedHeaders, which must be lower case
+// and sorted, and a payload that is the hex encoded SHA-256 digest of the body or a SigV4Payload.
+func (s *SigV4Signer) CanonicalRequest(r *http.Request, signedHeaders []string, payload string) string {
+	var b strings.Builder
+	b.WriteString(r.Method)
+	b.WriteByte('\n')
+	b.WriteString(s.canonicalURI(r.URL))
+	b.WriteByte('\n')
+	b.WriteString(sigV4CanonicalQuery(r.URL.RawQuery))
+	b.WriteByte('\n')
+	for _, name := range signedHeaders {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(sigV4HeaderValue(r, name))
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	b.WriteString(strings.Join(signedHeaders, ";"))
+	b.WriteByte('\n')
+	b.WriteString(payload)
+	return b.String()
+}
+
+// Presign returns a presigned URL of a HTTP request at t, which expires after expires. The headers of the request
+// are signed, and must be sent with the URL, but the body is not.
+func (s *SigV4Signer) Presign(r *http.Request, t time.Time, expires time.Duration) *url.URL {
+	t = t.UTC()
+	var signedHeaders []string = s.signedHeaders(r)
+	var query []string = []string{
+		"X-Amz-Algorithm=" + SigV4Algorithm,
+		"X-Amz-Credential=" + sigV4Escape(s.Credentials.AccessKeyID+"/"+s.scope(t), true),
+		"X-Amz-Date=" + t.Format(sigV4TimeFormat),
+		"X-Amz-Expires=" + strconv.FormatInt(int64(expires/time.Second), 10),
+	}
+	if len(s.Credentials.SessionToken) != 0 {
+		(query) = (append(query, "X-Amz-Security-Token="+sigV4Escape(s.Credentials.SessionToken, true)))
+	}
+	(query) = (append(query, "X-Amz-SignedHeaders="+sigV4Escape(strings.Join(signedHeaders, ";"), true)))
+	var u url.URL = *r.URL
+	if len(u.RawQuery) != 0 {
+		u.RawQuery += "&"
+	}
+	u.RawQuery += strings.Join(query, "&")
+	var presigned *http.Request = r.Clone(r.Context())
+	presigned.URL = &u
+	var signature string = s.signature(t, s.CanonicalRequest(presigned, signedHeaders, string(SigV4PayloadUnsigned)))
+	u.RawQuery += "&X-Amz-Signature=" + signature
+	return &u
+}
+
+// RoundTrip signs a HTTP request now and sends it.
+func (s *SigV4Signer) RoundTrip(r *http.Request) (*http.Response, error) {
+	var transport http.RoundTripper = s.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	r = r.Clone(r.Context())
+	if err := s.Sign(r, time.Now()); err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(r)
+}
+
+// Sign signs a HTTP request at t, setting its X-Amz-Date and Authorization HTTP headers, and X-Amz-Security-Token
+// when the credentials have a SessionToken. A signed payload is read from GetBody when the request has one, and is
+// otherwise read into memory and replaced, together with GetBody. A streaming payload replaces the body with its
+// aws-chunked encoding, which requires the length of the body to be known.
+func (s *SigV4Signer) Sign(r *http.Request, t time.Time) error {
+	t = t.UTC()
+	var payload string = string(s.Payload)
+	if s.Payload == SigV4PayloadSigned {
+		var err error
+		if payload, err = sigV4PayloadHash(r, 0); err != nil {
+			return err
+		}
+	}
+	r.Header.Del(Authorization)
+	r.Header.Set(xAmzDate, t.Format(sigV4TimeFormat))
+	if len(s.Credentials.SessionToken) != 0 {
+		r.Header.Set(xAmzSecurityToken, s.Credentials.SessionToken)
+	}
+	if s.s3() || s.Payload != SigV4PayloadSigned {
+		r.Header.Set(xAmzContentSHA256, payload)
+	}
+	if s.Payload == SigV4PayloadStreaming {
+		if r.ContentLength < 0 || (r.ContentLength == 0 && r.Body != nil && r.Body != http.NoBody) {
+			return errSigV4ContentLength
+		}
+		var codings []string = []string{"aws-chunked"}
+		if value := r.Header.Get(ContentEncoding); len(value) != 0 {
+			(codings) = (append(codings, value))
+		}
+		r.Header.Set(ContentEncoding, strings.Join(codings, ","))
+		r.Header.Set(xAmzDecodedContentLength, strconv.FormatInt(r.ContentLength, 10))
+		r.ContentLength = sigV4ChunkedLength(r.ContentLength, int64(s.chunkSize()))
+		r.Header.Set(ContentLength, strconv.FormatInt(r.ContentLength, 10))
+	}
+	var signedHeaders []string = s.signedHeaders(r)
+	var signature string = s.signature(t, s.CanonicalRequest(r, signedHeaders, payload))
+	r.Header.Set(Authorization, AuthorizationHeader{
+		Credentials: fmt.Sprintf("Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.Credentials.AccessKeyID, s.scope(t), strings.Join(signedHeaders, ";"), signature),
+		Type:        SigV4Algorithm}.String())
+	if s.Payload == SigV4PayloadStreaming {
+		var body io.ReadCloser = r.Body
+		if body == nil {
+			body = http.NoBody
+		}
+		r.Body = s.chunkReader(body, t, signature)
+		if r.GetBody != nil {
+			var getBody func() (io.ReadCloser, error) = r.GetBody
+			r.GetBody = func() (io.ReadCloser, error) {
+				var body, err = getBody()
+				if err != nil {
+					return nil, err
+				}
+				return s.chunkReader(body, t, signature), nil
+			}
+		}
+	}
+	return nil
+}
+
+// SigningKey returns the key derived from the credentials of a SigV4Signer for the scope of t.
+func (s *SigV4Signer) SigningKey(t time.Time) []byte {
+	var key []byte = sigV4HMAC([]byte("AWS4"+s.Credentials.SecretAccessKey), t.UTC().Format("20060102"))
+	(key) = (sigV4HMAC(key, s.Region))
+	(key) = (sigV4HMAC(key, s.Service))
+	return sigV4HMAC(key, "aws4_request")
+}
+
+// StringToSign returns the string to sign of a canonical request at t.
+func (s *SigV4Signer) StringToSign(t time.Time, canonicalRequest string) string {
+	var sum [sha256.Size]byte = sha256.Sum256([]byte(canonicalRequest))
+	return strings.Join([]string{SigV4Algorithm, t.UTC().Format(sigV4TimeFormat), s.scope(t), hex.EncodeToString(sum[:])}, "\n")
+}
+
+// canonicalURI returns the canonical URI of a URL.
+func (s *SigV4Signer) canonicalURI(u *url.URL) string {
+	if s.s3() {
+		if len(u.Path) == 0 {
+			return "/"
+		}
+		return sigV4Escape(u.Path, false)
+	}
+	var p string = u.EscapedPath()
+	if len(p) == 0 {
+		return "/"
+	}
+	var cleaned string = path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return sigV4Escape(cleaned, false)
+}
+
+// chunkReader returns a sigV4ChunkReader of a body whose headers were signed at t with seed.
+func (s *SigV4Signer) chunkReader(body io.ReadCloser, t time.Time, seed string) *sigV4ChunkReader {
+	return &sigV4ChunkReader{
+		body:     body,
+		chunk:    make([]byte, s.chunkSize()),
+		key:      s.SigningKey(t),
+		prefix:   "AWS4-HMAC-SHA256-PAYLOAD\n" + t.Format(sigV4TimeFormat) + "\n" + s.scope(t) + "\n",
+		previous: seed}
+}
+
+// chunkSize returns the ChunkSize of a SigV4Signer, or 64 KiB when it is 0.
+func (s *SigV4Signer) chunkSize() int {
+	if s.ChunkSize <= 0 {
+		return 64 << 10
+	}
+	return s.ChunkSize
+}
+
+// s3 reports whether a SigV4Signer signs requests to Amazon S3.
+func (s *SigV4Signer) s3() bool {
+	return s.Service == "s3"
+}
+
+// scope returns the credential scope of t.
+func (s *SigV4Signer) scope(t time.Time) string {
+	return strings.Join([]string{t.UTC().Format("20060102"), s.Region, s.Service, "aws4_request"}, "/")
+}
+
+// signature returns the hex encoded signature of a canonical request at t.
+func (s *SigV4Signer) signature(t time.Time, canonicalRequest string) string {
+	return hex.EncodeToString(sigV4HMAC(s.SigningKey(t), s.StringToSign(t, canonicalRequest)))
+}
+
+// signedHeaders returns the sorted, lower case names of the headers of a HTTP request that are signed.
+func (s *SigV4Signer) signedHeaders(r *http.Request) []string {
+	var signedHeaders []string = []string{"host"}
+	for name := range r.Header {
+		var lower string = strings.ToLower(name)
+		if lower != "host" && !sigV4IgnoredHeaders[lower] {
+			(signedHeaders) = (append(signedHeaders, lower))
+		}
+	}
+	sort.Strings(signedHeaders)
+	return signedHeaders
+}
+
+// Handler returns a http.Handler that verifies the signatures of requests before calling next, which can read the
+// access key ID of the signature with SigV4AccessKeyIDFromContext. Requests without a valid signature receive a 403
+// Forbidden response, and requests whose body is larger than MaxBodySize a 413 Content Too Large response.
+func (v *SigV4Verifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.Clone(r.Context())
+		var accessKeyID, err = v.Verify(r)
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sigV4AccessKeyIDKey{}, accessKeyID)))
+	})
+}
+
+// Verify verifies the signature of a HTTP request and returns its access key ID. When the X-Amz-Content-Sha256 HTTP
+// header is a digest, the body is replaced with a reader that returns ErrDigestMismatch at its end when it does not
+// match.
+func (v *SigV4Verifier) Verify(r *http.Request) (string, error) {
+	var query url.Values = r.URL.Query()
+	var a sigV4Authorization
+	var err error
+	var presigned bool = len(query.Get("X-Amz-Algorithm")) != 0
+	if presigned {
+		a, err = parseSigV4Presigned(query)
+	} else {
+		a, err = parseSigV4Authorization(r.Header)
+	}
+	if err != nil {
+		return "", err
+	}
+	if (len(v.Region) != 0 && a.region != v.Region) || (len(v.Service) != 0 && a.service != v.Service) {
+		return "", ErrSignatureInvalid
+	}
+	var skew time.Duration = v.ClockSkew
+	if skew == 0 {
+		skew = 15 * time.Minute
+	}
+	var now time.Time = time.Now()
+	if a.date.After(now.Add(skew)) {
+		return "", ErrSignatureExpired
+	}
+	if presigned {
+		var expires, err = strconv.ParseInt(query.Get("X-Amz-Expires"), 10, 64)
+		if err != nil || expires < 0 || expires > sigV4MaxExpires || now.After(a.date.Add(time.Duration(expires)*time.Second)) {
+			return "", ErrSignatureExpired
+		}
+	} else if a.date.Before(now.Add(-skew)) {
+		return "", ErrSignatureExpired
+	}
+	var payload string = r.Header.Get(xAmzContentSHA256)
+	if presigned && len(query.Get(xAmzContentSHA256)) != 0 {
+		payload = query.Get(xAmzContentSHA256)
+	}
+	switch {
+	case len(payload) == 0 && presigned:
+		payload = string(SigV4PayloadUnsigned)
+	case len(payload) == 0:
+		if payload, err = sigV4PayloadHash(r, v.MaxBodySize); err != nil {
+			return "", err
+		}
+	case payload == string(SigV4PayloadUnsigned):
+	case len(payload) == sha256.Size*2 && strings.Trim(payload, "0123456789abcdef") == "":
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = &sigV4PayloadReader{body: r.Body, expected: payload, hash: sha256.New()}
+		} else if payload != sigV4EmptyHash {
+			return "", ErrDigestMismatch
+		}
+	default:
+		return "", ErrSignatureInvalid
+	}
+	var credentials SigV4Credentials
+	if v.Keys == nil {
+		return "", ErrSignatureInvalid
+	}
+	if credentials, err = v.Keys.ResolveSigV4Credentials(r.Context(), a.accessKeyID); err != nil {
+		return "", err
+	}
+	var s *SigV4Signer = &SigV4Signer{Credentials: credentials, Region: a.region, Service: a.service}
+	var signed *http.Request = r
+	if presigned {
+		signed = r.Clone(r.Context())
+		var u url.URL = *r.URL
+		(u.RawQuery) = (sigV4RemoveQuery(u.RawQuery, "X-Amz-Signature"))
+		signed.URL = &u
+	}
+	var signature string = s.signature(a.date, s.CanonicalRequest(signed, a.signedHeaders, payload))
+	if !hmac.Equal([]byte(signature), []byte(a.signature)) {
+		return "", ErrSignatureInvalid
+	}
+	return a.accessKeyID, nil
+}
+
+// parseSigV4Authorization parses the signature of the Authorization and X-Amz-Date HTTP headers.
+func parseSigV4Authorization(h http.Header) (sigV4Authorization, error) {
+	var value string = h.Get(Authorization)
+	if len(value) == 0 {
+		return sigV4Authorization{}, ErrSignatureNotFound
+	}
+	var algorithm, credentials, _ = strings.Cut(value, " ")
+	if algorithm != SigV4Algorithm {
+		return sigV4Authorization{}, ErrSignatureNotFound
+	}
+	var params map[string]string = make(map[string]string)
+	for _, param := range strings.Split(credentials, ",") {
+		var name, value, _ = strings.Cut(strings.TrimSpace(param), "=")
+		params[name] = value
+	}
+	return parseSigV4Params(params["Credential"], h.Get(xAmzDate), params["SignedHeaders"], params["Signature"])
+}
+
+// parseSigV4Params parses the parameters of a signature of AWS Signature Version 4.
+func parseSigV4Params(credential string, date string, signedHeaders string, signature string) (sigV4Authorization, error) {
+	var a sigV4Authorization = sigV4Authorization{signature: signature}
+	var err error
+	if a.date, err = time.Parse(sigV4TimeFormat, date); err != nil {
+		return sigV4Authorization{}, ErrSignatureInvalid
+	}
+	var parts []string = strings.Split(credential, "/")
+	if len(parts) < 5 || parts[len(parts)-1] != "aws4_request" || parts[len(parts)-4] != date[:8] {
+		return sigV4Authorization{}, ErrSignatureInvalid
+	}
+	a.accessKeyID = strings.Join(parts[:len(parts)-4], "/")
+	a.region = parts[len(parts)-3]
+	a.service = parts[len(parts)-2]
+	a.signedHeaders = strings.Split(signedHeaders, ";")
+	var i int = sort.SearchStrings(a.signedHeaders, "host")
+	if !sort.StringsAreSorted(a.signedHeaders) || i == len(a.signedHeaders) || a.signedHeaders[i] != "host" || len(a.signature) != sha256.Size*2 {
+		return sigV4Authorization{}, ErrSignatureInvalid
+	}
+	return a, nil
+}
+
+// parseSigV4Presigned parses the signature of the query of a presigned URL.
+func parseSigV4Presigned(query url.Values) (sigV4Authorization, error) {
+	if query.Get("X-Amz-Algorithm") != SigV4Algorithm {
+		return sigV4Authorization{}, ErrSignatureNotFound
+	}
+	return parseSigV4Params(query.Get("X-Amz-Credential"), query.Get("X-Amz-Date"), query.Get("X-Amz-SignedHeaders"), query.Get("X-Amz-Signature"))
+}
+
+// sigV4CanonicalQuery returns the canonical query string of a raw query, with the names and values escaped and
+// sorted by name and then by value.
+func sigV4CanonicalQuery(rawQuery string) string {
+	var pairs [][2]string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if len(pair) == 0 {
+			continue
+		}
+		var name, value, _ = strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		(pairs) = (append(pairs, [2]string{sigV4Escape(name, true), sigV4Escape(value, true)}))
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	var substrings []string
+	for _, pair := range pairs {
+		(substrings) = (append(substrings, pair[0]+"="+pair[1]))
+	}
+	return strings.Join(substrings, "&")
+}
+
+// sigV4ChunkedLength returns the length of the aws-chunked encoding of n bytes in chunks of size.
+func sigV4ChunkedLength(n int64, size int64) int64 {
+	var length int64 = 1 + int64(sigV4ChunkSignatureLength) + 4
+	if full := n / size; full != 0 {
+		length += full * (int64(len(strconv.FormatInt(size, 16))+sigV4ChunkSignatureLength+4) + size)
+	}
+	if rest := n % size; rest != 0 {
+		length += int64(len(strconv.FormatInt(rest, 16))+sigV4ChunkSignatureLength+4) + rest
+	}
+	return length
+}
+
+// sigV4Escape escapes a string as AWS Signature Version 4 does, leaving only unreserved characters, and slashes when
+// slash is false.
+func sigV4Escape(s string, slash bool) string {
+	const hexDigits string = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		var c byte = s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !slash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0xf])
+		}
+	}
+	return b.String()
+}
+
+// sigV4HMAC returns the HMAC-SHA256 of data with key.
+func sigV4HMAC(key []byte, data string) []byte {
+	var h hash.Hash = hmac.New(sha256.New, key)
+	io.WriteString(h, data)
+	return h.Sum(nil)
+}
+
+// sigV4HeaderValue returns the canonical value of a header of a HTTP request: its lines trimmed, with sequential
+// spaces collapsed, and joined with commas.
+func sigV4HeaderValue(r *http.Request, name string) string {
+	if name == "host" {
+		return sigV4Host(r)
+	}
+	var values []string
+	for _, value := range r.Header.Values(name) {
+		(values) = (append(values, strings.Join(strings.Fields(value), " ")))
+	}
+	return strings.Join(values, ",")
+}
+
+// sigV4Host returns the host of a HTTP request, without the default port of its scheme.
+func sigV4Host(r *http.Request) string {
+	var host string = r.Host
+	if len(host) == 0 {
+		host = r.URL.Host
+	}
+	if name, port, err := net.SplitHostPort(host); err == nil && ((port == "80" && r.URL.Scheme == "http") || (port == "443" && r.URL.Scheme == "https")) {
+		if strings.Contains(name, ":") {
+			return "[" + name + "]"
+		}
+		return name
+	}
+	return host
+}
+
+// sigV4LimitReader returns a reader of at most one byte more than limit bytes of r, so that a longer body can be
+// detected, or r itself when limit is 0.
+func sigV4LimitReader(r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+	return io.LimitReader(r, limit+1)
+}
+
+// sigV4PayloadHash returns the hex encoded SHA-256 digest of the body of a HTTP request. The body is read from
+// GetBody when the request has one, and is otherwise read into memory and replaced, together with GetBody. It returns
+// a *http.MaxBytesError when the body is larger than limit bytes, unless limit is 0.
+func sigV4PayloadHash(r *http.Request, limit int64) (string, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return sigV4EmptyHash, nil
+	}
+	var h hash.Hash = sha256.New()
+	if r.GetBody != nil {
+		var body, err = r.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+		var n int64
+		if n, err = io.Copy(h, sigV4LimitReader(body, limit)); err != nil {
+			return "", err
+		}
+		if limit > 0 && n > limit {
+			return "", &http.MaxBytesError{Limit: limit}
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+	var data, err = io.ReadAll(sigV4LimitReader(r.Body, limit))
+	r.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		return "", &http.MaxBytesError{Limit: limit}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	r.ContentLength = int64(len(data))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sigV4RemoveQuery returns a raw query without the parameters named name.
+func sigV4RemoveQuery(rawQuery string, name string) string {
+	var pairs []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if key, _, _ := strings.Cut(pair, "="); key != name {
+			(pairs) = (append(pairs, pair))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// Close closes the body.
+func (c *sigV4ChunkReader) Close() error {
+	return c.body.Close()
+}
+
+// Read reads the aws-chunked encoding of the body.
+func (c *sigV4ChunkReader) Read(b []byte) (int, error) {
+	for c.buf.Len() == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		var n, err = io.ReadFull(c.body, c.chunk)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		if n != 0 {
+			c.write(c.chunk[:n])
+		}
+		if n < len(c.chunk) {
+			c.write(nil)
+			c.done = true
+		}
+	}
+	return c.buf.Read(b)
+}
+
+// write writes a signed chunk of data.
+func (c *sigV4ChunkReader) write(data []byte) {
+	var sum [sha256.Size]byte = sha256.Sum256(data)
+	c.previous = hex.EncodeToString(sigV4HMAC(c.key, c.prefix+c.previous+"\n"+sigV4EmptyHash+"\n"+hex.EncodeToString(sum[:])))
+	fmt.Fprintf(&c.buf, "%x;chunk-signature=%s\r\n", len(data), c.previous)
+	c.buf.Write(data)
+	c.buf.WriteString("\r\n")
+}
+
+// Close closes the body.
+func (p *sigV4PayloadReader) Close() error {
+	return p.body.Close()
+}
+
+// Read reads from the body. At the end of the body, it returns ErrDigestMismatch when the body does not match.
+func (p *sigV4PayloadReader) Read(b []byte) (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	var n, err = p.body.Read(b)
+	p.hash.Write(b[:n])
+	if err == io.EOF && hex.EncodeToString(p.hash.Sum(nil)) != p.expected {
+		err = ErrDigestMismatch
+	}
+	p.err = err
+	return n, err
+}
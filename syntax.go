@@ -0,0 +1,165 @@
+package w3g
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// isToken reports whether a string is a token as defined by RFC 9110.
+func isToken(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isTokenChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTokenChar reports whether a character is a tchar as defined by RFC 9110.
+func isTokenChar(c byte) bool {
+	if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) != -1
+}
+
+// quoteString returns a string as a quoted-string as defined by RFC 9110.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// tokenOrQuoted returns a string as a token when it is one, and as a quoted-string otherwise.
+func tokenOrQuoted(s string) string {
+	if isToken(s) {
+		return s
+	}
+	return quoteString(s)
+}
+
+// splitQuoted splits a string at every separator that is not inside a quoted-string or angle brackets, trimming
+// optional whitespace from each part.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	var quoted, bracketed bool
+	var start int
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == '<':
+			bracketed = true
+		case !quoted && c == '>':
+			bracketed = false
+		case !quoted && !bracketed && c == sep:
+			parts = append(parts, strings.Trim(s[start:i], " \t"))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.Trim(s[start:], " \t"))
+}
+
+// unquoteString returns the value of a token or quoted-string as defined by RFC 9110. The returned bool is false when
+// a string is neither.
+func unquoteString(s string) (string, bool) {
+	if len(s) == 0 || s[0] != '"' {
+		return s, isToken(s)
+	}
+	if len(s) < 2 || s[len(s)-1] != '"' {
+		return "", false
+	}
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		var c byte = s[i]
+		if c == '\\' {
+			if i+1 == len(s)-1 {
+				return "", false
+			}
+			i++
+			c = s[i]
+		} else if c == '"' {
+			return "", false
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), true
+}
+
+// decodeExtValue decodes an ext-value as defined by RFC 8187, such as the value of a title* or filename* parameter.
+// The UTF-8 and ISO-8859-1 character sets are supported. The returned bool is false when a string cannot be decoded.
+func decodeExtValue(s string) (string, bool) {
+	var parts []string = strings.SplitN(s, "'", 3)
+	if len(parts) != 3 {
+		return "", false
+	}
+	var b []byte = make([]byte, 0, len(parts[2]))
+	for i := 0; i < len(parts[2]); i++ {
+		var c byte = parts[2][i]
+		if c == '%' {
+			if i+2 >= len(parts[2]) {
+				return "", false
+			}
+			var n, err = strconv.ParseUint(parts[2][i+1:i+3], 16, 8)
+			if err != nil {
+				return "", false
+			}
+			b = append(b, byte(n))
+			i += 2
+		} else if isAttrChar(c) {
+			b = append(b, c)
+		} else {
+			return "", false
+		}
+	}
+	switch strings.ToLower(parts[0]) {
+	case "utf-8":
+		if !utf8.Valid(b) {
+			return "", false
+		}
+		return string(b), true
+	case "iso-8859-1":
+		var r []rune = make([]rune, len(b))
+		for i, c := range b {
+			r[i] = rune(c)
+		}
+		return string(r), true
+	}
+	return "", false
+}
+
+// encodeExtValue encodes a string as a UTF-8 ext-value as defined by RFC 8187.
+func encodeExtValue(s string) string {
+	var b strings.Builder
+	b.WriteString("UTF-8''")
+	for i := 0; i < len(s); i++ {
+		if isAttrChar(s[i]) {
+			b.WriteByte(s[i])
+		} else {
+			fmt.Fprintf(&b, "%%%02X", s[i])
+		}
+	}
+	return b.String()
+}
+
+// isAttrChar reports whether a character is an attr-char as defined by RFC 8187.
+func isAttrChar(c byte) bool {
+	if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) != -1
+}
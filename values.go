@@ -0,0 +1,540 @@
+package w3g
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ConnectionClose is the ConnectionValue indicating that the sender will close the connection after the current transaction.
+const ConnectionClose ConnectionValue = "close"
+
+// ConnectionKeepAlive is the ConnectionValue indicating that the sender wishes to keep the connection open.
+const ConnectionKeepAlive ConnectionValue = "keep-alive"
+
+// CookiePriorityHigh is the CookiePriorityValue of a cookie that is evicted last when a user agent removes cookies
+// of a domain that has too many.
+const CookiePriorityHigh CookiePriorityValue = "High"
+
+// CookiePriorityLow is the CookiePriorityValue of a cookie that is evicted first when a user agent removes cookies
+// of a domain that has too many.
+const CookiePriorityLow CookiePriorityValue = "Low"
+
+// CookiePriorityMedium is the default CookiePriorityValue of a cookie.
+const CookiePriorityMedium CookiePriorityValue = "Medium"
+
+// CrossOriginEmbedderPolicyCredentialless is the CrossOriginEmbedderPolicyValue that allows no-cors cross-origin
+// requests without an explicit permission, as long as they are sent without credentials.
+const CrossOriginEmbedderPolicyCredentialless CrossOriginEmbedderPolicyValue = "credentialless"
+
+// CrossOriginEmbedderPolicyRequireCorp is the CrossOriginEmbedderPolicyValue that only allows a document to load
+// cross-origin resources that grant permission through a Cross-Origin-Resource-Policy or CORS.
+const CrossOriginEmbedderPolicyRequireCorp CrossOriginEmbedderPolicyValue = "require-corp"
+
+// CrossOriginEmbedderPolicyUnsafeNone is the default CrossOriginEmbedderPolicyValue that allows a document to load
+// cross-origin resources without an explicit permission.
+const CrossOriginEmbedderPolicyUnsafeNone CrossOriginEmbedderPolicyValue = "unsafe-none"
+
+// CrossOriginResourcePolicyCrossOrigin is the CrossOriginResourcePolicyValue that allows any origin to load the resource.
+const CrossOriginResourcePolicyCrossOrigin CrossOriginResourcePolicyValue = "cross-origin"
+
+// CrossOriginResourcePolicySameOrigin is the CrossOriginResourcePolicyValue that only allows the same origin to load the resource.
+const CrossOriginResourcePolicySameOrigin CrossOriginResourcePolicyValue = "same-origin"
+
+// CrossOriginResourcePolicySameSite is the CrossOriginResourcePolicyValue that only allows the same site to load the resource.
+const CrossOriginResourcePolicySameSite CrossOriginResourcePolicyValue = "same-site"
+
+// ReferrerPolicyNoReferrer is the ReferrerPolicyValue that omits the Referer header entirely.
+const ReferrerPolicyNoReferrer ReferrerPolicyValue = "no-referrer"
+
+// ReferrerPolicyNoReferrerWhenDowngrade is the ReferrerPolicyValue that sends the full URL unless the request
+// is downgraded from HTTPS to HTTP.
+const ReferrerPolicyNoReferrerWhenDowngrade ReferrerPolicyValue = "no-referrer-when-downgrade"
+
+// ReferrerPolicyOrigin is the ReferrerPolicyValue that only sends the origin of the document.
+const ReferrerPolicyOrigin ReferrerPolicyValue = "origin"
+
+// ReferrerPolicyOriginWhenCrossOrigin is the ReferrerPolicyValue that sends the full URL to the same origin
+// and only the origin to every other origin.
+const ReferrerPolicyOriginWhenCrossOrigin ReferrerPolicyValue = "origin-when-cross-origin"
+
+// ReferrerPolicySameOrigin is the ReferrerPolicyValue that sends the full URL to the same origin and nothing to every other origin.
+const ReferrerPolicySameOrigin ReferrerPolicyValue = "same-origin"
+
+// ReferrerPolicyStrictOrigin is the ReferrerPolicyValue that only sends the origin of the document,
+// and nothing when the request is downgraded from HTTPS to HTTP.
+const ReferrerPolicyStrictOrigin ReferrerPolicyValue = "strict-origin"
+
+// ReferrerPolicyStrictOriginWhenCrossOrigin is the ReferrerPolicyValue that sends the full URL to the same origin,
+// only the origin to every other origin, and nothing when the request is downgraded from HTTPS to HTTP.
+const ReferrerPolicyStrictOriginWhenCrossOrigin ReferrerPolicyValue = "strict-origin-when-cross-origin"
+
+// ReferrerPolicyUnsafeURL is the ReferrerPolicyValue that always sends the full URL.
+const ReferrerPolicyUnsafeURL ReferrerPolicyValue = "unsafe-url"
+
+// SameSiteLax is the SameSiteValue of a cookie that is sent with same-site requests and with cross-site top-level
+// navigations that use a safe method. It is the SameSiteValue of a cookie without a SameSite attribute.
+const SameSiteLax SameSiteValue = "Lax"
+
+// SameSiteNone is the SameSiteValue of a cookie that is sent with same-site and cross-site requests. The cookie must
+// have the Secure attribute.
+const SameSiteNone SameSiteValue = "None"
+
+// SameSiteStrict is the SameSiteValue of a cookie that is only sent with same-site requests.
+const SameSiteStrict SameSiteValue = "Strict"
+
+// SecFetchDestAudio is the SecFetchDestValue of a request initiated by an <audio> element.
+const SecFetchDestAudio SecFetchDestValue = "audio"
+
+// SecFetchDestAudioWorklet is the SecFetchDestValue of a request for an audio worklet module.
+const SecFetchDestAudioWorklet SecFetchDestValue = "audioworklet"
+
+// SecFetchDestDocument is the SecFetchDestValue of a top-level navigation request.
+const SecFetchDestDocument SecFetchDestValue = "document"
+
+// SecFetchDestEmbed is the SecFetchDestValue of a request initiated by an <embed> element.
+const SecFetchDestEmbed SecFetchDestValue = "embed"
+
+// SecFetchDestEmpty is the SecFetchDestValue of a request without a destination, such as fetch() or XMLHttpRequest.
+const SecFetchDestEmpty SecFetchDestValue = "empty"
+
+// SecFetchDestFencedFrame is the SecFetchDestValue of a navigation request for a <fencedframe> element.
+const SecFetchDestFencedFrame SecFetchDestValue = "fencedframe"
+
+// SecFetchDestFont is the SecFetchDestValue of a request for a font.
+const SecFetchDestFont SecFetchDestValue = "font"
+
+// SecFetchDestFrame is the SecFetchDestValue of a navigation request for a <frame> element.
+const SecFetchDestFrame SecFetchDestValue = "frame"
+
+// SecFetchDestIFrame is the SecFetchDestValue of a navigation request for an <iframe> element.
+const SecFetchDestIFrame SecFetchDestValue = "iframe"
+
+// SecFetchDestImage is the SecFetchDestValue of a request for an image.
+const SecFetchDestImage SecFetchDestValue = "image"
+
+// SecFetchDestJSON is the SecFetchDestValue of a request for a JSON module.
+const SecFetchDestJSON SecFetchDestValue = "json"
+
+// SecFetchDestManifest is the SecFetchDestValue of a request for a web app manifest.
+const SecFetchDestManifest SecFetchDestValue = "manifest"
+
+// SecFetchDestObject is the SecFetchDestValue of a request initiated by an <object> element.
+const SecFetchDestObject SecFetchDestValue = "object"
+
+// SecFetchDestPaintWorklet is the SecFetchDestValue of a request for a paint worklet module.
+const SecFetchDestPaintWorklet SecFetchDestValue = "paintworklet"
+
+// SecFetchDestReport is the SecFetchDestValue of a report, such as a Content-Security-Policy report.
+const SecFetchDestReport SecFetchDestValue = "report"
+
+// SecFetchDestScript is the SecFetchDestValue of a request for a script.
+const SecFetchDestScript SecFetchDestValue = "script"
+
+// SecFetchDestServiceWorker is the SecFetchDestValue of a request for a service worker.
+const SecFetchDestServiceWorker SecFetchDestValue = "serviceworker"
+
+// SecFetchDestSharedWorker is the SecFetchDestValue of a request for a shared worker.
+const SecFetchDestSharedWorker SecFetchDestValue = "sharedworker"
+
+// SecFetchDestStyle is the SecFetchDestValue of a request for a style sheet.
+const SecFetchDestStyle SecFetchDestValue = "style"
+
+// SecFetchDestTrack is the SecFetchDestValue of a request for an HTML text track.
+const SecFetchDestTrack SecFetchDestValue = "track"
+
+// SecFetchDestVideo is the SecFetchDestValue of a request initiated by a <video> element.
+const SecFetchDestVideo SecFetchDestValue = "video"
+
+// SecFetchDestWebIdentity is the SecFetchDestValue of a request made by the Federated Credential Management API.
+const SecFetchDestWebIdentity SecFetchDestValue = "webidentity"
+
+// SecFetchDestWorker is the SecFetchDestValue of a request for a dedicated worker.
+const SecFetchDestWorker SecFetchDestValue = "worker"
+
+// SecFetchDestXSLT is the SecFetchDestValue of a request for an XSLT transform.
+const SecFetchDestXSLT SecFetchDestValue = "xslt"
+
+// SecFetchModeCORS is the SecFetchModeValue of a CORS protocol request.
+const SecFetchModeCORS SecFetchModeValue = "cors"
+
+// SecFetchModeNavigate is the SecFetchModeValue of a request navigating between documents.
+const SecFetchModeNavigate SecFetchModeValue = "navigate"
+
+// SecFetchModeNoCORS is the SecFetchModeValue of a no-cors request, such as an image or script load.
+const SecFetchModeNoCORS SecFetchModeValue = "no-cors"
+
+// SecFetchModeSameOrigin is the SecFetchModeValue of a request that may only be made to the same origin.
+const SecFetchModeSameOrigin SecFetchModeValue = "same-origin"
+
+// SecFetchModeWebSocket is the SecFetchModeValue of a request establishing a WebSocket connection.
+const SecFetchModeWebSocket SecFetchModeValue = "websocket"
+
+// SecFetchSiteCrossSite is the SecFetchSiteValue of a request initiated by another site.
+const SecFetchSiteCrossSite SecFetchSiteValue = "cross-site"
+
+// SecFetchSiteNone is the SecFetchSiteValue of a request initiated by the user, such as typing a URL or opening a bookmark.
+const SecFetchSiteNone SecFetchSiteValue = "none"
+
+// SecFetchSiteSameOrigin is the SecFetchSiteValue of a request initiated by the same origin.
+const SecFetchSiteSameOrigin SecFetchSiteValue = "same-origin"
+
+// SecFetchSiteSameSite is the SecFetchSiteValue of a request initiated by another origin of the same site.
+const SecFetchSiteSameSite SecFetchSiteValue = "same-site"
+
+// TkDisregardingDoNotTrack is the TkValue of an origin server that is disregarding the DNT header.
+const TkDisregardingDoNotTrack TkValue = "D"
+
+// TkDynamic is the TkValue of an origin server whose tracking status depends on the request.
+const TkDynamic TkValue = "?"
+
+// TkGateway is the TkValue of a gateway to multiple parties.
+const TkGateway TkValue = "G"
+
+// TkNotTracking is the TkValue of an origin server that is not tracking.
+const TkNotTracking TkValue = "N"
+
+// TkPotentialConsent is the TkValue of an origin server that believes it has consent to track.
+const TkPotentialConsent TkValue = "P"
+
+// TkTracking is the TkValue of an origin server that is tracking.
+const TkTracking TkValue = "T"
+
+// TkTrackingWithConsent is the TkValue of an origin server that is tracking with consent.
+const TkTrackingWithConsent TkValue = "C"
+
+// TkUnderConstruction is the TkValue of an origin server whose tracking status is under construction.
+const TkUnderConstruction TkValue = "!"
+
+// TkUpdated is the TkValue of an origin server whose tracking status has been updated.
+const TkUpdated TkValue = "U"
+
+// ConnectionValue is a value of a Connection HTTP header.
+type ConnectionValue string
+
+// CookiePriorityValue is a value of the Priority attribute of a Set-Cookie HTTP header.
+type CookiePriorityValue string
+
+// CrossOriginEmbedderPolicyValue is a value of a Cross-Origin-Embedder-Policy HTTP header.
+type CrossOriginEmbedderPolicyValue string
+
+// CrossOriginResourcePolicyValue is a value of a Cross-Origin-Resource-Policy HTTP header.
+type CrossOriginResourcePolicyValue string
+
+// ReferrerPolicyValue is a value of a Referrer-Policy HTTP header.
+type ReferrerPolicyValue string
+
+// SameSiteValue is a value of the SameSite attribute of a Set-Cookie HTTP header.
+type SameSiteValue string
+
+// SecFetchDestValue is a value of a Sec-Fetch-Dest HTTP header.
+type SecFetchDestValue string
+
+// SecFetchModeValue is a value of a Sec-Fetch-Mode HTTP header.
+type SecFetchModeValue string
+
+// SecFetchSiteValue is a value of a Sec-Fetch-Site HTTP header.
+type SecFetchSiteValue string
+
+// TkValue is a value of a Tk HTTP header.
+type TkValue string
+
+var connectionValues = map[ConnectionValue]bool{
+	ConnectionClose:     true,
+	ConnectionKeepAlive: true}
+
+var cookiePriorityValues = map[CookiePriorityValue]bool{
+	CookiePriorityHigh:   true,
+	CookiePriorityLow:    true,
+	CookiePriorityMedium: true}
+
+var crossOriginEmbedderPolicyValues = map[CrossOriginEmbedderPolicyValue]bool{
+	CrossOriginEmbedderPolicyCredentialless: true,
+	CrossOriginEmbedderPolicyRequireCorp:    true,
+	CrossOriginEmbedderPolicyUnsafeNone:     true}
+
+var crossOriginResourcePolicyValues = map[CrossOriginResourcePolicyValue]bool{
+	CrossOriginResourcePolicyCrossOrigin: true,
+	CrossOriginResourcePolicySameOrigin:  true,
+	CrossOriginResourcePolicySameSite:    true}
+
+var referrerPolicyValues = map[ReferrerPolicyValue]bool{
+	ReferrerPolicyNoReferrer:                  true,
+	ReferrerPolicyNoReferrerWhenDowngrade:     true,
+	ReferrerPolicyOrigin:                      true,
+	ReferrerPolicyOriginWhenCrossOrigin:       true,
+	ReferrerPolicySameOrigin:                  true,
+	ReferrerPolicyStrictOrigin:                true,
+	ReferrerPolicyStrictOriginWhenCrossOrigin: true,
+	ReferrerPolicyUnsafeURL:                   true}
+
+var sameSiteValues = map[SameSiteValue]bool{
+	SameSiteLax:    true,
+	SameSiteNone:   true,
+	SameSiteStrict: true}
+
+var secFetchDestValues = map[SecFetchDestValue]bool{
+	SecFetchDestAudio:         true,
+	SecFetchDestAudioWorklet:  true,
+	SecFetchDestDocument:      true,
+	SecFetchDestEmbed:         true,
+	SecFetchDestEmpty:         true,
+	SecFetchDestFencedFrame:   true,
+	SecFetchDestFont:          true,
+	SecFetchDestFrame:         true,
+	SecFetchDestIFrame:        true,
+	SecFetchDestImage:         true,
+	SecFetchDestJSON:          true,
+	SecFetchDestManifest:      true,
+	SecFetchDestObject:        true,
+	SecFetchDestPaintWorklet:  true,
+	SecFetchDestReport:        true,
+	SecFetchDestScript:        true,
+	SecFetchDestServiceWorker: true,
+	SecFetchDestSharedWorker:  true,
+	SecFetchDestStyle:         true,
+	SecFetchDestTrack:         true,
+	SecFetchDestVideo:         true,
+	SecFetchDestWebIdentity:   true,
+	SecFetchDestWorker:        true,
+	SecFetchDestXSLT:          true}
+
+var secFetchModeValues = map[SecFetchModeValue]bool{
+	SecFetchModeCORS:       true,
+	SecFetchModeNavigate:   true,
+	SecFetchModeNoCORS:     true,
+	SecFetchModeSameOrigin: true,
+	SecFetchModeWebSocket:  true}
+
+var secFetchSiteValues = map[SecFetchSiteValue]bool{
+	SecFetchSiteCrossSite:  true,
+	SecFetchSiteNone:       true,
+	SecFetchSiteSameOrigin: true,
+	SecFetchSiteSameSite:   true}
+
+var tkValues = map[TkValue]bool{
+	TkDisregardingDoNotTrack: true,
+	TkDynamic:                true,
+	TkGateway:                true,
+	TkNotTracking:            true,
+	TkPotentialConsent:       true,
+	TkTracking:               true,
+	TkTrackingWithConsent:    true,
+	TkUnderConstruction:      true,
+	TkUpdated:                true}
+
+// ParseConnection parses a Connection HTTP header value, a comma-separated list of case-insensitive connection
+// options, into whether the connection persists: ConnectionClose when the list has the close option, and
+// ConnectionKeepAlive otherwise. ParseConnectionHeader returns the other connection options, such as "upgrade".
+func ParseConnection(s string) (ConnectionValue, error) {
+	var c, err = ParseConnectionHeader(s)
+	if err != nil {
+		return "", err
+	}
+	return c.Value(), nil
+}
+
+// ParseCookiePriority parses the value of the Priority attribute of a Set-Cookie HTTP header. The value is
+// case-insensitive.
+func ParseCookiePriority(s string) (CookiePriorityValue, error) {
+	for p := range cookiePriorityValues {
+		if strings.EqualFold(string(p), strings.TrimSpace(s)) {
+			return p, nil
+		}
+	}
+	return "", &HeaderValueError{Header: SetCookie, Value: s}
+}
+
+// ParseCrossOriginEmbedderPolicy parses a Cross-Origin-Embedder-Policy HTTP header value.
+func ParseCrossOriginEmbedderPolicy(s string) (CrossOriginEmbedderPolicyValue, error) {
+	var c CrossOriginEmbedderPolicyValue = CrossOriginEmbedderPolicyValue(strings.TrimSpace(s))
+	if !c.Valid() {
+		return "", &HeaderValueError{Header: CrossOriginEmbedderPolicy, Value: s}
+	}
+	return c, nil
+}
+
+// ParseCrossOriginResourcePolicy parses a Cross-Origin-Resource-Policy HTTP header value.
+func ParseCrossOriginResourcePolicy(s string) (CrossOriginResourcePolicyValue, error) {
+	var c CrossOriginResourcePolicyValue = CrossOriginResourcePolicyValue(strings.TrimSpace(s))
+	if !c.Valid() {
+		return "", &HeaderValueError{Header: CrossOriginResourcePolicy, Value: s}
+	}
+	return c, nil
+}
+
+// ParseReferrerPolicy parses a single Referrer-Policy token. Referrer policy tokens are case-insensitive.
+func ParseReferrerPolicy(s string) (ReferrerPolicyValue, error) {
+	var r ReferrerPolicyValue = ReferrerPolicyValue(strings.ToLower(strings.TrimSpace(s)))
+	if !r.Valid() {
+		return "", &HeaderValueError{Header: ReferrerPolicy, Value: s}
+	}
+	return r, nil
+}
+
+// ParseSameSite parses the value of the SameSite attribute of a Set-Cookie HTTP header. The value is
+// case-insensitive.
+func ParseSameSite(s string) (SameSiteValue, error) {
+	for v := range sameSiteValues {
+		if strings.EqualFold(string(v), strings.TrimSpace(s)) {
+			return v, nil
+		}
+	}
+	return "", &HeaderValueError{Header: SetCookie, Value: s}
+}
+
+// ParseSecFetchDest parses a Sec-Fetch-Dest HTTP header value.
+func ParseSecFetchDest(s string) (SecFetchDestValue, error) {
+	var d SecFetchDestValue = SecFetchDestValue(strings.TrimSpace(s))
+	if !d.Valid() {
+		return "", &HeaderValueError{Header: SecFetchDest, Value: s}
+	}
+	return d, nil
+}
+
+// ParseSecFetchMode parses a Sec-Fetch-Mode HTTP header value.
+func ParseSecFetchMode(s string) (SecFetchModeValue, error) {
+	var m SecFetchModeValue = SecFetchModeValue(strings.TrimSpace(s))
+	if !m.Valid() {
+		return "", &HeaderValueError{Header: SecFetchMode, Value: s}
+	}
+	return m, nil
+}
+
+// ParseSecFetchSite parses a Sec-Fetch-Site HTTP header value.
+func ParseSecFetchSite(s string) (SecFetchSiteValue, error) {
+	var x SecFetchSiteValue = SecFetchSiteValue(strings.TrimSpace(s))
+	if !x.Valid() {
+		return "", &HeaderValueError{Header: SecFetchSite, Value: s}
+	}
+	return x, nil
+}
+
+// ParseTk parses a Tk HTTP header value.
+func ParseTk(s string) (TkValue, error) {
+	var t TkValue = TkValue(strings.TrimSpace(s))
+	if !t.Valid() {
+		return "", &HeaderValueError{Header: Tk, Value: s}
+	}
+	return t, nil
+}
+
+// String returns a string representation of a Connection HTTP header value.
+func (c ConnectionValue) String() string {
+	return string(c)
+}
+
+// Valid reports whether the ConnectionValue is one of the values defined by the Connection HTTP header.
+func (c ConnectionValue) Valid() bool {
+	return connectionValues[c]
+}
+
+// String returns a string representation of the Priority attribute of a Set-Cookie HTTP header.
+func (c CookiePriorityValue) String() string {
+	return string(c)
+}
+
+// Valid reports whether the CookiePriorityValue is one of the values of the Priority attribute.
+func (c CookiePriorityValue) Valid() bool {
+	return cookiePriorityValues[c]
+}
+
+// String returns a string representation of a Cross-Origin-Embedder-Policy HTTP header value.
+func (c CrossOriginEmbedderPolicyValue) String() string {
+	return string(c)
+}
+
+// Valid reports whether the CrossOriginEmbedderPolicyValue is one of the values defined by the Cross-Origin-Embedder-Policy HTTP header.
+func (c CrossOriginEmbedderPolicyValue) Valid() bool {
+	return crossOriginEmbedderPolicyValues[c]
+}
+
+// String returns a string representation of a Cross-Origin-Resource-Policy HTTP header value.
+func (c CrossOriginResourcePolicyValue) String() string {
+	return string(c)
+}
+
+// Valid reports whether the CrossOriginResourcePolicyValue is one of the values defined by the Cross-Origin-Resource-Policy HTTP header.
+func (c CrossOriginResourcePolicyValue) Valid() bool {
+	return crossOriginResourcePolicyValues[c]
+}
+
+// String returns a string representation of a Referrer-Policy HTTP header value.
+func (r ReferrerPolicyValue) String() string {
+	return string(r)
+}
+
+// Valid reports whether the ReferrerPolicyValue is one of the values defined by the Referrer-Policy HTTP header.
+func (r ReferrerPolicyValue) Valid() bool {
+	return referrerPolicyValues[r]
+}
+
+// String returns a string representation of the SameSite attribute of a Set-Cookie HTTP header.
+func (s SameSiteValue) String() string {
+	return string(s)
+}
+
+// Valid reports whether the SameSiteValue is one of the values of the SameSite attribute.
+func (s SameSiteValue) Valid() bool {
+	return sameSiteValues[s]
+}
+
+// String returns a string representation of a Sec-Fetch-Dest HTTP header value.
+func (s SecFetchDestValue) String() string {
+	return string(s)
+}
+
+// Valid reports whether the SecFetchDestValue is one of the values defined by the Sec-Fetch-Dest HTTP header.
+func (s SecFetchDestValue) Valid() bool {
+	return secFetchDestValues[s]
+}
+
+// String returns a string representation of a Sec-Fetch-Mode HTTP header value.
+func (s SecFetchModeValue) String() string {
+	return string(s)
+}
+
+// Valid reports whether the SecFetchModeValue is one of the values defined by the Sec-Fetch-Mode HTTP header.
+func (s SecFetchModeValue) Valid() bool {
+	return secFetchModeValues[s]
+}
+
+// String returns a string representation of a Sec-Fetch-Site HTTP header value.
+func (s SecFetchSiteValue) String() string {
+	return string(s)
+}
+
+// Valid reports whether the SecFetchSiteValue is one of the values defined by the Sec-Fetch-Site HTTP header.
+func (s SecFetchSiteValue) Valid() bool {
+	return secFetchSiteValues[s]
+}
+
+// String returns a string representation of a Tk HTTP header value.
+func (t TkValue) String() string {
+	return string(t)
+}
+
+// Valid reports whether the TkValue is one of the values defined by the Tk HTTP header.
+func (t TkValue) Valid() bool {
+	return tkValues[t]
+}
+
+// structValue returns the token of the only true bool field of a deprecated header struct, or an empty string
+// when no field is true. Tokens maps the field names of the struct to the token each field represents.
+func structValue(header string, v interface{}, tokens map[string]string) (string, error) {
+	var substrings ([]string) = (make([]string, 0))
+	var r reflect.Value = reflect.ValueOf(v)
+	var t reflect.Type = r.Type()
+	for i, n := 0, r.NumField(); i < n; i++ {
+		var f reflect.Value = r.Field(i)
+		if f.Kind() == reflect.Bool && f.Bool() {
+			(substrings) = (append(substrings, tokens[t.Field(i).Name]))
+		}
+	}
+	switch len(substrings) {
+	case 0:
+		return "", nil
+	case 1:
+		return substrings[0], nil
+	}
+	return "", &HeaderValueError{Header: header, Value: strings.Join(substrings, ", ")}
+}
@@ -0,0 +1,67 @@
+package w3g_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestParseValues(t *testing.T) {
+	if v, err := w3g.ParseSecFetchMode("websocket"); err != nil || v != w3g.SecFetchModeWebSocket {
+		t.Errorf("ParseSecFetchMode(websocket) = %q, %v", v, err)
+	}
+	if v, err := w3g.ParseReferrerPolicy(" Strict-Origin "); err != nil || v != w3g.ReferrerPolicyStrictOrigin {
+		t.Errorf("ParseReferrerPolicy(Strict-Origin) = %q, %v", v, err)
+	}
+	if v, err := w3g.ParseConnection("Keep-Alive"); err != nil || v != w3g.ConnectionKeepAlive {
+		t.Errorf("ParseConnection(Keep-Alive) = %q, %v", v, err)
+	}
+	if v, err := w3g.ParseConnection("keep-alive, Upgrade"); err != nil || v != w3g.ConnectionKeepAlive {
+		t.Errorf("ParseConnection(keep-alive, Upgrade) = %q, %v", v, err)
+	}
+	if v, err := w3g.ParseConnection("Upgrade, Close"); err != nil || v != w3g.ConnectionClose {
+		t.Errorf("ParseConnection(Upgrade, Close) = %q, %v", v, err)
+	}
+	if _, err := w3g.ParseConnection("keep alive"); err == nil {
+		t.Error("ParseConnection(keep alive) should fail")
+	}
+	if v := (w3g.ConnectionHeader{Options: []string{"upgrade", "Close"}}).Value(); v != w3g.ConnectionClose {
+		t.Errorf("ConnectionHeader{Options: upgrade, Close}.Value() = %q", v)
+	}
+	if v, err := w3g.ParseSameSite("none"); err != nil || v != w3g.SameSiteNone {
+		t.Errorf("ParseSameSite(none) = %q, %v", v, err)
+	}
+	if v, err := w3g.ParseCookiePriority("HIGH"); err != nil || v != w3g.CookiePriorityHigh {
+		t.Errorf("ParseCookiePriority(HIGH) = %q, %v", v, err)
+	}
+	var err error
+	var target *w3g.HeaderValueError
+	if _, err = w3g.ParseSecFetchSite("cors, navigate"); !errors.As(err, &target) || target.Header != w3g.SecFetchSite {
+		t.Errorf("ParseSecFetchSite(cors, navigate) error = %v", err)
+	}
+	if _, err = w3g.ParseCrossOriginResourcePolicy("Same-Site"); err == nil {
+		t.Error("ParseCrossOriginResourcePolicy(Same-Site) should be case-sensitive")
+	}
+	if _, err = w3g.ParseTk("X"); err == nil {
+		t.Error("ParseTk(X) should fail")
+	}
+}
+
+func TestDeprecatedHeaderValue(t *testing.T) {
+	if v, err := (w3g.SecFetchModeHeader{WebSocket: true}).Value(); err != nil || v != w3g.SecFetchModeWebSocket {
+		t.Errorf("SecFetchModeHeader{WebSocket}.Value() = %q, %v", v, err)
+	}
+	if _, err := (w3g.SecFetchModeHeader{Cors: true, Navigate: true}).Value(); err == nil {
+		t.Error("SecFetchModeHeader{Cors, Navigate}.Value() should fail")
+	}
+	if v, err := (w3g.CrossOriginResourcePolicyHeader{}).Value(); err != nil || len(v) != 0 {
+		t.Errorf("CrossOriginResourcePolicyHeader{}.Value() = %q, %v", v, err)
+	}
+	if v, err := (w3g.TkHeader{}).Value(); err != nil || v != w3g.TkUnderConstruction {
+		t.Errorf("TkHeader{}.Value() = %q, %v", v, err)
+	}
+	if v, err := (w3g.ReferrerPolicyHeader{StrictOriginWhenCrossOrigin: true}).Value(); err != nil || v != w3g.ReferrerPolicyStrictOriginWhenCrossOrigin {
+		t.Errorf("ReferrerPolicyHeader{StrictOriginWhenCrossOrigin}.Value() = %q, %v", v, err)
+	}
+}
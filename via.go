@@ -0,0 +1,176 @@
+package w3g
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// commentReplacer escapes the characters of a comment as defined by RFC 9110 that are sent as quoted-pairs.
+var commentReplacer = strings.NewReplacer("\\", "\\\\", "(", "\\(", ")", "\\)")
+
+// ViaChain is a struct to hold the hops of the Via HTTP headers of a message, from the first recipient to the last.
+type ViaChain struct {
+	Hops []ViaHeader `json:"hops"`
+}
+
+// ParseVia parses a Via HTTP header value, a comma-separated list of hops. A recipient with a port, or an IP literal,
+// is parsed as a Host and Port, and any other recipient as a Pseudonym.
+func ParseVia(s string) (ViaChain, error) {
+	var v ViaChain
+	for _, hop := range splitComments(s, ',') {
+		if len(hop) == 0 {
+			continue
+		}
+		var h, ok = parseViaHop(hop)
+		if !ok {
+			return ViaChain{}, &HeaderValueError{Header: Via, Value: s}
+		}
+		(v.Hops) = (append(v.Hops, h))
+	}
+	return v, nil
+}
+
+// ParseViaChain parses the Via HTTP headers of a message, in order.
+func ParseViaChain(h http.Header) (ViaChain, error) {
+	return ParseVia(strings.Join(h.Values(Via), ", "))
+}
+
+// Contains reports whether a recipient, a pseudonym or a host with an optional port, is a hop of a ViaChain,
+// ignoring case. A proxy that finds its own pseudonym in the Via HTTP headers of a request is in a loop.
+func (v ViaChain) Contains(recipient string) bool {
+	for _, hop := range v.Hops {
+		if strings.EqualFold(hop.recipient(), recipient) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns a string representation of a Via HTTP header.
+func (v ViaChain) String() string {
+	var substrings ([]string) = (make([]string, 0, len(v.Hops)))
+	for _, hop := range v.Hops {
+		(substrings) = (append(substrings, hop.String()))
+	}
+	return strings.Join(substrings, ", ")
+}
+
+// recipient returns the received-by of a hop of a Via HTTP header.
+func (v ViaHeader) recipient() string {
+	if len(v.Host) == 0 {
+		return v.Pseudonym
+	}
+	var host string = v.Host
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if len(v.Port) != 0 {
+		host += ":" + v.Port
+	}
+	return host
+}
+
+// parseComment parses a comment as defined by RFC 9110, returning its text without the outer parentheses and with
+// quoted-pairs unescaped. Nested comments are kept as they are.
+func parseComment(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '(' || s[len(s)-1] != ')' {
+		return "", false
+	}
+	var b strings.Builder
+	var depth int
+	for i := 1; i < len(s)-1; i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s)-1:
+			i++
+			b.WriteByte(s[i])
+		case c == '(':
+			depth++
+			b.WriteByte(c)
+		case c == ')':
+			if depth--; depth < 0 {
+				return "", false
+			}
+			b.WriteByte(c)
+		case c == '\r' || c == '\n' || c == 0:
+			return "", false
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), depth == 0
+}
+
+// parseViaHop parses a hop of a Via HTTP header.
+func parseViaHop(s string) (ViaHeader, bool) {
+	var v ViaHeader
+	if i := strings.IndexByte(s, '('); i != -1 {
+		var comment, ok = parseComment(strings.TrimSpace(s[i:]))
+		if !ok {
+			return ViaHeader{}, false
+		}
+		v.Comment = comment
+		s = s[:i]
+	}
+	var fields []string = strings.Fields(s)
+	if len(fields) != 2 {
+		return ViaHeader{}, false
+	}
+	if name, version, ok := strings.Cut(fields[0], "/"); ok {
+		v.ProtocolName = name
+		v.ProtocolVersion = version
+		if !isToken(name) {
+			return ViaHeader{}, false
+		}
+	} else {
+		v.ProtocolVersion = name
+	}
+	if !isToken(v.ProtocolVersion) {
+		return ViaHeader{}, false
+	}
+	switch host, port, err := net.SplitHostPort(fields[1]); {
+	case err == nil:
+		if len(host) == 0 || strings.Trim(port, "0123456789") != "" {
+			return ViaHeader{}, false
+		}
+		v.Host = host
+		v.Port = port
+	case strings.HasPrefix(fields[1], "[") && strings.HasSuffix(fields[1], "]"):
+		if net.ParseIP(fields[1][1:len(fields[1])-1]) == nil {
+			return ViaHeader{}, false
+		}
+		v.Host = fields[1][1 : len(fields[1])-1]
+	case isToken(fields[1]):
+		v.Pseudonym = fields[1]
+	default:
+		return ViaHeader{}, false
+	}
+	return v, true
+}
+
+// splitComments splits a string at every separator that is not inside a comment as defined by RFC 9110, trimming
+// optional whitespace from each part.
+func splitComments(s string, sep byte) []string {
+	var parts []string
+	var depth, start int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if depth > 0 {
+				i++
+			}
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case sep:
+			if depth == 0 {
+				(parts) = (append(parts, strings.TrimSpace(s[start:i])))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
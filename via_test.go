@@ -0,0 +1,81 @@
+package w3g_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gellel/w3g"
+)
+
+func TestViaHeader(t *testing.T) {
+	for _, test := range []struct {
+		via  w3g.ViaHeader
+		want string
+	}{
+		{w3g.ViaHeader{ProtocolVersion: "1.0", Pseudonym: "fred"}, "1.0 fred"},
+		{w3g.ViaHeader{ProtocolName: "HTTP", ProtocolVersion: "1.1", Host: "p.example.net", Port: "8080"}, "HTTP/1.1 p.example.net:8080"},
+		{w3g.ViaHeader{ProtocolVersion: "2", Host: "2001:db8::1", Comment: "edge (a) \\ b"}, "2 [2001:db8::1] (edge \\(a\\) \\\\ b)"},
+	} {
+		if got := test.via.String(); got != test.want {
+			t.Errorf("String() = %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestParseVia(t *testing.T) {
+	var v, err = w3g.ParseVia("1.0 fred, 1.1 p.example.net:8080 (Apache/1.1, (nested)), HTTP/2 [::1], SHTTP/1.3 proxy (\\(x\\))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []w3g.ViaHeader = []w3g.ViaHeader{
+		{ProtocolVersion: "1.0", Pseudonym: "fred"},
+		{ProtocolVersion: "1.1", Host: "p.example.net", Port: "8080", Comment: "Apache/1.1, (nested)"},
+		{ProtocolName: "HTTP", ProtocolVersion: "2", Host: "::1"},
+		{ProtocolName: "SHTTP", ProtocolVersion: "1.3", Pseudonym: "proxy", Comment: "(x)"},
+	}
+	if len(v.Hops) != len(want) {
+		t.Fatalf("ParseVia() = %+v", v.Hops)
+	}
+	for i := range want {
+		if v.Hops[i] != want[i] {
+			t.Errorf("hop %d = %+v, want %+v", i, v.Hops[i], want[i])
+		}
+	}
+	if got := v.String(); got != "1.0 fred, 1.1 p.example.net:8080 (Apache/1.1, \\(nested\\)), HTTP/2 [::1], SHTTP/1.3 proxy (\\(x\\))" {
+		t.Errorf("String() = %q", got)
+	}
+	if !v.Contains("FRED") || !v.Contains("p.example.net:8080") || !v.Contains("[::1]") || v.Contains("p.example.net") {
+		t.Error("Contains() did not match the recipients")
+	}
+	for _, invalid := range []string{"1.1", "1.1 a b", "1.1 fred (unclosed", "1.1 host:port", "/1.1 fred"} {
+		if _, err := w3g.ParseVia(invalid); err == nil {
+			t.Errorf("ParseVia(%q) did not return an error", invalid)
+		}
+	}
+}
+
+func TestReverseProxyLoop(t *testing.T) {
+	var backend *httptest.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(w3g.Via)))
+	}))
+	defer backend.Close()
+	var target, _ = url.Parse(backend.URL)
+	var frontend *httptest.Server = httptest.NewServer(&w3g.ReverseProxy{Pseudonym: "gateway", Target: target})
+	defer frontend.Close()
+	for via, code := range map[string]int{"": http.StatusOK, "1.1 edge": http.StatusOK, "1.1 edge, 1.1 gateway": http.StatusLoopDetected} {
+		var r, _ = http.NewRequest(http.MethodGet, frontend.URL, nil)
+		if len(via) != 0 {
+			r.Header.Set(w3g.Via, via)
+		}
+		var res, err = http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != code {
+			t.Errorf("Via %q: status = %d, want %d", via, res.StatusCode, code)
+		}
+	}
+}
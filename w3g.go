@@ -10,6 +10,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/gellel/w3g/sfv"
 )
 
 // Accept request HTTP header advertises which content types, expressed as MIME types, the client is able to understand.
@@ -108,6 +110,9 @@ const CloudfrontViewerCountry string = "Cloudfront-Viewer-Country"
 // Connection HTTP header controls whether or not the network connection stays open after the current transaction finishes.
 const Connection string = "Connection"
 
+// ContentDigest HTTP header contains a digest of the content of a message, as defined by RFC 9530.
+const ContentDigest string = "Content-Digest"
+
 // ContentDisposition HTTP response header is a header indicating if the content is expected to be displayed inline in the browser,
 // that is, as a Web page or as part of a Web page, or as an attachment, that is downloaded and saved locally.
 const ContentDisposition string = "Content-Disposition"
@@ -150,6 +155,9 @@ const Cookie string = "Cookie"
 // Cookie2 HTTP request header used to advise the server that the user agent understands "new-style" cookies.
 const Cookie2 string = "Cookie2"
 
+// CrossOriginEmbedderPolicy HTTP response header prevents a document from loading any cross-origin resources that don't explicitly grant the document permission.
+const CrossOriginEmbedderPolicy string = "Cross-Origin-Embedder-Policy"
+
 // CrossOriginResourcePolicy HTTP response header conveys a desire that the browser blocks no-cors cross-origin/cross-site requests to the given resource.
 const CrossOriginResourcePolicy string = "Cross-Origin-Resource-Policy"
 
@@ -170,6 +178,8 @@ const Date string = "Date"
 const DeviceMemory string = "Device-Memory"
 
 // Digest response HTTP header provides a digest of the requested resource.
+//
+// Deprecated: RFC 9530 obsoletes the Digest HTTP header; use ContentDigest or ReprDigest.
 const Digest string = "Digest"
 
 // ETag HTTP response header is an identifier for a specific version of a resource.
@@ -241,6 +251,9 @@ const Origin string = "Origin"
 
 const P3P string = "P3P"
 
+// PermissionsPolicy HTTP header provides a mechanism to allow and deny the use of browser features in a document and in the frames it embeds.
+const PermissionsPolicy string = "Permissions-Policy"
+
 // Pragma HTTP header is an implementation-specific header that is used for backwards compatibility with HTTP/1.0.
 const Pragma string = "Pragma"
 
@@ -268,6 +281,9 @@ const ReferrerPolicy string = "Referrer-Policy"
 // Refresh response HTTP header controls when a new resource has been created and the time until redirection.
 const Refresh string = "Refresh"
 
+// ReprDigest HTTP header contains a digest of the selected representation of a resource, as defined by RFC 9530.
+const ReprDigest string = "Repr-Digest"
+
 // RetryAfter response HTTP header indicates how long the user agent should wait before making a follow-up request.
 const RetryAfter string = "Retry-After"
 
@@ -301,6 +317,13 @@ const SetCookie string = "Set-Cookie"
 // SetCookie2 HTTP response header used to send cookies from the server to the user agent.
 const SetCookie2 string = "Set-Cookie2"
 
+// Signature HTTP header contains the HTTP Message Signatures of a message, as defined by RFC 9421.
+const Signature string = "Signature"
+
+// SignatureInput HTTP header contains the covered components and parameters of the HTTP Message Signatures of a
+// message, as defined by RFC 9421.
+const SignatureInput string = "Signature-Input"
+
 // SourceMap HTTP response header links generated code to a source map, enabling the browser to reconstruct the original source and present
 // the reconstructed original in the debugger.
 const SourceMap string = "SourceMap"
@@ -325,6 +348,10 @@ const Trailer string = "Trailer"
 // TransferEncoding response HTTP header specifies the form of encoding used to safely transfer the payload body to the user.
 const TransferEncoding string = "Transfer-Encoding"
 
+// Upgrade HTTP header asks the server to switch the connection to another protocol, and names the protocol a server
+// switches to in a 101 Switching Protocols response.
+const Upgrade string = "Upgrade"
+
 // UpgradeInsecureRequests request HTTP header sends a signal to the server expressing the client’s preference for an encrypted and authenticated response.
 const UpgradeInsecureRequests string = "Upgrade-Insecure-Requests"
 
@@ -343,9 +370,17 @@ const Via string = "Via"
 // WWWAuthenticate response HTTP header defines the authentication method that should be used to gain access to a resource.
 const WWWAuthenticate string = "WWW-Authenticate"
 
+// WantContentDigest HTTP header indicates the digest algorithms a sender would like to receive in a Content-Digest HTTP header.
+const WantContentDigest string = "Want-Content-Digest"
+
 // WantDigest HTTP header is used to ask the responder to provide a digest of the requested resource.
+//
+// Deprecated: RFC 9530 obsoletes the Want-Digest HTTP header; use WantContentDigest or WantReprDigest.
 const WantDigest string = "Want-Digest"
 
+// WantReprDigest HTTP header indicates the digest algorithms a sender would like to receive in a Repr-Digest HTTP header.
+const WantReprDigest string = "Want-Repr-Digest"
+
 // Warning HTTP header contains information about possible problems with the status of the message.
 const Warning string = "Warning"
 
@@ -521,7 +556,7 @@ func (a AcceptLanguageHeader) String() string {
 	if qOK {
 		(substrings) = (append(substrings, fmt.Sprintf("q=%1.1f", a.Q)))
 	}
-	s = (strings.Join(substrings, "/"))
+	s = (strings.Join(substrings, ";"))
 	return s
 }
 
@@ -702,7 +737,7 @@ func (a AltSvcHeader) String() string {
 	if a.Clear {
 		return s
 	}
-	s = (fmt.Sprintf(("%s=\"%s\""), a.ProtocolID, a.AltAuthority))
+	s = (fmt.Sprintf(("%s=%s"), a.ProtocolID, quoteString(a.AltAuthority)))
 	if a.MaxAge != 0 {
 		s = (fmt.Sprintf(("%s;%d"), s, a.MaxAge))
 	}
@@ -798,58 +833,107 @@ func (c ClearSiteDataHeader) String() string {
 	return s
 }
 
-// ConnectionHeader is a struct to prepare a Connection HTTP header.
+// ConnectionHeader is a struct to prepare a Connection HTTP header. Options are the other connection options, in lower
+// case, such as "keep-alive", "upgrade" or the names of hop-by-hop HTTP headers.
 type ConnectionHeader struct {
-	Close bool `json:"close"`
+	Close   bool     `json:"close"`
+	Options []string `json:"options"`
 }
 
 // String returns a string representation of a Connection HTTP header value.
 func (c ConnectionHeader) String() string {
+	if len(c.Options) == 0 {
+		if c.Close {
+			return "close"
+		}
+		return "keep-alive"
+	}
+	var substrings ([]string) = (make([]string, 0, len(c.Options)+1))
 	if c.Close {
-		return "close"
+		(substrings) = (append(substrings, "close"))
+	}
+	(substrings) = (append(substrings, c.Options...))
+	return strings.Join(substrings, ", ")
+}
+
+// Has reports whether a Connection HTTP header has a connection option, ignoring case.
+func (c ConnectionHeader) Has(option string) bool {
+	if strings.EqualFold(option, "close") && c.Close {
+		return true
 	}
-	return "keep-alive"
+	for _, o := range c.Options {
+		if strings.EqualFold(o, option) {
+			return true
+		}
+	}
+	return false
 }
 
-// ContentDispositionHeader is a struct to prepare a Content-Disposition HTTP header.
+// Value returns whether the connection of a Connection HTTP header persists: ConnectionClose when it has the close
+// option, and ConnectionKeepAlive otherwise. The other connection options are in Options.
+func (c ConnectionHeader) Value() ConnectionValue {
+	if c.Close {
+		return ConnectionClose
+	}
+	for _, o := range c.Options {
+		if strings.EqualFold(o, "close") {
+			return ConnectionClose
+		}
+	}
+	return ConnectionKeepAlive
+}
+
+// ContentDispositionHeader is a struct to prepare a Content-Disposition HTTP header. A FileName that is not printable
+// ASCII is sent as a filename* parameter, with an ASCII filename parameter for user agents that do not support it.
 type ContentDispositionHeader struct {
 	Attachment bool   `json:"attachment"`
 	FileName   string `json:"file_name"`
 	FormData   bool   `json:"form_data"`
+	Inline     bool   `json:"inline"`
 	Name       string `json:"name"`
 }
 
-// String returns a string representation of a Content-Disposition HTTP header value.
+// String returns a string representation of a Content-Disposition HTTP header value. The inline disposition type
+// is used when no disposition type is set.
 func (c ContentDispositionHeader) String() string {
 	var substrings ([]string) = (make([]string, 0))
 	var s string
 	if c.Attachment {
 		(substrings) = (append(substrings, ("attachment")))
-	}
-	if !c.Attachment && c.FormData {
+	} else if c.FormData {
 		(substrings) = (append(substrings, ("form-data")))
-	}
-	if len(c.FileName) != 0 {
-		(substrings) = (append(substrings, fmt.Sprintf("filename=\"%s\"", c.FileName)))
+	} else {
+		(substrings) = (append(substrings, ("inline")))
 	}
 	if len(c.Name) != 0 {
-		(substrings) = (append(substrings, fmt.Sprintf("name=\"%s\"", c.Name)))
+		(substrings) = (append(substrings, ("name=" + quoteString(escapeFormDataName(c.Name)))))
+	}
+	if len(c.FileName) != 0 {
+		(substrings) = (append(substrings, ("filename=" + quoteString(asciiFileName(c.FileName)))))
+		if !isPrintableASCII(c.FileName) {
+			(substrings) = (append(substrings, ("filename*=" + encodeExtValue(c.FileName))))
+		}
 	}
 	s = (strings.Join(substrings, "; "))
 	return s
 }
 
-// ContentEncodingHeader is a struct to prepare a Content-Encoding HTTP header.
+// ContentEncodingHeader is a struct to prepare a Content-Encoding HTTP header. Codings lists the content codings in the
+// order they were applied and takes precedence over the bool fields, which cannot express an order.
 type ContentEncodingHeader struct {
-	Br       bool `json:"br"`
-	Compress bool `json:"compress"`
-	Deflate  bool `json:"deflate"`
-	Identity bool `json:"identity"`
-	GZip     bool `json:"gizp"`
+	Br       bool     `json:"br"`
+	Codings  []string `json:"codings"`
+	Compress bool     `json:"compress"`
+	Deflate  bool     `json:"deflate"`
+	Identity bool     `json:"identity"`
+	GZip     bool     `json:"gizp"`
 }
 
 // String returns a string representation of a Content-Encoding HTTP header value.
 func (c ContentEncodingHeader) String() string {
+	if len(c.Codings) != 0 {
+		return (strings.Join(c.Codings, ", "))
+	}
 	var substrings ([]string) = (make([]string, 0))
 	var s string
 	if c.Br {
@@ -901,11 +985,17 @@ func (c ContentLocationHeader) String() string {
 	return c.URL
 }
 
-// ContentMD5Header is a struct to prepare a Content-MD5 HTTP header.
+// ContentMD5Header is a struct to prepare a Content-MD5 HTTP header. MD5 holds the base64 encoding of the MD5 digest
+// of the body.
 type ContentMD5Header struct {
 	MD5 string `json:"md5"`
 }
 
+// String returns a string representation of a Content-MD5 HTTP header.
+func (c ContentMD5Header) String() string {
+	return c.MD5
+}
+
 // ContentRangeHeader is a struct to prepare a Content-Range HTTP header.
 type ContentRangeHeader struct {
 	RangeEnd   int64  `json:"range_end"`
@@ -997,7 +1087,40 @@ func (c CookieHeader) String() string {
 	return s
 }
 
+// CrossOriginEmbedderPolicyHeader is a struct to prepare a Cross-Origin-Embedder-Policy HTTP header.
+//
+// Deprecated: use CrossOriginEmbedderPolicyValue, which cannot hold more than one policy.
+type CrossOriginEmbedderPolicyHeader struct {
+	Credentialless bool `json:"credentialless"`
+	RequireCorp    bool `json:"require_corp"`
+}
+
+// String returns a string representation of a Cross-Origin-Embedder-Policy HTTP header.
+func (c CrossOriginEmbedderPolicyHeader) String() string {
+	if c.RequireCorp {
+		return "require-corp"
+	}
+	if c.Credentialless {
+		return "credentialless"
+	}
+	return "unsafe-none"
+}
+
+// Value returns the CrossOriginEmbedderPolicyValue of a Cross-Origin-Embedder-Policy HTTP header.
+// An error is returned when more than one policy is set.
+func (c CrossOriginEmbedderPolicyHeader) Value() (CrossOriginEmbedderPolicyValue, error) {
+	var s, err = structValue(CrossOriginEmbedderPolicy, c, map[string]string{
+		"Credentialless": "credentialless",
+		"RequireCorp":    "require-corp"})
+	if len(s) == 0 && err == nil {
+		return CrossOriginEmbedderPolicyUnsafeNone, nil
+	}
+	return CrossOriginEmbedderPolicyValue(s), err
+}
+
 // CrossOriginResourcePolicyHeader is a struct to prepare a Cross-Origin-Resource-Policy HTTP header.
+//
+// Deprecated: use CrossOriginResourcePolicyValue, which cannot hold more than one policy.
 type CrossOriginResourcePolicyHeader struct {
 	CrossOrigin bool `json:"cross_origin"`
 	SameOrigin  bool `json:"same_origin"`
@@ -1018,6 +1141,16 @@ func (c CrossOriginResourcePolicyHeader) String() string {
 	return "*"
 }
 
+// Value returns the CrossOriginResourcePolicyValue of a Cross-Origin-Resource-Policy HTTP header, or an empty
+// CrossOriginResourcePolicyValue when no policy is set. An error is returned when more than one policy is set.
+func (c CrossOriginResourcePolicyHeader) Value() (CrossOriginResourcePolicyValue, error) {
+	var s, err = structValue(CrossOriginResourcePolicy, c, map[string]string{
+		"CrossOrigin": "cross-origin",
+		"SameOrigin":  "same-origin",
+		"SameSite":    "same-site"})
+	return CrossOriginResourcePolicyValue(s), err
+}
+
 // DNTHeader is a struct to prepare a DNT HTTP header.
 type DNTHeader struct {
 	DNT bool `json:"dnt"`
@@ -1063,6 +1196,8 @@ func (d DeviceMemoryHeader) String() string {
 }
 
 // DigestHeader is a struct to prepare a Digest HTTP header.
+//
+// Deprecated: RFC 9530 obsoletes the Digest HTTP header; use IntegrityHeader.
 type DigestHeader struct {
 	Algorithm string `json:"algorithm"`
 	Value     string `json:"value"`
@@ -1128,7 +1263,7 @@ func (e ExpectCTHeader) String() string {
 		(substrings) = (append(substrings, "enforce"))
 	}
 	if !reflect.ValueOf(e.ReportURI).IsZero() {
-		(substrings) = (append(substrings, fmt.Sprintf("report-uri=\"%s\"", e.ReportURI)))
+		(substrings) = (append(substrings, fmt.Sprintf("report-uri=%s", quoteString(e.ReportURI))))
 	}
 	(s) = (strings.Join(substrings, ", "))
 	return s
@@ -1178,7 +1313,6 @@ type FeaturePolicyHeader struct {
 
 // String returns a string representation of a Feature-Policy HTTP header.
 func (f FeaturePolicyHeader) String() string {
-	var regex *regexp.Regexp = regexp.MustCompile(`[A-Z][^A-Z]*`)
 	var substrings ([]string) = (make([]string, 0))
 	var s string
 	var r reflect.Value = reflect.ValueOf(f)
@@ -1186,11 +1320,10 @@ func (f FeaturePolicyHeader) String() string {
 	for i, n := 0, r.NumField(); i < n; i++ {
 		var f reflect.Value = r.Field(i)
 		if f.IsValid() && !f.IsZero() {
-			var name string = strings.Join(regex.FindAllString(v.Field(i).Name, -1), "-")
-			name = strings.ToLower(name)
+			var name string = strings.ReplaceAll(v.Field(i).Tag.Get("json"), "_", "-")
 			switch f.Kind() {
 			case reflect.String:
-				(substrings) = (append(substrings, fmt.Sprintf("%s '%s'", name, f.String())))
+				(substrings) = (append(substrings, fmt.Sprintf("%s %s", name, featurePolicyAllowlist(f.String()))))
 			}
 		}
 	}
@@ -1198,31 +1331,34 @@ func (f FeaturePolicyHeader) String() string {
 	return s
 }
 
-// ForwardedHeader is a struct to prepare a Forwarded HTTP header.
+// ForwardedHeader is a struct to prepare an element of a Forwarded HTTP header. The client is Identifier, with the
+// optional Port, or For when it is not an IP address, such as "unknown" or an obfuscated identifier like "_hidden".
 type ForwardedHeader struct {
 	By         string `json:"by"`
+	For        string `json:"for"`
 	Host       string `json:"host"`
 	Identifier net.IP `json:"identifier"`
+	Port       string `json:"port"`
 	Proto      string `json:"proto"`
 }
 
+// String returns a string representation of a Forwarded HTTP header.
 func (f ForwardedHeader) String() string {
 	var substrings ([]string) = (make([]string, 0))
 	var s string
 	if !reflect.ValueOf(f.By).IsZero() {
-		(substrings) = (append(substrings, fmt.Sprintf("by=%s", f.By)))
+		(substrings) = (append(substrings, fmt.Sprintf("by=%s", tokenOrQuoted(f.By))))
 	}
-	if f.Identifier != nil {
-		if f.Identifier.To4() != nil {
-			(substrings) = (append(substrings, fmt.Sprintf("for=%s", f.Identifier.String())))
-		} else if f.Identifier.To16() != nil {
-			(substrings) = (append(substrings, fmt.Sprintf("for=\"[%s]\"", f.Identifier.String())))
-		}
+	if node := f.node(); len(node) != 0 {
+		(substrings) = (append(substrings, fmt.Sprintf("for=%s", tokenOrQuoted(node))))
 	}
 	if !reflect.ValueOf(f.Host).IsZero() {
-		(substrings) = (append(substrings, fmt.Sprintf("host=%s", f.Host)))
+		(substrings) = (append(substrings, fmt.Sprintf("host=%s", tokenOrQuoted(f.Host))))
 	}
-	(s) = (strings.Join(substrings, "; "))
+	if !reflect.ValueOf(f.Proto).IsZero() {
+		(substrings) = (append(substrings, fmt.Sprintf("proto=%s", tokenOrQuoted(f.Proto))))
+	}
+	(s) = (strings.Join(substrings, ";"))
 	return s
 }
 
@@ -1361,13 +1497,23 @@ func (l LastModifiedHeader) String() string {
 }
 
 // LinkHeader is a struct to prepare a Link HTTP header.
+//
+// The URL field is deprecated: it adds a link without any relation type, which can be written as a LinkValue.
 type LinkHeader struct {
-	URL url.URL `json:"url"`
+	Links []LinkValue `json:"links"`
+	URL   url.URL     `json:"url"`
 }
 
 // String returns a string representation of a Link HTTP header.
 func (l LinkHeader) String() string {
-	return (l.URL.String())
+	var substrings ([]string) = (make([]string, 0, len(l.Links)+1))
+	if !reflect.ValueOf(l.URL).IsZero() {
+		(substrings) = (append(substrings, LinkValue{Target: l.URL}.String()))
+	}
+	for _, link := range l.Links {
+		(substrings) = (append(substrings, link.String()))
+	}
+	return (strings.Join(substrings, ", "))
 }
 
 // LocationHeader is a struct to prepare a Location HTTP header.
@@ -1387,7 +1533,7 @@ type OriginHeader struct {
 
 // String returns a string representation of a Origin HTTP header.
 func (o OriginHeader) String() string {
-	return (o.URL.String())
+	return (OriginOf(&o.URL).String())
 }
 
 // PragmaHeader is a struct to prepare a Pragma HTTP header.
@@ -1412,7 +1558,7 @@ func (p ProxyAuthenticateHeader) String() string {
 		(substrings) = (append(substrings, p.Type))
 	}
 	if !reflect.ValueOf(p.Realm).IsZero() {
-		(substrings) = (append(substrings, fmt.Sprintf("realm=%s", p.Realm)))
+		(substrings) = (append(substrings, fmt.Sprintf("realm=%s", tokenOrQuoted(p.Realm))))
 	}
 	(s) = (strings.Join(substrings, " "))
 	return s
@@ -1456,10 +1602,10 @@ func (p PublicKeyPinsHeader) String() string {
 		(substrings) = (append(substrings, fmt.Sprintf("max-age=%d", p.MaxAge)))
 	}
 	if !reflect.ValueOf(p.PinSHA256).IsZero() {
-		(substrings) = (append(substrings, fmt.Sprintf("pin-sha256=\"%s\"", p.PinSHA256)))
+		(substrings) = (append(substrings, fmt.Sprintf("pin-sha256=%s", quoteString(p.PinSHA256))))
 	}
 	if !reflect.ValueOf(p.ReportURI).IsZero() {
-		(substrings) = (append(substrings, fmt.Sprintf("report-uri=\"%s\"", p.ReportURI.String())))
+		(substrings) = (append(substrings, fmt.Sprintf("report-uri=%s", quoteString(p.ReportURI.String()))))
 	}
 	(s) = (strings.Join(substrings, "; "))
 	return s
@@ -1483,10 +1629,10 @@ func (p PublicKeyPinsReporyOnlyHeader) String() string {
 		(substrings) = (append(substrings, fmt.Sprintf("max-age=%d", p.MaxAge)))
 	}
 	if !reflect.ValueOf(p.PinSHA256).IsZero() {
-		(substrings) = (append(substrings, fmt.Sprintf("pin-sha256=\"%s\"", p.PinSHA256)))
+		(substrings) = (append(substrings, fmt.Sprintf("pin-sha256=%s", quoteString(p.PinSHA256))))
 	}
 	if !reflect.ValueOf(p.ReportURI).IsZero() {
-		(substrings) = (append(substrings, fmt.Sprintf("report-uri=\"%s\"", p.ReportURI.String())))
+		(substrings) = (append(substrings, fmt.Sprintf("report-uri=%s", quoteString(p.ReportURI.String()))))
 	}
 	(s) = (strings.Join(substrings, "; "))
 	return s
@@ -1534,6 +1680,8 @@ func (r RefererHeader) String() string {
 }
 
 // ReferrerPolicyHeader is a struct to prepare a Referrer Policy HTTP header.
+//
+// Deprecated: use ReferrerPolicyValue, which cannot hold more than one policy.
 type ReferrerPolicyHeader struct {
 	NoReferrer                  bool `json:"no_referrer"`
 	NoReferrerWhenDowngrade     bool `json:"no_referrer_when_downgrade"`
@@ -1566,6 +1714,20 @@ func (r ReferrerPolicyHeader) String() string {
 	return s
 }
 
+// Value returns the ReferrerPolicyValue of a Referrer-Policy HTTP header, or an empty ReferrerPolicyValue
+// when no policy is set. An error is returned when more than one policy is set.
+func (r ReferrerPolicyHeader) Value() (ReferrerPolicyValue, error) {
+	var s, err = structValue(ReferrerPolicy, r, map[string]string{
+		"NoReferrer":                  "no-referrer",
+		"NoReferrerWhenDowngrade":     "no-referrer-when-downgrade",
+		"Origin":                      "origin",
+		"OriginWhenCrossOrigin":       "origin-when-cross-origin",
+		"SameOrigin":                  "same-origin",
+		"StrictOrigin":                "strict-origin",
+		"StrictOriginWhenCrossOrigin": "strict-origin-when-cross-origin"})
+	return ReferrerPolicyValue(s), err
+}
+
 // RetryAfterHeader is a struct to prepare a Retry-After HTTP header.
 type RetryAfterHeader struct {
 	Seconds int64     `json:"seconds"`
@@ -1594,6 +1756,8 @@ func (s SaveDataHeader) String() string {
 }
 
 // SecFetchDestHeader is a struct to prepare a Sec-Fetch-Dest HTTP header.
+//
+// Deprecated: use SecFetchDestValue, which cannot hold more than one destination.
 type SecFetchDestHeader struct {
 	Audio          bool `json:"audio"`
 	Audioworklet   bool `json:"audioworklet"`
@@ -1639,7 +1803,36 @@ func (s SecFetchDestHeader) String() string {
 	return ss
 }
 
+// Value returns the SecFetchDestValue of a Sec-Fetch-Dest HTTP header, or an empty SecFetchDestValue
+// when no destination is set. An error is returned when more than one destination is set.
+func (s SecFetchDestHeader) Value() (SecFetchDestValue, error) {
+	var v, err = structValue(SecFetchDest, s, map[string]string{
+		"Audio":          "audio",
+		"Audioworklet":   "audioworklet",
+		"Document":       "document",
+		"Embed":          "embed",
+		"Empty":          "empty",
+		"Font":           "font",
+		"Image":          "image",
+		"Manifest":       "manifest",
+		"NestedDocument": "iframe",
+		"Object":         "object",
+		"Paintworklet":   "paintworklet",
+		"Report":         "report",
+		"Script":         "script",
+		"Serviceworker":  "serviceworker",
+		"Sharedworker":   "sharedworker",
+		"Style":          "style",
+		"Track":          "track",
+		"Video":          "video",
+		"Worker":         "worker",
+		"Xslt":           "xslt"})
+	return SecFetchDestValue(v), err
+}
+
 // SecFetchModeHeader is a struct to preparea Sec-Fetch-Mode HTTP header.
+//
+// Deprecated: use SecFetchModeValue, which cannot hold more than one mode.
 type SecFetchModeHeader struct {
 	Cors           bool `json:"cors"`
 	Navigate       bool `json:"navigate"`
@@ -1671,7 +1864,22 @@ func (s SecFetchModeHeader) String() string {
 	return ss
 }
 
+// Value returns the SecFetchModeValue of a Sec-Fetch-Mode HTTP header, or an empty SecFetchModeValue
+// when no mode is set. An error is returned when more than one mode is set.
+func (s SecFetchModeHeader) Value() (SecFetchModeValue, error) {
+	var v, err = structValue(SecFetchMode, s, map[string]string{
+		"Cors":           "cors",
+		"Navigate":       "navigate",
+		"NestedNavigate": "navigate",
+		"NoCors":         "no-cors",
+		"SameOrigin":     "same-origin",
+		"WebSocket":      "websocket"})
+	return SecFetchModeValue(v), err
+}
+
 // SecFetchSiteHeader is a struct to prepare a Sec-Fetch-Site HTTP header.
+//
+// Deprecated: use SecFetchSiteValue, which cannot hold more than one site.
 type SecFetchSiteHeader struct {
 	CrossSite  bool `json:"cross_site"`
 	None       bool `json:"none"`
@@ -1701,6 +1909,17 @@ func (s SecFetchSiteHeader) String() string {
 	return ss
 }
 
+// Value returns the SecFetchSiteValue of a Sec-Fetch-Site HTTP header, or an empty SecFetchSiteValue
+// when no site is set. An error is returned when more than one site is set.
+func (s SecFetchSiteHeader) Value() (SecFetchSiteValue, error) {
+	var v, err = structValue(SecFetchSite, s, map[string]string{
+		"CrossSite":  "cross-site",
+		"None":       "none",
+		"SameOrigin": "same-origin",
+		"SameSite":   "same-site"})
+	return SecFetchSiteValue(v), err
+}
+
 // SecFetchUserHeader is a struct to prepare a Sec-Fetch-User HTTP header.
 type SecFetchUserHeader struct {
 	Activated bool `json:"activated"`
@@ -1708,10 +1927,7 @@ type SecFetchUserHeader struct {
 
 // String returns a string representation of a Sec-Fetch-User HTTP header.
 func (s SecFetchUserHeader) String() string {
-	if s.Activated {
-		return "?1"
-	}
-	return "?0"
+	return (sfv.Item{Value: s.Activated}.String())
 }
 
 // SecWebSocketAcceptHeader is a struct to prepare Sec-Web-Socket-Accept HTTP header.
@@ -1735,10 +1951,32 @@ func (s ServerHeader) String() string {
 }
 
 // ServerTimingHeader is a struct to prepare a Server-Timing HTTP header.
+//
+// The Cache, CPU and MissedCache fields are deprecated: they add metrics without a duration or description, which
+// can be written as ServerTimingMetrics.
 type ServerTimingHeader struct {
-	Cache       bool `json:"cache"`
-	CPU         bool `json:"cpu"`
-	MissedCache bool `json:"missed_cache"`
+	Cache       bool                 `json:"cache"`
+	CPU         bool                 `json:"cpu"`
+	Metrics     []ServerTimingMetric `json:"metrics"`
+	MissedCache bool                 `json:"missed_cache"`
+}
+
+// String returns a string representation of a Server-Timing HTTP header.
+func (s ServerTimingHeader) String() string {
+	var substrings ([]string) = (make([]string, 0, len(s.Metrics)+3))
+	if s.Cache {
+		(substrings) = (append(substrings, "cache"))
+	}
+	if s.CPU {
+		(substrings) = (append(substrings, "cpu"))
+	}
+	if s.MissedCache {
+		(substrings) = (append(substrings, "missedCache"))
+	}
+	for _, m := range s.Metrics {
+		(substrings) = (append(substrings, m.String()))
+	}
+	return (strings.Join(substrings, ", "))
 }
 
 // SetCookieHeader is a struct to prepare a Set-Cookie HTTP header.
@@ -1824,6 +2062,21 @@ type TimingAllowOriginHeader struct {
 	Origins []url.URL `json:"origins"`
 }
 
+// Allows reports whether a Timing-Allow-Origin HTTP header allows the serialised origin of a request to see
+// timing information. An origin is allowed when it is listed or when the header lists *.
+func (t TimingAllowOriginHeader) Allows(origin string) bool {
+	var o, err = ParseOrigin(origin)
+	for _, u := range t.Origins {
+		if u.String() == "*" {
+			return true
+		}
+		if err == nil && OriginOf(&u).SameOrigin(o) {
+			return true
+		}
+	}
+	return false
+}
+
 // String returns a string representation of a Timing-Allow-Origin HTTP header.
 func (t TimingAllowOriginHeader) String() string {
 	var substrings ([]string) = (make([]string, 0))
@@ -1836,6 +2089,8 @@ func (t TimingAllowOriginHeader) String() string {
 }
 
 // TkHeader is a struct to prepare a Tk HTTP header.
+//
+// Deprecated: use TkValue, which cannot hold more than one tracking status.
 type TkHeader struct {
 	DisregardingDoNotTrack bool `json:"disregarding_do_not_track"`
 	Dynamic                bool `json:"dynamic"`
@@ -1870,6 +2125,25 @@ func (t TkHeader) String() string {
 	return "!"
 }
 
+// Value returns the TkValue of a Tk HTTP header. A TkHeader without a tracking status is under construction.
+// An error is returned when more than one tracking status is set.
+func (t TkHeader) Value() (TkValue, error) {
+	var s, err = structValue(Tk, t, map[string]string{
+		"DisregardingDoNotTrack": "D",
+		"Dynamic":                "?",
+		"Gateway":                "G",
+		"NotTracking":            "N",
+		"PotentialConsent":       "P",
+		"Tracking":               "T",
+		"TrackingWithConsent":    "C",
+		"UnderConstruction":      "!",
+		"Updated":                "U"})
+	if len(s) == 0 && err == nil {
+		return TkUnderConstruction, nil
+	}
+	return TkValue(s), err
+}
+
 // TrailerHeader is a struct to prepare a Trailer HTTP header.
 type TrailerHeader struct {
 	Headers []string `json:"headers"`
@@ -1883,21 +2157,24 @@ func (t TrailerHeader) String() string {
 // TransferHeader is a struct to prepare a Transfer HTTP header.
 type TransferHeader struct{}
 
-// TransferEncodingHeader is a struct to prepare a Transfer-Encoding HTTP header.
+// TransferEncodingHeader is a struct to prepare a Transfer-Encoding HTTP header. Codings lists the transfer codings in
+// the order they were applied and takes precedence over the bool fields, which are sent with chunked last, as it must
+// be.
 type TransferEncodingHeader struct {
-	Chunked  bool `json:"chunked"`
-	Compress bool `json:"compress"`
-	Deflate  bool `json:"delate"`
-	GZip     bool `json:"gzip"`
-	Identity bool `json:"identity"`
+	Chunked  bool     `json:"chunked"`
+	Codings  []string `json:"codings"`
+	Compress bool     `json:"compress"`
+	Deflate  bool     `json:"delate"`
+	GZip     bool     `json:"gzip"`
+	Identity bool     `json:"identity"`
 }
 
 // String returns a string representation of a Transfer-Encoding HTTP header.
 func (t TransferEncodingHeader) String() string {
-	var substrings ([]string) = (make([]string, 0))
-	if t.Chunked {
-		substrings = append(substrings, "chunked")
+	if len(t.Codings) != 0 {
+		return (strings.Join(t.Codings, ", "))
 	}
+	var substrings ([]string) = (make([]string, 0))
 	if t.Compress {
 		substrings = append(substrings, "compress")
 	}
@@ -1910,6 +2187,9 @@ func (t TransferEncodingHeader) String() string {
 	if t.Identity {
 		substrings = append(substrings, "identity")
 	}
+	if t.Chunked {
+		substrings = append(substrings, "chunked")
+	}
 	return strings.Join(substrings, ", ")
 }
 
@@ -1946,12 +2226,15 @@ func (v VaryHeader) String() string {
 	return strings.Join(v.Headers, ", ")
 }
 
-// ViaHeader is a struct to prepare a Via HTTP header.
+// ViaHeader is a struct to prepare a hop of a Via HTTP header. ProtocolName is left out of the received-protocol
+// when it is empty, as it is for HTTP. The recipient is Host and Port when Host is not empty, and Pseudonym
+// otherwise.
 type ViaHeader struct {
-	ProtocolName    string `json:"protocol_name"`
-	ProtocolVersion string `json:"protocol_version"`
+	Comment         string `json:"comment"`
 	Host            string `json:"host"`
 	Port            string `json:"port"`
+	ProtocolName    string `json:"protocol_name"`
+	ProtocolVersion string `json:"protocol_version"`
 	Pseudonym       string `json:"pseudonym"`
 }
 
@@ -1959,23 +2242,13 @@ type ViaHeader struct {
 func (v ViaHeader) String() string {
 	var substrings = (make([]string, 0))
 	if !reflect.ValueOf(v.ProtocolName).IsZero() {
-		substrings = append(substrings, v.ProtocolName)
-	}
-	if !reflect.ValueOf(v.ProtocolVersion).IsZero() {
+		substrings = append(substrings, v.ProtocolName+"/"+v.ProtocolVersion)
+	} else {
 		substrings = append(substrings, v.ProtocolVersion)
 	}
-	if !reflect.ValueOf(v.Host).IsZero() || !reflect.ValueOf(v.Port).IsZero() {
-		var s = (make([]string, 0))
-		if !reflect.ValueOf(v.Host).IsZero() {
-			s = append(s, v.Host)
-		}
-		if !reflect.ValueOf(v.Port).IsZero() {
-			s = append(s, v.Port)
-		}
-		substrings = append(substrings, strings.Join(s, ":"))
-	}
-	if !reflect.ValueOf(v.Pseudonym).IsZero() {
-		substrings = append(substrings, v.Pseudonym)
+	substrings = append(substrings, v.recipient())
+	if !reflect.ValueOf(v.Comment).IsZero() {
+		substrings = append(substrings, "("+commentReplacer.Replace(v.Comment)+")")
 	}
 	return strings.Join(substrings, " ")
 }